@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"stakeway_test_task/internal/deposit"
+	"stakeway_test_task/internal/eth2"
+)
+
+// confirmAmounts asks for explicit confirmation when any deposit differs from 32 ETH, or for compounding
+// credentials falls outside 32 to 2048 ETH: smaller deposits do not activate a new validator on their own,
+// and any deposit for an existing validator is a top-up.
+func confirmAmounts(depositDataList []deposit.Data, in io.Reader, out io.Writer) (bool, error) {
+	var nonStandard []string
+	for i, depositData := range depositDataList {
+		if !standardAmount(depositData) {
+			nonStandard = append(nonStandard, fmt.Sprintf("  deposit %d (%s): %s ETH", i, depositData.Pubkey, deposit.FormatGwei(depositData.Amount)))
+		}
+	}
+	if len(nonStandard) == 0 {
+		return true, nil
+	}
+
+	fmt.Fprintf(out, "The following deposits differ from %s ETH (up to %s ETH with compounding credentials) and will only be useful as top-ups of existing validators or partial deposits:\n", deposit.FormatGwei(eth2.MaxEffectiveBalance), deposit.FormatGwei(eth2.MaxEffectiveBalanceElectra))
+	for _, line := range nonStandard {
+		fmt.Fprintln(out, line)
+	}
+	fmt.Fprint(out, "Type 'yes' to continue: ")
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	return strings.TrimSpace(answer) == "yes", nil
+}
+
+func standardAmount(depositData deposit.Data) bool {
+	if strings.HasPrefix(strings.TrimPrefix(depositData.WithdrawalCredentials, "0x"), "02") {
+		return depositData.Amount >= eth2.MaxEffectiveBalance && depositData.Amount <= eth2.MaxEffectiveBalanceElectra
+	}
+	return depositData.Amount == eth2.MaxEffectiveBalance
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"stakeway_test_task/internal/deposit"
+)
+
+func TestConfirmAmounts(t *testing.T) {
+	standard := []deposit.Data{{Pubkey: "aa", Amount: 32000000000}}
+	topUp := []deposit.Data{{Pubkey: "aa", Amount: 32000000000}, {Pubkey: "bb", Amount: 2000000000}}
+
+	t.Run("no confirmation for 32 ETH deposits", func(t *testing.T) {
+		var out bytes.Buffer
+		ok, err := confirmAmounts(standard, strings.NewReader(""), &out)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Empty(t, out.String())
+	})
+
+	t.Run("confirmed top-up", func(t *testing.T) {
+		var out bytes.Buffer
+		ok, err := confirmAmounts(topUp, strings.NewReader("yes\n"), &out)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Contains(t, out.String(), "deposit 1 (bb): 2 ETH")
+		assert.NotContains(t, out.String(), "deposit 0")
+	})
+
+	t.Run("compounding deposits", func(t *testing.T) {
+		compounding := "020000000000000000000000e8011087b85953f2b0816a236876a9779462f10b"
+		depositDataList := []deposit.Data{
+			{Pubkey: "aa", WithdrawalCredentials: compounding, Amount: 2048000000000},
+			{Pubkey: "bb", WithdrawalCredentials: compounding, Amount: 4096000000000},
+		}
+
+		var out bytes.Buffer
+		ok, err := confirmAmounts(depositDataList, strings.NewReader("no\n"), &out)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Contains(t, out.String(), "deposit 1 (bb): 4096 ETH")
+		assert.NotContains(t, out.String(), "deposit 0")
+	})
+
+	t.Run("declined top-up", func(t *testing.T) {
+		ok, err := confirmAmounts(topUp, strings.NewReader("no\n"), &bytes.Buffer{})
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"stakeway_test_task/internal/deposit"
+)
+
+func runBroadcast(args []string) {
+	fs := flag.NewFlagSet("broadcast", flag.ExitOnError)
+	var netFlags networkFlags
+	netFlags.register(fs)
+	var dupFlags duplicateFlags
+	dupFlags.register(fs)
+	wait := fs.Bool("wait", true, "wait for every transaction to be mined")
+	var outFlags outputFlags
+	outFlags.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s broadcast [flags] [FILE]\n\nSubmits transactions produced by -offline, read from FILE or stdin.\n\nFlags:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	network, rpcURL, err := netFlags.resolve()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := outFlags.check(); err != nil {
+		log.Fatal(err)
+	}
+	out := outFlags.progress()
+
+	in := io.Reader(os.Stdin)
+	if fs.NArg() > 0 && fs.Arg(0) != "-" {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			log.Fatalf("Error opening transactions: %v", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	txs, err := deposit.ReadTxs(in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := deposit.CheckTxs(txs, network.ChainID, network.DepositContract); err != nil {
+		log.Fatalf("Refusing to broadcast: %v", err)
+	}
+
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		log.Fatalf("Error connecting to %s: %v", rpcURL, err)
+	}
+
+	ctx := context.Background()
+	chainID, err := client.ChainID(ctx)
+	if err != nil {
+		log.Fatalf("Error getting chain ID: %v", err)
+	}
+	if chainID.Uint64() != network.ChainID {
+		log.Fatalf("RPC %s serves chain ID %d, but the %s profile expects chain ID %d", rpcURL, chainID, network.Name, network.ChainID)
+	}
+
+	candidates, err := deposit.TxCandidates(txs)
+	if err != nil {
+		log.Fatalf("Refusing to broadcast: %v", err)
+	}
+	checker, err := dupFlags.checker(client, network, out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	duplicates, err := deposit.ReportDuplicates(ctx, checker, candidates, out)
+	if err != nil {
+		log.Fatalf("Error checking for duplicate deposits: %v", err)
+	}
+	if duplicates > 0 && !dupFlags.allow {
+		log.Fatalf("Refusing to broadcast deposits for %d pubkey(s) that already have a deposit, rerun with -allow-duplicates if these are intentional top-ups", duplicates)
+	}
+
+	s, err := deposit.NewSubmitter(client, nil, network, deposit.FeeSettings{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.Confirmations = outFlags.confirmations
+
+	results := make([]deposit.Result, len(txs))
+	var failures []error
+	fail := func(i int, err error) {
+		results[i].Status = deposit.StatusFailed
+		results[i].Error = err.Error()
+		failures = append(failures, fmt.Errorf("transaction %d (%s): %w", i, txs[i].Hash().Hex(), err))
+		fmt.Fprintf(out, "[%d/%d] %s: FAILED: %v\n", i+1, len(txs), txs[i].Hash().Hex(), err)
+	}
+
+	for i, tx := range txs {
+		results[i] = deposit.Result{Index: i, Pubkey: candidates[i].Pubkey, TxHash: tx.Hash().Hex()}
+		if err := s.Broadcast(ctx, tx); err != nil {
+			fail(i, err)
+			continue
+		}
+		results[i].Status = deposit.StatusSent
+		fmt.Fprintf(out, "[%d/%d] %s: sent (nonce %d)\n", i+1, len(txs), tx.Hash().Hex(), tx.Nonce())
+	}
+
+	if *wait {
+		for i, tx := range txs {
+			if results[i].Status != deposit.StatusSent {
+				continue
+			}
+			receipt, err := s.Confirm(ctx, tx, &results[i])
+			if err != nil {
+				fail(i, err)
+				continue
+			}
+			results[i].Status = deposit.StatusConfirmed
+			fmt.Fprintf(out, "[%d/%d] %s: confirmed in block %d, gas used %d\n", i+1, len(txs), tx.Hash().Hex(), receipt.BlockNumber, receipt.GasUsed)
+		}
+	}
+
+	if err := outFlags.writeResults(os.Stdout, results); err != nil {
+		log.Fatalf("Error writing results: %v", err)
+	}
+	if err := errors.Join(failures...); err != nil {
+		log.Fatalf("Some transactions failed:\n%v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"stakeway_test_task/internal/beacon"
+	"stakeway_test_task/internal/deposit"
+	"stakeway_test_task/internal/eth2"
+)
+
+const (
+	duplicateCheckLogs   = deposit.SourceLogs
+	duplicateCheckBeacon = deposit.SourceBeacon
+	duplicateCheckBoth   = "both"
+	duplicateCheckNone   = "none"
+)
+
+type duplicateFlags struct {
+	mode      string
+	beaconURL string
+	scanFrom  int64
+	allow     bool
+}
+
+func (f *duplicateFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.mode, "check-duplicates", duplicateCheckLogs, "how to detect pubkeys that were already deposited: logs (DepositEvent logs), beacon (-beacon node), both or none")
+	fs.StringVar(&f.beaconURL, "beacon", "", "beacon node REST API URL used by -check-duplicates beacon/both")
+	fs.Int64Var(&f.scanFrom, "scan-from", -1, "first block scanned for DepositEvent logs (default: deposit contract deployment block of the network)")
+	fs.BoolVar(&f.allow, "allow-duplicates", false, "only warn about pubkeys that were already deposited, e.g. for intentional top-ups")
+}
+
+func (f *duplicateFlags) checker(logs deposit.LogReader, network *eth2.Network, progress io.Writer) (*deposit.DuplicateChecker, error) {
+	c := &deposit.DuplicateChecker{
+		Contract:  network.DepositContract,
+		FromBlock: network.DepositContractBlock,
+		Progress:  progress,
+	}
+	if f.scanFrom >= 0 {
+		c.FromBlock = uint64(f.scanFrom)
+	}
+
+	switch f.mode {
+	case duplicateCheckNone:
+		return nil, nil
+	case duplicateCheckLogs:
+		c.Logs = logs
+	case duplicateCheckBeacon, duplicateCheckBoth:
+		if f.beaconURL == "" {
+			return nil, fmt.Errorf("-check-duplicates %s requires -beacon", f.mode)
+		}
+		c.Beacon = beacon.NewClient(f.beaconURL)
+		if f.mode == duplicateCheckBoth {
+			c.Logs = logs
+		}
+	default:
+		return nil, fmt.Errorf("unknown -check-duplicates mode %q", f.mode)
+	}
+	return c, nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"stakeway_test_task/internal/eth2"
+)
+
+func TestDuplicateFlags(t *testing.T) {
+	holesky, err := eth2.NetworkByName("holesky")
+	require.NoError(t, err)
+
+	_, err = (&duplicateFlags{mode: duplicateCheckBeacon}).checker(nil, holesky, nil)
+	assert.Error(t, err)
+
+	_, err = (&duplicateFlags{mode: "sometimes"}).checker(nil, holesky, nil)
+	assert.Error(t, err)
+
+	c, err := (&duplicateFlags{mode: duplicateCheckNone}).checker(nil, holesky, nil)
+	require.NoError(t, err)
+	assert.Nil(t, c)
+
+	c, err = (&duplicateFlags{mode: duplicateCheckLogs, scanFrom: -1}).checker(nil, holesky, nil)
+	require.NoError(t, err)
+	assert.Equal(t, holesky.DepositContract, c.Contract)
+}
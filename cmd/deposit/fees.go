@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"stakeway_test_task/internal/deposit"
+)
+
+// feeFlags holds the user-supplied fee overrides, in gwei. Empty fees and a zero gas limit mean "ask the node".
+type feeFlags struct {
+	maxFee         string
+	maxPriorityFee string
+	gasLimit       uint64
+	gasMargin      uint64
+}
+
+func (f *feeFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.maxFee, "max-fee", "", "max fee per gas in gwei (default: 2 * base fee + priority fee)")
+	fs.StringVar(&f.maxPriorityFee, "max-priority-fee", "", "max priority fee per gas in gwei (default: suggested by the node)")
+	fs.Uint64Var(&f.gasLimit, "gas-limit", 0, "gas limit of each deposit transaction (default: estimated)")
+	fs.Uint64Var(&f.gasMargin, "gas-margin", 20, "safety margin added to the estimated gas, in percent")
+}
+
+func (f *feeFlags) settings() (deposit.FeeSettings, error) {
+	settings := deposit.FeeSettings{GasLimit: f.gasLimit, GasMargin: f.gasMargin}
+
+	var err error
+	if f.maxFee != "" {
+		if settings.MaxFee, err = deposit.ParseGwei(f.maxFee); err != nil {
+			return deposit.FeeSettings{}, fmt.Errorf("invalid -max-fee: %w", err)
+		}
+	}
+	if f.maxPriorityFee != "" {
+		if settings.MaxPriorityFee, err = deposit.ParseGwei(f.maxPriorityFee); err != nil {
+			return deposit.FeeSettings{}, fmt.Errorf("invalid -max-priority-fee: %w", err)
+		}
+	}
+	if settings.MaxFee != nil && settings.MaxPriorityFee != nil && settings.MaxPriorityFee.Cmp(settings.MaxFee) > 0 {
+		return deposit.FeeSettings{}, errors.New("-max-priority-fee cannot exceed -max-fee")
+	}
+	return settings, nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeeFlags(t *testing.T) {
+	t.Run("fee overrides", func(t *testing.T) {
+		settings, err := (&feeFlags{maxFee: "30", maxPriorityFee: "1.5", gasLimit: 100000, gasMargin: 20}).settings()
+		require.NoError(t, err)
+		assert.Equal(t, "30000000000", settings.MaxFee.String())
+		assert.Equal(t, "1500000000", settings.MaxPriorityFee.String())
+		assert.Equal(t, uint64(100000), settings.GasLimit)
+	})
+
+	t.Run("suggested fees", func(t *testing.T) {
+		settings, err := (&feeFlags{}).settings()
+		require.NoError(t, err)
+		assert.Nil(t, settings.MaxFee)
+		assert.Nil(t, settings.MaxPriorityFee)
+	})
+
+	t.Run("invalid fee", func(t *testing.T) {
+		_, err := (&feeFlags{maxFee: "abc"}).settings()
+		assert.ErrorContains(t, err, "-max-fee")
+	})
+
+	t.Run("priority fee above max fee", func(t *testing.T) {
+		_, err := (&feeFlags{maxFee: "1", maxPriorityFee: "2"}).settings()
+		assert.Error(t, err)
+	})
+}
@@ -2,175 +2,224 @@ package main
 
 import (
 	"context"
-	"crypto/ecdsa"
-	"encoding/hex"
-	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
-	"math/big"
 	"os"
 
-	"github.com/ethereum/go-ethereum/accounts/abi"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
-	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/core/types"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
-	"strings"
-)
-
-type DepositData struct {
-	Pubkey                string `json:"pubkey"`
-	WithdrawalCredentials string `json:"withdrawal_credentials"`
-	Amount                uint64 `json:"amount"`
-	Signature             string `json:"signature"`
-	DepositMessageRoot    string `json:"deposit_message_root"`
-	DepositDataRoot       string `json:"deposit_data_root"`
-	ForkVersion           string `json:"fork_version"`
-	NetworkName           string `json:"network_name"`
-}
 
-const depositContractABI = `[
-	{
-		"inputs": [
-			{"name": "pubkey", "type": "bytes"},
-			{"name": "withdrawal_credentials", "type": "bytes"},
-			{"name": "signature", "type": "bytes"},
-			{"name": "deposit_data_root", "type": "bytes32"}
-		],
-		"name": "deposit",
-		"outputs": [],
-		"stateMutability": "payable",
-		"type": "function"
-	}
-]`
+	"stakeway_test_task/internal/deposit"
+)
 
 func main() {
-	if len(os.Args) < 2 {
-		log.Fatal("Please set private key as an argument")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "verify":
+			runVerify(os.Args[2:])
+			return
+		case "broadcast":
+			runBroadcast(os.Args[2:])
+			return
+		}
+	}
+
+	var netFlags networkFlags
+	netFlags.register(flag.CommandLine)
+	var sigFlags signerFlags
+	sigFlags.register(flag.CommandLine)
+	depositFile := flag.String("file", "deposit_data.json", "deposit data file produced by the staking deposit CLI")
+	var fFlags feeFlags
+	fFlags.register(flag.CommandLine)
+	var dupFlags duplicateFlags
+	dupFlags.register(flag.CommandLine)
+	concurrency := flag.Int("concurrency", 1, "number of deposits to submit and await in parallel")
+	stateFile := flag.String("state", "deposit_state.json", "file tracking submitted deposits, used to resume an interrupted batch")
+	dryRun := flag.Bool("dry-run", false, "print the transactions that would be sent without signing or sending them")
+	offline := flag.Bool("offline", false, "sign the transactions without a node connection and write them out instead of sending them")
+	nonceFlag := flag.Int64("nonce", -1, "nonce of the first transaction (required with -offline, default: pending nonce of the account)")
+	txFormat := flag.String("tx-format", deposit.TxFormatHex, "format of the transactions written by -offline: hex (one raw transaction per line) or json")
+	txOut := flag.String("tx-out", "-", "file the transactions signed by -offline are written to, - for stdout")
+	var outFlags outputFlags
+	outFlags.register(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n  %[1]s [flags]\n  %[1]s verify [flags] [deposit_data.json]\n  %[1]s broadcast [flags] [FILE]\n\nThe funding account is loaded from -keystore, -signer or the %[2]s environment variable.\n\nFlags:\n", os.Args[0], privateKeyEnv)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() > 0 {
+		log.Fatalf("Unexpected argument %q: the private key is no longer accepted on the command line, use -keystore, -signer or %s", flag.Arg(0), privateKeyEnv)
+	}
+
+	if *dryRun && *offline {
+		log.Fatal("-dry-run and -offline cannot be used together")
+	}
+	if *offline && *nonceFlag < 0 {
+		log.Fatal("-offline requires -nonce")
+	}
+	if *txFormat != deposit.TxFormatHex && *txFormat != deposit.TxFormatJSON {
+		log.Fatalf("Unknown -tx-format %q, expected %s or %s", *txFormat, deposit.TxFormatHex, deposit.TxFormatJSON)
+	}
+	if err := outFlags.check(); err != nil {
+		log.Fatal(err)
+	}
+	if outFlags.format == outputJSON && (*dryRun || *offline) {
+		log.Fatalf("-output %s reports submitted deposits and cannot be used with -dry-run or -offline", outputJSON)
+	}
+
+	network, rpcURL, err := netFlags.resolve()
+	if err != nil {
+		log.Fatal(err)
 	}
-	privateKeyHex := os.Args[1]
 
-	if !strings.HasPrefix(privateKeyHex, "0x") {
-		privateKeyHex = "0x" + privateKeyHex
+	fees, err := fFlags.settings()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *offline {
+		if _, err := fees.FixedCaps(); err != nil {
+			log.Fatal("-offline requires -max-fee, -max-priority-fee and -gas-limit")
+		}
 	}
-	privateKeyHex = strings.TrimPrefix(privateKeyHex, "0x")
 
-	jsonFile, err := os.ReadFile("deposit_data.json")
+	signer, err := sigFlags.load()
 	if err != nil {
-		log.Fatalf("Error reading file: %v", err)
+		log.Fatalf("Error loading signer: %v", err)
 	}
 
-	var depositDataList []DepositData
-	if err := json.Unmarshal(jsonFile, &depositDataList); err != nil {
-		log.Fatalf("Error parsing JSON: %v", err)
+	depositDataList, err := deposit.Load(*depositFile)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	if len(depositDataList) == 0 {
-		log.Fatal("File does not contain any deposit data")
+	if err := deposit.VerifyAll(depositDataList, network); err != nil {
+		log.Fatalf("Refusing to submit invalid deposit data: %v", err)
 	}
 
-	depositData := depositDataList[0]
+	if *offline {
+		// All transaction fields come from flags, so the submitter never needs a backend.
+		s, err := deposit.NewSubmitter(nil, signer, network, fees)
+		if err != nil {
+			log.Fatal(err)
+		}
+		runOffline(s, signer, depositDataList, uint64(*nonceFlag), *txFormat, *txOut)
+		return
+	}
 
-	client, err := ethclient.Dial("https://ethereum-holesky.publicnode.com")
+	client, err := ethclient.Dial(rpcURL)
 	if err != nil {
-		log.Fatalf("Error connecting to Holesky: %v", err)
+		log.Fatalf("Error connecting to %s: %v", rpcURL, err)
 	}
-
-	privateKey, err := crypto.HexToECDSA(privateKeyHex)
+	s, err := deposit.NewSubmitter(client, signer, network, fees)
 	if err != nil {
-		log.Fatalf("Error parsing private key: %v", err)
+		log.Fatal(err)
 	}
 
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		log.Fatal("Error casting public key to ECDSA")
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		log.Fatalf("Error getting chain ID: %v", err)
 	}
-	fromAddress := crypto.PubkeyToAddress(*publicKeyECDSA)
 
-	nonce, err := client.PendingNonceAt(context.Background(), fromAddress)
-	if err != nil {
-		log.Fatalf("Error getting nonce: %v", err)
+	if chainID.Uint64() != network.ChainID {
+		log.Fatalf("RPC %s serves chain ID %d, but the %s profile expects chain ID %d", rpcURL, chainID, network.Name, network.ChainID)
 	}
 
-	gasPrice, err := client.SuggestGasPrice(context.Background())
+	s.Out = outFlags.progress()
+	s.Confirmations = outFlags.confirmations
+
+	checker, err := dupFlags.checker(client, network, s.Out)
 	if err != nil {
-		log.Fatalf("Error getting gas price: %v", err)
+		log.Fatal(err)
 	}
 
-	depositContractAddress := common.HexToAddress("0x4242424242424242424242424242424242424242")
+	if *dryRun {
+		if _, err := deposit.ReportDuplicates(context.Background(), checker, deposit.NewCandidates(depositDataList, nil), os.Stdout); err != nil {
+			log.Fatalf("Error checking for duplicate deposits: %v", err)
+		}
 
-	pubkeyBytes, err := hex.DecodeString(strings.TrimPrefix(depositData.Pubkey, "0x"))
-	if err != nil {
-		log.Fatalf("Error decoding pubkey: %v", err)
+		nonce := uint64(*nonceFlag)
+		if *nonceFlag < 0 {
+			nonce, err = client.PendingNonceAt(context.Background(), signer.Address())
+			if err != nil {
+				log.Fatalf("Error getting nonce: %v", err)
+			}
+		}
+
+		fmt.Printf("Dry run: %d deposit(s) from %s to %s deposit contract %s, nothing will be sent\n", len(depositDataList), signer.Address().Hex(), network.Name, network.DepositContract.Hex())
+		if err := s.DryRun(context.Background(), depositDataList, nonce, os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	withdrawalCredentialsBytes, err := hex.DecodeString(strings.TrimPrefix(depositData.WithdrawalCredentials, "0x"))
+	state, err := deposit.LoadState(*stateFile)
 	if err != nil {
-		log.Fatalf("Error decoding withdrawal credentials: %v", err)
+		log.Fatal(err)
 	}
 
-	signatureBytes, err := hex.DecodeString(strings.TrimPrefix(depositData.Signature, "0x"))
+	// Deposits recorded in the state file were sent by an earlier run and are resumed, not duplicated.
+	duplicates, err := deposit.ReportDuplicates(context.Background(), checker, deposit.NewCandidates(depositDataList, state), s.Out)
 	if err != nil {
-		log.Fatalf("Error decoding signature: %v", err)
+		log.Fatalf("Error checking for duplicate deposits: %v", err)
+	}
+	if duplicates > 0 && !dupFlags.allow {
+		log.Fatalf("Refusing to deposit %d pubkey(s) that already have a deposit, rerun with -allow-duplicates if these are intentional top-ups", duplicates)
 	}
 
-	depositDataRootBytes, err := hex.DecodeString(strings.TrimPrefix(depositData.DepositDataRoot, "0x"))
+	confirmed, err := confirmAmounts(depositDataList, os.Stdin, s.Out)
 	if err != nil {
-		log.Fatalf("Error decoding deposit data root: %v", err)
+		log.Fatalf("Error reading confirmation: %v", err)
+	}
+	if !confirmed {
+		log.Fatal("Deposit cancelled")
 	}
 
-	var depositDataRoot [32]byte
-	copy(depositDataRoot[:], depositDataRootBytes)
+	fmt.Fprintf(s.Out, "Submitting %d deposit(s) from %s to %s deposit contract %s\n", len(depositDataList), signer.Address().Hex(), network.Name, network.DepositContract.Hex())
 
-	parsedABI, err := abi.JSON(strings.NewReader(depositContractABI))
-	if err != nil {
-		log.Fatalf("Error parsing ABI: %v", err)
+	results, err := s.SubmitAll(context.Background(), depositDataList, state, *concurrency)
+	if writeErr := outFlags.writeResults(os.Stdout, results); writeErr != nil {
+		log.Fatalf("Error writing results: %v", writeErr)
 	}
-
-	data, err := parsedABI.Pack("deposit", pubkeyBytes, withdrawalCredentialsBytes, signatureBytes, depositDataRoot)
 	if err != nil {
-		log.Fatalf("Error encoding function call: %v", err)
+		log.Fatalf("Some deposits failed, rerun to resume from %s:\n%v", *stateFile, err)
 	}
 
-	amount := big.NewInt(10000000000000000) // 0.01 ETH in wei
-
-	gasLimit := uint64(500000)
-
-	tx := types.NewTransaction(
-		nonce,
-		depositContractAddress,
-		amount,
-		gasLimit,
-		gasPrice,
-		data,
-	)
+	fmt.Fprintln(s.Out, "All deposits confirmed")
+}
 
-	chainID, err := client.NetworkID(context.Background())
-	if err != nil {
-		log.Fatalf("Error getting chain ID: %v", err)
+func runOffline(s *deposit.Submitter, signer deposit.Signer, depositDataList []deposit.Data, nonce uint64, format, path string) {
+	duplicates, _ := deposit.ReportDuplicates(context.Background(), nil, deposit.NewCandidates(depositDataList, nil), os.Stderr)
+	if duplicates > 0 {
+		log.Fatal("Refusing to sign deposits for repeated pubkeys")
 	}
+	fmt.Fprintln(os.Stderr, "Offline mode cannot check the chain for existing deposits, broadcast checks them before sending")
 
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), privateKey)
+	// Confirmation prompts go to stderr, so that stdout only carries the signed transactions.
+	confirmed, err := confirmAmounts(depositDataList, os.Stdin, os.Stderr)
 	if err != nil {
-		log.Fatalf("Error signing transaction: %v", err)
+		log.Fatalf("Error reading confirmation: %v", err)
+	}
+	if !confirmed {
+		log.Fatal("Deposit cancelled")
 	}
 
-	err = client.SendTransaction(context.Background(), signedTx)
+	txs, err := s.SignOffline(depositDataList, nonce)
 	if err != nil {
-		log.Fatalf("Error sending transaction: %v", err)
+		log.Fatal(err)
 	}
 
-	fmt.Printf("Transaction sent: %s\n", signedTx.Hash().Hex())
-	fmt.Println("Waiting for confirmation...")
-
-	receipt, err := bind.WaitMined(context.Background(), client, signedTx)
-	if err != nil {
-		log.Fatalf("Error waiting for confirmation: %v", err)
+	out := os.Stdout
+	if path != "-" {
+		out, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			log.Fatalf("Error creating %s: %v", path, err)
+		}
+		defer out.Close()
 	}
 
-	fmt.Printf("Transaction confirmed in block: %d\n", receipt.BlockNumber)
-	fmt.Printf("Transaction hash: %s\n", receipt.TxHash.Hex())
-	fmt.Printf("Gas used: %d\n", receipt.GasUsed)
+	if err := deposit.WriteTxs(out, txs, format); err != nil {
+		log.Fatalf("Error writing transactions: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Signed %d deposit transaction(s) from %s with nonces %d to %d\n", len(txs), signer.Address().Hex(), nonce, nonce+uint64(len(txs))-1)
 }
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"stakeway_test_task/internal/deposit"
+	"stakeway_test_task/internal/eth2"
+)
+
+var defaultRPCURLs = map[string]string{
+	"mainnet": "https://ethereum-rpc.publicnode.com",
+	"holesky": "https://ethereum-holesky.publicnode.com",
+	"sepolia": "https://ethereum-sepolia.publicnode.com",
+	"hoodi":   "https://ethereum-hoodi.publicnode.com",
+}
+
+type networkFlags struct {
+	name        string
+	rpcURL      string
+	contract    string
+	chainID     uint64
+	forkVersion string
+}
+
+func (f *networkFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.name, "network", "holesky", fmt.Sprintf("network profile: %s or %s", strings.Join(eth2.NetworkNames(), ", "), deposit.CustomNetwork))
+	fs.StringVar(&f.rpcURL, "rpc", "", "execution layer RPC URL (defaults to a public node of the selected network)")
+	fs.StringVar(&f.contract, "contract", "", "deposit contract address (required for the custom network)")
+	fs.Uint64Var(&f.chainID, "chain-id", 0, "chain ID (custom network only)")
+	fs.StringVar(&f.forkVersion, "fork-version", "", "genesis fork version as hex (custom network only)")
+}
+
+// resolve builds the network profile selected by the flags together with the RPC URL to use.
+func (f *networkFlags) resolve() (*eth2.Network, string, error) {
+	if f.name == deposit.CustomNetwork {
+		return f.resolveCustom()
+	}
+
+	if f.chainID != 0 || f.forkVersion != "" {
+		return nil, "", fmt.Errorf("-chain-id and -fork-version can only be used with -network %s", deposit.CustomNetwork)
+	}
+
+	builtin, err := eth2.NetworkByName(f.name)
+	if err != nil {
+		return nil, "", err
+	}
+	network := *builtin
+
+	if f.contract != "" {
+		if !common.IsHexAddress(f.contract) {
+			return nil, "", fmt.Errorf("invalid deposit contract address %q", f.contract)
+		}
+		network.DepositContract = common.HexToAddress(f.contract)
+	}
+
+	rpcURL := f.rpcURL
+	if rpcURL == "" {
+		rpcURL = defaultRPCURLs[network.Name]
+	}
+	return &network, rpcURL, nil
+}
+
+func (f *networkFlags) resolveCustom() (*eth2.Network, string, error) {
+	if f.rpcURL == "" || f.contract == "" || f.chainID == 0 || f.forkVersion == "" {
+		return nil, "", fmt.Errorf("-network %s requires -rpc, -contract, -chain-id and -fork-version", deposit.CustomNetwork)
+	}
+	if !common.IsHexAddress(f.contract) {
+		return nil, "", fmt.Errorf("invalid deposit contract address %q", f.contract)
+	}
+
+	forkVersion, err := deposit.ParseForkVersion(f.forkVersion)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &eth2.Network{
+		Name:               deposit.CustomNetwork,
+		ChainID:            f.chainID,
+		DepositContract:    common.HexToAddress(f.contract),
+		GenesisForkVersion: forkVersion,
+	}, f.rpcURL, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"stakeway_test_task/internal/deposit"
+)
+
+func TestNetworkFlags(t *testing.T) {
+	t.Run("built-in profile", func(t *testing.T) {
+		f := networkFlags{name: "holesky"}
+
+		network, rpcURL, err := f.resolve()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(17000), network.ChainID)
+		assert.Equal(t, common.HexToAddress("0x4242424242424242424242424242424242424242"), network.DepositContract)
+		assert.Equal(t, "https://ethereum-holesky.publicnode.com", rpcURL)
+	})
+
+	t.Run("built-in profile with overrides", func(t *testing.T) {
+		f := networkFlags{name: "sepolia", rpcURL: "http://localhost:8545", contract: "0x1111111111111111111111111111111111111111"}
+
+		network, rpcURL, err := f.resolve()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(11155111), network.ChainID)
+		assert.Equal(t, common.HexToAddress("0x1111111111111111111111111111111111111111"), network.DepositContract)
+		assert.Equal(t, "http://localhost:8545", rpcURL)
+	})
+
+	t.Run("chain id override on built-in profile", func(t *testing.T) {
+		f := networkFlags{name: "mainnet", chainID: 5}
+
+		_, _, err := f.resolve()
+		assert.Error(t, err)
+	})
+
+	t.Run("custom profile", func(t *testing.T) {
+		f := networkFlags{
+			name:        deposit.CustomNetwork,
+			rpcURL:      "http://localhost:8545",
+			contract:    "0x1111111111111111111111111111111111111111",
+			chainID:     1337,
+			forkVersion: "0x10000038",
+		}
+
+		network, rpcURL, err := f.resolve()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1337), network.ChainID)
+		assert.Equal(t, [4]byte{0x10, 0x00, 0x00, 0x38}, network.GenesisForkVersion)
+		assert.Equal(t, "http://localhost:8545", rpcURL)
+	})
+
+	t.Run("incomplete custom profile", func(t *testing.T) {
+		f := networkFlags{name: deposit.CustomNetwork, rpcURL: "http://localhost:8545"}
+
+		_, _, err := f.resolve()
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown profile", func(t *testing.T) {
+		f := networkFlags{name: "goerli"}
+
+		_, _, err := f.resolve()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"stakeway_test_task/internal/deposit"
+)
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+type outputFlags struct {
+	format        string
+	confirmations uint64
+}
+
+func (f *outputFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.format, "output", outputText, "result format: text, or json to write one JSON array of results to stdout and progress to stderr")
+	fs.Uint64Var(&f.confirmations, "confirmations", 1, "number of blocks, including the one with the transaction, to wait for before a deposit counts as confirmed")
+}
+
+func (f *outputFlags) check() error {
+	if f.format != outputText && f.format != outputJSON {
+		return fmt.Errorf("unknown -output %q, expected %s or %s", f.format, outputText, outputJSON)
+	}
+	if f.confirmations == 0 {
+		return fmt.Errorf("-confirmations must be at least 1")
+	}
+	return nil
+}
+
+// progress returns where human-readable progress goes. With JSON output stdout is reserved for the results.
+func (f *outputFlags) progress() io.Writer {
+	if f.format == outputJSON {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// writeResults prints the results for automation, it does nothing for text output where progress lines
+// already carry the same information.
+func (f *outputFlags) writeResults(w io.Writer, results []deposit.Result) error {
+	if f.format != outputJSON {
+		return nil
+	}
+	if results == nil {
+		results = []deposit.Result{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"stakeway_test_task/internal/deposit"
+)
+
+func TestOutputFlags(t *testing.T) {
+	t.Run("validation", func(t *testing.T) {
+		assert.NoError(t, (&outputFlags{format: outputJSON, confirmations: 3}).check())
+		assert.Error(t, (&outputFlags{format: "yaml", confirmations: 1}).check())
+		assert.Error(t, (&outputFlags{format: outputText}).check())
+	})
+
+	results := []deposit.Result{{
+		Index:             0,
+		Pubkey:            "aa",
+		Status:            deposit.StatusConfirmed,
+		TxHash:            "0x01",
+		BlockNumber:       42,
+		GasUsed:           50000,
+		EffectiveGasPrice: "1000000007",
+	}}
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, (&outputFlags{format: outputJSON}).writeResults(&out, results))
+
+		var decoded []map[string]any
+		require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+		require.Len(t, decoded, 1)
+		assert.Equal(t, "aa", decoded[0]["pubkey"])
+		assert.Equal(t, "0x01", decoded[0]["tx_hash"])
+		assert.Equal(t, float64(42), decoded[0]["block_number"])
+		assert.Equal(t, float64(50000), decoded[0]["gas_used"])
+		assert.Equal(t, "1000000007", decoded[0]["effective_gas_price"])
+		assert.NotContains(t, decoded[0], "error")
+	})
+
+	t.Run("text", func(t *testing.T) {
+		var out bytes.Buffer
+		require.NoError(t, (&outputFlags{format: outputText}).writeResults(&out, results))
+		assert.Empty(t, out.String())
+	})
+}
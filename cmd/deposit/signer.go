@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/external"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/term"
+
+	"stakeway_test_task/internal/deposit"
+)
+
+// privateKeyEnv is read when neither a keystore nor an external signer is configured.
+const privateKeyEnv = "DEPOSIT_PRIVATE_KEY"
+
+type signerFlags struct {
+	keystoreFile string
+	passwordFile string
+	signerURL    string
+	from         string
+}
+
+func (f *signerFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.keystoreFile, "keystore", "", "encrypted go-ethereum keystore file of the funding account")
+	fs.StringVar(&f.passwordFile, "password-file", "", "file containing the keystore password (prompted for if not set)")
+	fs.StringVar(&f.signerURL, "signer", "", "external signer endpoint (Clef-compatible JSON-RPC), e.g. http://localhost:8550")
+	fs.StringVar(&f.from, "from", "", "funding account address at the external signer (required if it manages several accounts)")
+}
+
+// load returns the signer selected by the flags, falling back to the DEPOSIT_PRIVATE_KEY environment variable.
+func (f *signerFlags) load() (deposit.Signer, error) {
+	if f.keystoreFile != "" && f.signerURL != "" {
+		return nil, errors.New("-keystore and -signer cannot be used together")
+	}
+	if f.passwordFile != "" && f.keystoreFile == "" {
+		return nil, errors.New("-password-file can only be used with -keystore")
+	}
+	if f.from != "" && f.signerURL == "" {
+		return nil, errors.New("-from can only be used with -signer")
+	}
+
+	switch {
+	case f.keystoreFile != "":
+		return f.loadKeystore()
+	case f.signerURL != "":
+		return f.loadExternal()
+	}
+
+	privateKeyHex, ok := os.LookupEnv(privateKeyEnv)
+	if !ok || privateKeyHex == "" {
+		return nil, fmt.Errorf("no signer configured: use -keystore, -signer or set %s", privateKeyEnv)
+	}
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(privateKeyHex), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", privateKeyEnv, err)
+	}
+	return deposit.NewKeySigner(privateKey), nil
+}
+
+func (f *signerFlags) loadKeystore() (deposit.Signer, error) {
+	keyJSON, err := os.ReadFile(f.keystoreFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading keystore: %w", err)
+	}
+
+	password, err := f.password()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := keystore.DecryptKey(keyJSON, password)
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting keystore %s: %w", f.keystoreFile, err)
+	}
+	return deposit.NewKeySigner(key.PrivateKey), nil
+}
+
+func (f *signerFlags) password() (string, error) {
+	if f.passwordFile != "" {
+		password, err := os.ReadFile(f.passwordFile)
+		if err != nil {
+			return "", fmt.Errorf("error reading password file: %w", err)
+		}
+		return strings.TrimRight(string(password), "\r\n"), nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errors.New("stdin is not a terminal, use -password-file to supply the keystore password")
+	}
+	fmt.Fprintf(os.Stderr, "Password for %s: ", f.keystoreFile)
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("error reading password: %w", err)
+	}
+	return string(password), nil
+}
+
+func (f *signerFlags) loadExternal() (deposit.Signer, error) {
+	signer, err := external.NewExternalSigner(f.signerURL)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to external signer %s: %w", f.signerURL, err)
+	}
+
+	if f.from != "" {
+		if !common.IsHexAddress(f.from) {
+			return nil, fmt.Errorf("invalid -from address %q", f.from)
+		}
+		return deposit.NewExternalSigner(signer, accounts.Account{Address: common.HexToAddress(f.from)}), nil
+	}
+
+	accts := signer.Accounts()
+	if len(accts) != 1 {
+		return nil, fmt.Errorf("external signer exposes %d accounts, select one with -from", len(accts))
+	}
+	return deposit.NewExternalSigner(signer, accts[0]), nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"stakeway_test_task/internal/deposit"
+)
+
+const testPrivateKey = "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
+
+// stubClef implements the subset of the Clef external API used by accounts/external.
+type stubClef struct {
+	key    *ecdsa.PrivateKey
+	tamper bool
+}
+
+type signTransactionResult struct {
+	Raw hexutil.Bytes      `json:"raw"`
+	Tx  *types.Transaction `json:"tx"`
+}
+
+func (c *stubClef) Version() string {
+	return "6.0.0"
+}
+
+func (c *stubClef) List() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(c.key.PublicKey)}
+}
+
+func (c *stubClef) SignTransaction(args apitypes.SendTxArgs, methodSelector *string) (*signTransactionResult, error) {
+	if c.tamper {
+		args.Value = hexutil.Big(*big.NewInt(1))
+	}
+	tx, err := args.ToTransaction()
+	if err != nil {
+		return nil, err
+	}
+	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(args.ChainID.ToInt()), c.key)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signedTx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &signTransactionResult{Raw: raw, Tx: signedTx}, nil
+}
+
+func startStubClef(t *testing.T, clef *stubClef) string {
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("account", clef))
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+	return httpServer.URL
+}
+
+func testTx() *types.Transaction {
+	return types.NewTransaction(7, common.HexToAddress("0x4242424242424242424242424242424242424242"), deposit.Value(32000000000), 500000, big.NewInt(1000000000), []byte{0x22, 0x89, 0x51, 0x18})
+}
+
+func TestSignerFlags(t *testing.T) {
+	privateKey, err := crypto.HexToECDSA(testPrivateKey)
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(privateKey.PublicKey)
+	chainID := big.NewInt(17000)
+
+	assertSigns := func(t *testing.T, signer deposit.Signer) {
+		signedTx, err := signer.SignTx(testTx(), chainID)
+		require.NoError(t, err)
+
+		from, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+		require.NoError(t, err)
+		assert.Equal(t, address, from)
+	}
+
+	t.Run("environment variable", func(t *testing.T) {
+		t.Setenv(privateKeyEnv, "0x"+testPrivateKey)
+
+		signer, err := (&signerFlags{}).load()
+		require.NoError(t, err)
+		assert.Equal(t, address, signer.Address())
+		assertSigns(t, signer)
+	})
+
+	t.Run("no signer configured", func(t *testing.T) {
+		t.Setenv(privateKeyEnv, "")
+
+		_, err := (&signerFlags{}).load()
+		assert.ErrorContains(t, err, "no signer configured")
+	})
+
+	t.Run("keystore with password file", func(t *testing.T) {
+		dir := t.TempDir()
+		keyJSON, err := keystore.EncryptKey(&keystore.Key{Id: uuid.New(), Address: address, PrivateKey: privateKey},
+			"correct horse", keystore.LightScryptN, keystore.LightScryptP)
+		require.NoError(t, err)
+
+		keystoreFile := filepath.Join(dir, "keystore.json")
+		passwordFile := filepath.Join(dir, "password.txt")
+		require.NoError(t, os.WriteFile(keystoreFile, keyJSON, 0600))
+		require.NoError(t, os.WriteFile(passwordFile, []byte("correct horse\n"), 0600))
+
+		signer, err := (&signerFlags{keystoreFile: keystoreFile, passwordFile: passwordFile}).load()
+		require.NoError(t, err)
+		assert.Equal(t, address, signer.Address())
+		assertSigns(t, signer)
+
+		require.NoError(t, os.WriteFile(passwordFile, []byte("wrong"), 0600))
+		_, err = (&signerFlags{keystoreFile: keystoreFile, passwordFile: passwordFile}).load()
+		assert.ErrorContains(t, err, "error decrypting keystore")
+	})
+
+	t.Run("external signer", func(t *testing.T) {
+		url := startStubClef(t, &stubClef{key: privateKey})
+
+		signer, err := (&signerFlags{signerURL: url}).load()
+		require.NoError(t, err)
+		assert.Equal(t, address, signer.Address())
+		assertSigns(t, signer)
+	})
+
+	t.Run("external signer with unknown account", func(t *testing.T) {
+		url := startStubClef(t, &stubClef{key: privateKey})
+
+		signer, err := (&signerFlags{signerURL: url, from: "0x1111111111111111111111111111111111111111"}).load()
+		require.NoError(t, err)
+
+		_, err = signer.SignTx(testTx(), chainID)
+		assert.ErrorContains(t, err, "instead of")
+	})
+
+	t.Run("external signer modifying the transaction", func(t *testing.T) {
+		url := startStubClef(t, &stubClef{key: privateKey, tamper: true})
+
+		signer, err := (&signerFlags{signerURL: url}).load()
+		require.NoError(t, err)
+
+		_, err = signer.SignTx(testTx(), chainID)
+		assert.ErrorContains(t, err, "modified the transaction")
+	})
+
+	t.Run("conflicting flags", func(t *testing.T) {
+		_, err := (&signerFlags{keystoreFile: "key.json", signerURL: "http://localhost:8550"}).load()
+		assert.Error(t, err)
+
+		_, err = (&signerFlags{passwordFile: "password.txt"}).load()
+		assert.Error(t, err)
+
+		_, err = (&signerFlags{from: "0x1111111111111111111111111111111111111111"}).load()
+		assert.Error(t, err)
+	})
+}
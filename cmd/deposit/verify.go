@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"stakeway_test_task/internal/deposit"
+	"stakeway_test_task/internal/eth2"
+)
+
+func runVerify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	networkName := fs.String("network", "", "verify against this network instead of each entry's network_name (use "+deposit.CustomNetwork+" together with -fork-version)")
+	forkVersionHex := fs.String("fork-version", "", "genesis fork version as hex (custom network only)")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s verify [flags] [deposit_data.json]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	path := "deposit_data.json"
+	if fs.NArg() > 0 {
+		path = fs.Arg(0)
+	}
+
+	depositDataList, err := deposit.Load(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	var target *eth2.Network
+	switch *networkName {
+	case "":
+	case deposit.CustomNetwork:
+		forkVersion, err := deposit.ParseForkVersion(*forkVersionHex)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		target = &eth2.Network{Name: deposit.CustomNetwork, GenesisForkVersion: forkVersion}
+	default:
+		target, err = eth2.NetworkByName(*networkName)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	if !verifyEntries(os.Stdout, depositDataList, target) {
+		os.Exit(1)
+	}
+}
+
+// verifyEntries prints the result of verifying every entry against target, or against the network named by
+// the entry if target is nil, and reports whether all of them are valid.
+func verifyEntries(w io.Writer, depositDataList []deposit.Data, target *eth2.Network) bool {
+	ok := true
+	for i, depositData := range depositDataList {
+		network := target
+		var err error
+		if network == nil {
+			network, err = eth2.NetworkByName(depositData.NetworkName)
+		}
+		if err == nil {
+			err = deposit.Verify(depositData, network)
+		}
+
+		if err != nil {
+			ok = false
+			fmt.Fprintf(w, "Deposit %d (%s): FAILED: %v\n", i, depositData.Pubkey, err)
+			continue
+		}
+		fmt.Fprintf(w, "Deposit %d (%s): OK\n", i, depositData.Pubkey)
+	}
+	return ok
+}
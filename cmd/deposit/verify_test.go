@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"stakeway_test_task/internal/deposit"
+	"stakeway_test_task/internal/eth2"
+)
+
+func TestVerifyEntries(t *testing.T) {
+	depositDataList, err := deposit.Load("../../deposit_data.json")
+	require.NoError(t, err)
+	valid := depositDataList[0]
+	tampered := valid
+	tampered.Amount = 1000000000
+
+	holesky, err := eth2.NetworkByName("holesky")
+	require.NoError(t, err)
+
+	// An entry is reported on its own, a failure does not carry over to the entries after it.
+	for _, target := range []*eth2.Network{nil, holesky} {
+		var out bytes.Buffer
+		assert.False(t, verifyEntries(&out, []deposit.Data{tampered, valid, valid}, target))
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		require.Len(t, lines, 3)
+		assert.Contains(t, lines[0], "FAILED: deposit message root mismatch")
+		assert.Equal(t, fmt.Sprintf("Deposit 1 (%s): OK", valid.Pubkey), lines[1])
+		assert.Equal(t, fmt.Sprintf("Deposit 2 (%s): OK", valid.Pubkey), lines[2])
+	}
+
+	var out bytes.Buffer
+	assert.True(t, verifyEntries(&out, []deposit.Data{valid}, holesky))
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"io"
+	"log/slog"
+	"os"
+	"stakeway_test_task/internal/deposit"
+	"stakeway_test_task/internal/eth2"
+	services "stakeway_test_task/internal/service"
+	"strconv"
+	"strings"
+)
+
+// defaultGasMargin is added to the estimated gas of deposit transactions, in percent.
+const defaultGasMargin = 20
+
+// loadDepositSubmitter sets up the funding wallet used to submit deposits for requests that ask for it.
+// The deposit stage is disabled, and nil returned, unless DEPOSIT_RPC_URL is set.
+func loadDepositSubmitter(network *eth2.Network, logger *slog.Logger) (services.DepositSubmitter, error) {
+	rpcURL := os.Getenv("DEPOSIT_RPC_URL")
+	if rpcURL == "" {
+		return nil, nil
+	}
+
+	signer, err := loadFundingSigner()
+	if err != nil {
+		return nil, err
+	}
+
+	fees := deposit.FeeSettings{GasMargin: defaultGasMargin}
+	if maxFee := os.Getenv("DEPOSIT_MAX_FEE"); maxFee != "" {
+		if fees.MaxFee, err = deposit.ParseGwei(maxFee); err != nil {
+			return nil, fmt.Errorf("invalid DEPOSIT_MAX_FEE: %w", err)
+		}
+	}
+
+	client, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %s: %w", rpcURL, err)
+	}
+	chainID, err := client.ChainID(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error getting chain ID: %w", err)
+	}
+	if chainID.Uint64() != network.ChainID {
+		return nil, fmt.Errorf("DEPOSIT_RPC_URL serves chain ID %d, but network %s has chain ID %d", chainID, network.Name, network.ChainID)
+	}
+
+	submitter, err := deposit.NewSubmitter(client, signer, network, fees)
+	if err != nil {
+		return nil, err
+	}
+	// Progress is logged per request by the service.
+	submitter.Out = io.Discard
+	if confirmations := os.Getenv("DEPOSIT_CONFIRMATIONS"); confirmations != "" {
+		if submitter.Confirmations, err = strconv.ParseUint(confirmations, 10, 64); err != nil || submitter.Confirmations == 0 {
+			return nil, fmt.Errorf("invalid DEPOSIT_CONFIRMATIONS %q", confirmations)
+		}
+	}
+
+	logger.Info("Deposit submission enabled",
+		"funding_address", signer.Address().Hex(),
+		"deposit_contract", network.DepositContract.Hex(),
+		"confirmations", submitter.Confirmations)
+	return submitter, nil
+}
+
+// loadFundingSigner reads the funding account from DEPOSIT_KEYSTORE (with DEPOSIT_KEYSTORE_PASSWORD_FILE)
+// or DEPOSIT_PRIVATE_KEY.
+func loadFundingSigner() (deposit.Signer, error) {
+	if keystorePath := os.Getenv("DEPOSIT_KEYSTORE"); keystorePath != "" {
+		keyJSON, err := os.ReadFile(keystorePath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading DEPOSIT_KEYSTORE: %w", err)
+		}
+
+		passwordPath := os.Getenv("DEPOSIT_KEYSTORE_PASSWORD_FILE")
+		if passwordPath == "" {
+			return nil, errors.New("DEPOSIT_KEYSTORE requires DEPOSIT_KEYSTORE_PASSWORD_FILE")
+		}
+		password, err := os.ReadFile(passwordPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading DEPOSIT_KEYSTORE_PASSWORD_FILE: %w", err)
+		}
+
+		key, err := keystore.DecryptKey(keyJSON, strings.TrimRight(string(password), "\r\n"))
+		if err != nil {
+			return nil, fmt.Errorf("error decrypting DEPOSIT_KEYSTORE: %w", err)
+		}
+		return deposit.NewKeySigner(key.PrivateKey), nil
+	}
+
+	privateKeyHex := os.Getenv("DEPOSIT_PRIVATE_KEY")
+	if privateKeyHex == "" {
+		return nil, errors.New("DEPOSIT_RPC_URL requires a funding account in DEPOSIT_KEYSTORE or DEPOSIT_PRIVATE_KEY")
+	}
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(privateKeyHex), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing DEPOSIT_PRIVATE_KEY: %w", err)
+	}
+	return deposit.NewKeySigner(privateKey), nil
+}
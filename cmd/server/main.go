@@ -2,13 +2,22 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"github.com/tyler-smith/go-bip39"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"stakeway_test_task/internal/api"
+	"stakeway_test_task/internal/beacon"
+	"stakeway_test_task/internal/eth2"
 	"stakeway_test_task/internal/repository"
+	services "stakeway_test_task/internal/service"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 )
@@ -29,7 +38,89 @@ func main() {
 	}
 	defer repo.Close()
 
-	router := api.SetupRoutes(repo, logger)
+	mnemonicPath := os.Getenv("MNEMONIC_PATH")
+	if mnemonicPath == "" {
+		mnemonicPath = "./mnemonic.txt"
+	}
+
+	seed, err := loadSeed(os.Getenv("MNEMONIC"), mnemonicPath, logger)
+	if err != nil {
+		logger.Error("Failed to load mnemonic", "error", err)
+		os.Exit(1)
+	}
+
+	networkName := os.Getenv("NETWORK")
+	if networkName == "" {
+		networkName = "holesky"
+	}
+
+	network, err := eth2.NetworkByName(networkName)
+	if err != nil {
+		logger.Error("Failed to load network configuration", "error", err)
+		os.Exit(1)
+	}
+
+	deposits, err := loadDepositSubmitter(network, logger)
+	if err != nil {
+		logger.Error("Failed to configure deposit submission", "error", err)
+		os.Exit(1)
+	}
+
+	tokenPath := os.Getenv("KEYMANAGER_TOKEN_PATH")
+	if tokenPath == "" {
+		tokenPath = "./keymanager-token.txt"
+	}
+
+	keymanagerToken, err := loadKeymanagerToken(os.Getenv("KEYMANAGER_TOKEN"), tokenPath, logger)
+	if err != nil {
+		logger.Error("Failed to load Keymanager API token", "error", err)
+		os.Exit(1)
+	}
+
+	// Without a beacon node validators are not tracked and exits cannot be broadcast.
+	var beaconClient *beacon.Client
+	var beaconNode services.BeaconNode
+	beaconURL := os.Getenv("BEACON_URL")
+	if beaconURL != "" {
+		beaconClient = beacon.NewClient(beaconURL)
+		beaconNode = beaconClient
+	}
+
+	jobLimits, err := loadJobLimits()
+	if err != nil {
+		logger.Error("Failed to configure job workers", "error", err)
+		os.Exit(1)
+	}
+
+	validatorService := services.NewValidatorService(repo, logger, seed, network, deposits, beaconNode, jobLimits)
+	if err := validatorService.RecoverJobs(); err != nil {
+		logger.Error("Failed to recover jobs", "error", err)
+		os.Exit(1)
+	}
+
+	router := api.SetupRoutes(repo, logger, validatorService, network, keymanagerToken)
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go validatorService.RunJobs(jobsCtx)
+	logger.Info("Processing validator requests", "workers", jobLimits.Workers, "queue_size", jobLimits.QueueSize)
+
+	pollCtx, stopPolling := context.WithCancel(context.Background())
+	defer stopPolling()
+	if beaconClient != nil {
+		interval := time.Minute
+		if value := os.Getenv("BEACON_POLL_INTERVAL"); value != "" {
+			interval, err = time.ParseDuration(value)
+			if err != nil || interval <= 0 {
+				logger.Error("Invalid BEACON_POLL_INTERVAL", "value", value)
+				os.Exit(1)
+			}
+		}
+
+		poller := services.NewBeaconPoller(repo, beaconClient, logger)
+		go poller.Run(pollCtx, interval)
+		logger.Info("Tracking validators on beacon node", "url", beaconURL, "interval", interval.String())
+	}
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -57,6 +148,8 @@ func main() {
 	<-quit
 
 	logger.Info("Shutting down server...")
+	stopPolling()
+	stopJobs()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -68,3 +161,82 @@ func main() {
 
 	logger.Info("Server exiting")
 }
+
+// loadSeed returns the master seed all validator keys are derived from. The mnemonic is taken from
+// the environment, then from mnemonicPath; if neither exists a new one is generated and written to
+// mnemonicPath, which then becomes the only backup needed to recover every key.
+func loadSeed(mnemonic, mnemonicPath string, logger *slog.Logger) ([]byte, error) {
+	if mnemonic == "" {
+		data, err := os.ReadFile(mnemonicPath)
+		switch {
+		case err == nil:
+			mnemonic = strings.TrimSpace(string(data))
+		case errors.Is(err, os.ErrNotExist):
+			entropy, err := bip39.NewEntropy(256)
+			if err != nil {
+				return nil, err
+			}
+			mnemonic, err = bip39.NewMnemonic(entropy)
+			if err != nil {
+				return nil, err
+			}
+			if err := os.WriteFile(mnemonicPath, []byte(mnemonic+"\n"), 0600); err != nil {
+				return nil, err
+			}
+			logger.Warn("Generated new mnemonic, back it up to be able to recover validator keys", "path", mnemonicPath)
+		default:
+			return nil, err
+		}
+	}
+
+	return bip39.NewSeedWithErrorChecking(mnemonic, os.Getenv("MNEMONIC_PASSPHRASE"))
+}
+
+// loadKeymanagerToken returns the bearer token of the Keymanager API, taken from the environment or from
+// tokenPath. If neither exists a random token is written to tokenPath for validator clients to read.
+func loadKeymanagerToken(token, tokenPath string, logger *slog.Logger) (string, error) {
+	if token != "" {
+		return token, nil
+	}
+
+	data, err := os.ReadFile(tokenPath)
+	if err == nil {
+		// An empty token would make the API refuse every request, with nothing pointing at the file.
+		token = strings.TrimSpace(string(data))
+		if token == "" {
+			return "", fmt.Errorf("token file %s is empty", tokenPath)
+		}
+		return token, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	token = hex.EncodeToString(secret)
+	if err := os.WriteFile(tokenPath, []byte(token+"\n"), 0600); err != nil {
+		return "", err
+	}
+	logger.Info("Generated Keymanager API token", "path", tokenPath)
+	return token, nil
+}
+
+// loadJobLimits reads JOB_WORKERS and JOB_QUEUE_SIZE, falling back to services.DefaultJobLimits.
+func loadJobLimits() (services.JobLimits, error) {
+	limits := services.DefaultJobLimits
+	for name, limit := range map[string]*int{"JOB_WORKERS": &limits.Workers, "JOB_QUEUE_SIZE": &limits.QueueSize} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return limits, fmt.Errorf("invalid %s %q", name, value)
+		}
+		*limit = n
+	}
+	return limits, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadKeymanagerToken(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("environment", func(t *testing.T) {
+		token, err := loadKeymanagerToken("secret", filepath.Join(t.TempDir(), "token.txt"), logger)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", token)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "token.txt")
+		require.NoError(t, os.WriteFile(path, []byte("secret\n"), 0600))
+
+		token, err := loadKeymanagerToken("", path, logger)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", token)
+	})
+
+	t.Run("generated", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "token.txt")
+
+		token, err := loadKeymanagerToken("", path, logger)
+		require.NoError(t, err)
+		assert.Len(t, token, 64)
+
+		again, err := loadKeymanagerToken("", path, logger)
+		require.NoError(t, err)
+		assert.Equal(t, token, again)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "token.txt")
+		require.NoError(t, os.WriteFile(path, []byte(" \n"), 0600))
+
+		token, err := loadKeymanagerToken("", path, logger)
+		assert.ErrorContains(t, err, "is empty")
+		assert.Empty(t, token)
+	})
+}
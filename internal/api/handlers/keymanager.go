@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"net/http"
+	"stakeway_test_task/internal/models"
+)
+
+type Keymanager interface {
+	ListKeystores() ([]models.KeystoreInfo, error)
+	ImportKeystores(input *models.ImportKeystoresInput) ([]models.KeyStatus, error)
+	DeleteKeystores(input *models.DeleteKeysInput) (*models.DeleteKeystoresResponse, error)
+	ListRemoteKeys() ([]models.RemoteKey, error)
+	ImportRemoteKeys(input *models.ImportRemoteKeysInput) ([]models.KeyStatus, error)
+	DeleteRemoteKeys(input *models.DeleteKeysInput) ([]models.KeyStatus, error)
+	GetFeeRecipient(pubkey string) (*models.FeeRecipient, error)
+	SetFeeRecipient(pubkey, ethAddress string) error
+	DeleteFeeRecipient(pubkey string) error
+	ExportSlashingProtection(pubkeys []string) (*models.Interchange, error)
+	ImportSlashingProtection(interchange *models.Interchange) error
+}
+
+// KeymanagerHandler serves the ethereum/keymanager-APIs endpoints. Responses wrap their payload in a
+// "data" field and errors are JSON objects with a code and a message, as the spec requires.
+type KeymanagerHandler struct {
+	service Keymanager
+}
+
+func NewKeymanagerHandler(service Keymanager) *KeymanagerHandler {
+	return &KeymanagerHandler{service: service}
+}
+
+type dataResponse struct {
+	Data interface{} `json:"data"`
+}
+
+func (h *KeymanagerHandler) ListKeystores(w http.ResponseWriter, r *http.Request) {
+	keystores, err := h.service.ListKeystores()
+	if err != nil {
+		writeKeymanagerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dataResponse{Data: keystores})
+}
+
+func (h *KeymanagerHandler) ImportKeystores(w http.ResponseWriter, r *http.Request) {
+	var input models.ImportKeystoresInput
+	if !decodeKeymanagerBody(w, r, &input) {
+		return
+	}
+
+	statuses, err := h.service.ImportKeystores(&input)
+	if err != nil {
+		writeKeymanagerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dataResponse{Data: statuses})
+}
+
+func (h *KeymanagerHandler) DeleteKeystores(w http.ResponseWriter, r *http.Request) {
+	var input models.DeleteKeysInput
+	if !decodeKeymanagerBody(w, r, &input) {
+		return
+	}
+
+	response, err := h.service.DeleteKeystores(&input)
+	if err != nil {
+		writeKeymanagerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *KeymanagerHandler) ListRemoteKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.ListRemoteKeys()
+	if err != nil {
+		writeKeymanagerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dataResponse{Data: keys})
+}
+
+func (h *KeymanagerHandler) ImportRemoteKeys(w http.ResponseWriter, r *http.Request) {
+	var input models.ImportRemoteKeysInput
+	if !decodeKeymanagerBody(w, r, &input) {
+		return
+	}
+
+	statuses, err := h.service.ImportRemoteKeys(&input)
+	if err != nil {
+		writeKeymanagerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dataResponse{Data: statuses})
+}
+
+func (h *KeymanagerHandler) DeleteRemoteKeys(w http.ResponseWriter, r *http.Request) {
+	var input models.DeleteKeysInput
+	if !decodeKeymanagerBody(w, r, &input) {
+		return
+	}
+
+	statuses, err := h.service.DeleteRemoteKeys(&input)
+	if err != nil {
+		writeKeymanagerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dataResponse{Data: statuses})
+}
+
+func (h *KeymanagerHandler) GetFeeRecipient(w http.ResponseWriter, r *http.Request) {
+	feeRecipient, err := h.service.GetFeeRecipient(mux.Vars(r)["pubkey"])
+	if err != nil {
+		writeKeymanagerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, dataResponse{Data: feeRecipient})
+}
+
+func (h *KeymanagerHandler) SetFeeRecipient(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		EthAddress string `json:"ethaddress"`
+	}
+	if !decodeKeymanagerBody(w, r, &input) {
+		return
+	}
+
+	if err := h.service.SetFeeRecipient(mux.Vars(r)["pubkey"], input.EthAddress); err != nil {
+		writeKeymanagerError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *KeymanagerHandler) DeleteFeeRecipient(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteFeeRecipient(mux.Vars(r)["pubkey"]); err != nil {
+		writeKeymanagerError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ExportSlashingProtection returns EIP-3076 interchange JSON for the keys given as pubkey query
+// parameters, or for every key with signing history.
+func (h *KeymanagerHandler) ExportSlashingProtection(w http.ResponseWriter, r *http.Request) {
+	interchange, err := h.service.ExportSlashingProtection(r.URL.Query()["pubkey"])
+	if err != nil {
+		writeKeymanagerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, interchange)
+}
+
+func (h *KeymanagerHandler) ImportSlashingProtection(w http.ResponseWriter, r *http.Request) {
+	var interchange models.Interchange
+	if !decodeKeymanagerBody(w, r, &interchange) {
+		return
+	}
+
+	if err := h.service.ImportSlashingProtection(&interchange); err != nil {
+		writeKeymanagerError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func decodeKeymanagerBody(w http.ResponseWriter, r *http.Request, input interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(input); err != nil {
+		writeErrorJSON(w, http.StatusBadRequest, "Invalid request body")
+		return false
+	}
+	return true
+}
+
+func writeKeymanagerError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidInput):
+		writeErrorJSON(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, models.ErrKeyNotFound), errors.Is(err, models.ErrNoFeeRecipient):
+		writeErrorJSON(w, http.StatusNotFound, err.Error())
+	default:
+		writeErrorJSON(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func writeErrorJSON(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}{Code: code, Message: message})
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"stakeway_test_task/internal/models"
+	"testing"
+)
+
+const testPubkey = "0x80df3c8cb942676d7ade40b42a7306ea6275a9da4c3b952efb1a6dd9a9b4f2d21cca7463b7ca3cfe0dd09b6c335d5c26"
+
+type MockKeymanagerService struct {
+	mock.Mock
+}
+
+func (m *MockKeymanagerService) ListKeystores() ([]models.KeystoreInfo, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.KeystoreInfo), args.Error(1)
+}
+
+func (m *MockKeymanagerService) ImportKeystores(input *models.ImportKeystoresInput) ([]models.KeyStatus, error) {
+	args := m.Called(input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.KeyStatus), args.Error(1)
+}
+
+func (m *MockKeymanagerService) DeleteKeystores(input *models.DeleteKeysInput) (*models.DeleteKeystoresResponse, error) {
+	args := m.Called(input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.DeleteKeystoresResponse), args.Error(1)
+}
+
+func (m *MockKeymanagerService) ListRemoteKeys() ([]models.RemoteKey, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.RemoteKey), args.Error(1)
+}
+
+func (m *MockKeymanagerService) ImportRemoteKeys(input *models.ImportRemoteKeysInput) ([]models.KeyStatus, error) {
+	args := m.Called(input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.KeyStatus), args.Error(1)
+}
+
+func (m *MockKeymanagerService) DeleteRemoteKeys(input *models.DeleteKeysInput) ([]models.KeyStatus, error) {
+	args := m.Called(input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.KeyStatus), args.Error(1)
+}
+
+func (m *MockKeymanagerService) GetFeeRecipient(pubkey string) (*models.FeeRecipient, error) {
+	args := m.Called(pubkey)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.FeeRecipient), args.Error(1)
+}
+
+func (m *MockKeymanagerService) SetFeeRecipient(pubkey, ethAddress string) error {
+	return m.Called(pubkey, ethAddress).Error(0)
+}
+
+func (m *MockKeymanagerService) DeleteFeeRecipient(pubkey string) error {
+	return m.Called(pubkey).Error(0)
+}
+
+func (m *MockKeymanagerService) ExportSlashingProtection(pubkeys []string) (*models.Interchange, error) {
+	args := m.Called(pubkeys)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Interchange), args.Error(1)
+}
+
+func (m *MockKeymanagerService) ImportSlashingProtection(interchange *models.Interchange) error {
+	return m.Called(interchange).Error(0)
+}
+
+func TestListKeystores(t *testing.T) {
+	mockService := new(MockKeymanagerService)
+	mockService.On("ListKeystores").Return([]models.KeystoreInfo{{ValidatingPubkey: testPubkey, DerivationPath: "m/12381/3600/0/0/0"}}, nil)
+
+	handler := &KeymanagerHandler{service: mockService}
+	w := httptest.NewRecorder()
+	handler.ListKeystores(w, httptest.NewRequest(http.MethodGet, "/eth/v1/keystores", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":[{"validating_pubkey":"`+testPubkey+`","derivation_path":"m/12381/3600/0/0/0","readonly":false}]}`, w.Body.String())
+}
+
+func TestImportKeystores(t *testing.T) {
+	t.Run("statuses are wrapped in data", func(t *testing.T) {
+		mockService := new(MockKeymanagerService)
+		mockService.On("ImportKeystores", &models.ImportKeystoresInput{Keystores: []string{"{}"}, Passwords: []string{"secret"}}).
+			Return([]models.KeyStatus{{Status: models.KeyStatusError, Message: "invalid keystore"}}, nil)
+
+		handler := &KeymanagerHandler{service: mockService}
+		body := `{"keystores":["{}"],"passwords":["secret"]}`
+		w := httptest.NewRecorder()
+		handler.ImportKeystores(w, httptest.NewRequest(http.MethodPost, "/eth/v1/keystores", bytes.NewBufferString(body)))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"data":[{"status":"error","message":"invalid keystore"}]}`, w.Body.String())
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		mockService := new(MockKeymanagerService)
+		mockService.On("ImportKeystores", mock.Anything).Return(nil, models.ErrInvalidInput)
+
+		handler := &KeymanagerHandler{service: mockService}
+		w := httptest.NewRecorder()
+		handler.ImportKeystores(w, httptest.NewRequest(http.MethodPost, "/eth/v1/keystores", bytes.NewBufferString(`{"keystores":["{}"]}`)))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.JSONEq(t, `{"code":400,"message":"invalid input"}`, w.Body.String())
+	})
+
+	t.Run("malformed body", func(t *testing.T) {
+		handler := &KeymanagerHandler{service: new(MockKeymanagerService)}
+		w := httptest.NewRecorder()
+		handler.ImportKeystores(w, httptest.NewRequest(http.MethodPost, "/eth/v1/keystores", bytes.NewBufferString(`{`)))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestDeleteKeystores(t *testing.T) {
+	mockService := new(MockKeymanagerService)
+	mockService.On("DeleteKeystores", &models.DeleteKeysInput{Pubkeys: []string{testPubkey}}).
+		Return(&models.DeleteKeystoresResponse{Data: []models.KeyStatus{{Status: models.KeyStatusDeleted}}, SlashingProtection: `{"data":[]}`}, nil)
+
+	handler := &KeymanagerHandler{service: mockService}
+	w := httptest.NewRecorder()
+	handler.DeleteKeystores(w, httptest.NewRequest(http.MethodDelete, "/eth/v1/keystores", bytes.NewBufferString(`{"pubkeys":["`+testPubkey+`"]}`)))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":[{"status":"deleted"}],"slashing_protection":"{\"data\":[]}"}`, w.Body.String())
+}
+
+func TestRemoteKeys(t *testing.T) {
+	mockService := new(MockKeymanagerService)
+	mockService.On("ListRemoteKeys").Return([]models.RemoteKey{{Pubkey: testPubkey, URL: "https://signer.example"}}, nil)
+	mockService.On("ImportRemoteKeys", &models.ImportRemoteKeysInput{RemoteKeys: []models.RemoteKey{{Pubkey: testPubkey, URL: "https://signer.example"}}}).
+		Return([]models.KeyStatus{{Status: models.KeyStatusImported}}, nil)
+	mockService.On("DeleteRemoteKeys", &models.DeleteKeysInput{Pubkeys: []string{testPubkey}}).
+		Return([]models.KeyStatus{{Status: models.KeyStatusNotFound}}, nil)
+	handler := &KeymanagerHandler{service: mockService}
+
+	w := httptest.NewRecorder()
+	handler.ListRemoteKeys(w, httptest.NewRequest(http.MethodGet, "/eth/v1/remotekeys", nil))
+	assert.JSONEq(t, `{"data":[{"pubkey":"`+testPubkey+`","url":"https://signer.example","readonly":false}]}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	body := `{"remote_keys":[{"pubkey":"` + testPubkey + `","url":"https://signer.example"}]}`
+	handler.ImportRemoteKeys(w, httptest.NewRequest(http.MethodPost, "/eth/v1/remotekeys", bytes.NewBufferString(body)))
+	assert.JSONEq(t, `{"data":[{"status":"imported"}]}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler.DeleteRemoteKeys(w, httptest.NewRequest(http.MethodDelete, "/eth/v1/remotekeys", bytes.NewBufferString(`{"pubkeys":["`+testPubkey+`"]}`)))
+	assert.JSONEq(t, `{"data":[{"status":"not_found"}]}`, w.Body.String())
+}
+
+func TestFeeRecipientHandlers(t *testing.T) {
+	mockService := new(MockKeymanagerService)
+	handler := &KeymanagerHandler{service: mockService}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/eth/v1/validator/{pubkey}/feerecipient", handler.GetFeeRecipient).Methods("GET")
+	router.HandleFunc("/eth/v1/validator/{pubkey}/feerecipient", handler.SetFeeRecipient).Methods("POST")
+	router.HandleFunc("/eth/v1/validator/{pubkey}/feerecipient", handler.DeleteFeeRecipient).Methods("DELETE")
+	path := "/eth/v1/validator/" + testPubkey + "/feerecipient"
+
+	t.Run("get", func(t *testing.T) {
+		mockService.On("GetFeeRecipient", testPubkey).
+			Return(&models.FeeRecipient{Pubkey: testPubkey, EthAddress: "0x1234567890abcdef1234567890abcdef12345678"}, nil).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		var response struct {
+			Data models.FeeRecipient `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "0x1234567890abcdef1234567890abcdef12345678", response.Data.EthAddress)
+	})
+
+	t.Run("get unknown key", func(t *testing.T) {
+		mockService.On("GetFeeRecipient", testPubkey).Return(nil, models.ErrKeyNotFound).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.JSONEq(t, `{"code":404,"message":"validator key not found"}`, w.Body.String())
+	})
+
+	t.Run("set", func(t *testing.T) {
+		mockService.On("SetFeeRecipient", testPubkey, "0x1234567890abcdef1234567890abcdef12345678").Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(`{"ethaddress":"0x1234567890abcdef1234567890abcdef12345678"}`)))
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		mockService.On("DeleteFeeRecipient", testPubkey).Return(nil).Once()
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, path, nil))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
+
+func TestSlashingProtectionHandlers(t *testing.T) {
+	interchange := &models.Interchange{
+		Metadata: models.InterchangeMetadata{InterchangeFormatVersion: "5", GenesisValidatorsRoot: "0x9143aa7c615a7f7115e2b6aac319c03529df8242ae705fba9df39b79c59fa8b1"},
+		Data:     []models.InterchangeData{{Pubkey: testPubkey, SignedBlocks: []models.SignedBlock{{Slot: 81952}}, SignedAttestations: []models.SignedAttestation{}}},
+	}
+	body := `{"metadata":{"interchange_format_version":"5","genesis_validators_root":"0x9143aa7c615a7f7115e2b6aac319c03529df8242ae705fba9df39b79c59fa8b1"},` +
+		`"data":[{"pubkey":"` + testPubkey + `","signed_blocks":[{"slot":"81952"}],"signed_attestations":[]}]}`
+
+	t.Run("export", func(t *testing.T) {
+		mockService := new(MockKeymanagerService)
+		mockService.On("ExportSlashingProtection", []string{testPubkey}).Return(interchange, nil)
+
+		handler := &KeymanagerHandler{service: mockService}
+		w := httptest.NewRecorder()
+		handler.ExportSlashingProtection(w, httptest.NewRequest(http.MethodGet, "/slashing-protection?pubkey="+testPubkey, nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, body, w.Body.String())
+	})
+
+	t.Run("import", func(t *testing.T) {
+		mockService := new(MockKeymanagerService)
+		mockService.On("ImportSlashingProtection", interchange).Return(nil)
+
+		handler := &KeymanagerHandler{service: mockService}
+		w := httptest.NewRecorder()
+		handler.ImportSlashingProtection(w, httptest.NewRequest(http.MethodPost, "/slashing-protection", bytes.NewBufferString(body)))
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
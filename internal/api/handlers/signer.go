@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gorilla/mux"
+	"net/http"
+	"stakeway_test_task/internal/models"
+	"strings"
+)
+
+type Signer interface {
+	PublicKeys() ([]string, error)
+	Sign(pubkey string, request *models.SignRequest) ([]byte, error)
+}
+
+// SignerHandler serves the Web3Signer eth2 API. Errors are plain text, like Web3Signer's own.
+type SignerHandler struct {
+	service Signer
+}
+
+func NewSignerHandler(service Signer) *SignerHandler {
+	return &SignerHandler{service: service}
+}
+
+func (h *SignerHandler) Upcheck(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte("OK"))
+}
+
+func (h *SignerHandler) PublicKeys(w http.ResponseWriter, r *http.Request) {
+	pubkeys, err := h.service.PublicKeys()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(pubkeys)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Sign returns the signature as JSON when the client accepts it and as plain hex otherwise.
+func (h *SignerHandler) Sign(w http.ResponseWriter, r *http.Request) {
+	var request models.SignRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	signature, err := h.service.Sign(mux.Vars(r)["identifier"], &request)
+	switch {
+	case errors.Is(err, models.ErrInvalidInput):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, models.ErrKeyNotFound):
+		http.Error(w, "Public Key not found", http.StatusNotFound)
+		return
+	case errors.Is(err, models.ErrSlashable):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(models.SignResponse{Signature: hexutil.Encode(signature)})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(hexutil.Encode(signature)))
+}
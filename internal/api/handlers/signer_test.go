@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"bytes"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"stakeway_test_task/internal/models"
+	"testing"
+)
+
+type MockSignerService struct {
+	mock.Mock
+}
+
+func (m *MockSignerService) PublicKeys() ([]string, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockSignerService) Sign(pubkey string, request *models.SignRequest) ([]byte, error) {
+	args := m.Called(pubkey, request)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func TestPublicKeys(t *testing.T) {
+	mockService := new(MockSignerService)
+	mockService.On("PublicKeys").Return([]string{testPubkey}, nil)
+
+	handler := &SignerHandler{service: mockService}
+	w := httptest.NewRecorder()
+	handler.PublicKeys(w, httptest.NewRequest(http.MethodGet, "/api/v1/eth2/publicKeys", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `["`+testPubkey+`"]`, w.Body.String())
+}
+
+func TestSign(t *testing.T) {
+	mockService := new(MockSignerService)
+	handler := &SignerHandler{service: mockService}
+	router := mux.NewRouter()
+	router.HandleFunc("/api/v1/eth2/sign/{identifier}", handler.Sign).Methods("POST")
+
+	sign := func(body, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/eth2/sign/"+testPubkey, bytes.NewBufferString(body))
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("json signature", func(t *testing.T) {
+		mockService.On("Sign", testPubkey, &models.SignRequest{Type: models.SignTypeRandaoReveal, RandaoReveal: &models.RandaoReveal{Epoch: 5}}).
+			Return([]byte{0xab, 0xcd}, nil).Once()
+
+		w := sign(`{"type":"RANDAO_REVEAL","randao_reveal":{"epoch":"5"}}`, "application/json")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"signature":"0xabcd"}`, w.Body.String())
+	})
+
+	t.Run("plain text signature", func(t *testing.T) {
+		mockService.On("Sign", testPubkey, mock.Anything).Return([]byte{0xab, 0xcd}, nil).Once()
+
+		w := sign(`{"type":"RANDAO_REVEAL","randao_reveal":{"epoch":"5"}}`, "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "0xabcd", w.Body.String())
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		mockService.On("Sign", testPubkey, mock.Anything).Return(nil, models.ErrKeyNotFound).Once()
+
+		w := sign(`{"type":"RANDAO_REVEAL","randao_reveal":{"epoch":"5"}}`, "application/json")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("slashable message", func(t *testing.T) {
+		mockService.On("Sign", testPubkey, mock.Anything).Return(nil, models.ErrSlashable).Once()
+
+		w := sign(`{"type":"ATTESTATION"}`, "application/json")
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	})
+
+	t.Run("invalid request", func(t *testing.T) {
+		mockService.On("Sign", testPubkey, mock.Anything).Return(nil, models.ErrInvalidInput).Once()
+
+		assert.Equal(t, http.StatusBadRequest, sign(`{"type":"RANDAO_REVEAL"}`, "").Code)
+		assert.Equal(t, http.StatusBadRequest, sign(`{"type":"RANDAO_REVEAL","randao_reveal":{"epoch":5}}`, "").Code)
+	})
+}
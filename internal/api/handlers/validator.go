@@ -1,15 +1,25 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"github.com/gorilla/mux"
 	"net/http"
+	"stakeway_test_task/internal/keystore"
 	"stakeway_test_task/internal/models"
+	"strconv"
 )
 
+const keystorePasswordHeader = "X-Keystore-Password"
+
 type Validator interface {
 	CreateValidatorRequest(input *models.ValidatorRequestInput) (*models.ValidatorRequestResponse, error)
 	GetRequestStatus(requestID string) (*models.ValidatorStatusResponse, error)
+	GetKeystores(requestID, password, kdf string) ([]*keystore.Keystore, error)
+	GetDepositData(requestID string) ([]*models.DepositData, error)
+	SignVoluntaryExit(ctx context.Context, requestID, pubkey string, input *models.VoluntaryExitInput) (*models.SignedVoluntaryExit, error)
+	SignBLSToExecutionChanges(ctx context.Context, requestID string, input *models.BLSToExecutionChangeInput) ([]*models.SignedBLSToExecutionChange, error)
 }
 
 type ValidatorHandler struct {
@@ -28,6 +38,10 @@ func (h *ValidatorHandler) CreateValidator(w http.ResponseWriter, r *http.Reques
 	}
 
 	response, err := h.service.CreateValidatorRequest(&input)
+	if errors.Is(err, models.ErrQueueFull) {
+		writeServiceError(w, err)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -57,3 +71,120 @@ func (h *ValidatorHandler) GetValidatorStatus(w http.ResponseWriter, r *http.Req
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// GetKeystores exports the request's keys as EIP-2335 keystores. The password is taken from a header
+// rather than the query string so that it does not end up in access logs.
+func (h *ValidatorHandler) GetKeystores(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	requestID := vars["request_id"]
+
+	password := r.Header.Get(keystorePasswordHeader)
+	if password == "" {
+		http.Error(w, "Missing "+keystorePasswordHeader+" header", http.StatusBadRequest)
+		return
+	}
+
+	kdf := r.URL.Query().Get("kdf")
+	if kdf == "" {
+		kdf = keystore.KDFPBKDF2
+	}
+	if kdf != keystore.KDFPBKDF2 && kdf != keystore.KDFScrypt {
+		http.Error(w, keystore.ErrUnsupportedKDF.Error(), http.StatusBadRequest)
+		return
+	}
+
+	keystores, err := h.service.GetKeystores(requestID, password, kdf)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(keystores)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *ValidatorHandler) GetDepositData(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	requestID := vars["request_id"]
+
+	depositData, err := h.service.GetDepositData(requestID)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(depositData)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (h *ValidatorHandler) SignVoluntaryExit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var input models.VoluntaryExitInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	exit, err := h.service.SignVoluntaryExit(r.Context(), vars["request_id"], vars["pubkey"], &input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(exit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// SignBLSToExecutionChanges returns the changes as a JSON array that can be posted to a beacon node as is.
+func (h *ValidatorHandler) SignBLSToExecutionChanges(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var input models.BLSToExecutionChangeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	changes, err := h.service.SignBLSToExecutionChanges(r.Context(), vars["request_id"], &input)
+	if err != nil {
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(changes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// queueFullRetryAfter is the Retry-After in seconds sent with requests refused because the job queue is full.
+const queueFullRetryAfter = 30
+
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, models.ErrRequestNotFound), errors.Is(err, models.ErrKeyNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, models.ErrInvalidInput):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrKeysNotReady):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, models.ErrBeaconNode):
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	case errors.Is(err, models.ErrQueueFull):
+		w.Header().Set("Retry-After", strconv.Itoa(queueFullRetryAfter))
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -2,13 +2,16 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"net/http"
 	"net/http/httptest"
+	"stakeway_test_task/internal/keystore"
 	"stakeway_test_task/internal/models"
 	"testing"
 )
@@ -33,6 +36,38 @@ func (m *MockValidatorService) GetRequestStatus(requestID string) (*models.Valid
 	return args.Get(0).(*models.ValidatorStatusResponse), args.Error(1)
 }
 
+func (m *MockValidatorService) GetKeystores(requestID, password, kdf string) ([]*keystore.Keystore, error) {
+	args := m.Called(requestID, password, kdf)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*keystore.Keystore), args.Error(1)
+}
+
+func (m *MockValidatorService) GetDepositData(requestID string) ([]*models.DepositData, error) {
+	args := m.Called(requestID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.DepositData), args.Error(1)
+}
+
+func (m *MockValidatorService) SignVoluntaryExit(ctx context.Context, requestID, pubkey string, input *models.VoluntaryExitInput) (*models.SignedVoluntaryExit, error) {
+	args := m.Called(ctx, requestID, pubkey, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SignedVoluntaryExit), args.Error(1)
+}
+
+func (m *MockValidatorService) SignBLSToExecutionChanges(ctx context.Context, requestID string, input *models.BLSToExecutionChangeInput) ([]*models.SignedBLSToExecutionChange, error) {
+	args := m.Called(ctx, requestID, input)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.SignedBLSToExecutionChange), args.Error(1)
+}
+
 func TestCreateValidator(t *testing.T) {
 	t.Run("successful validator creation", func(t *testing.T) {
 		mockService := new(MockValidatorService)
@@ -105,6 +140,24 @@ func TestCreateValidator(t *testing.T) {
 
 		mockService.AssertCalled(t, "CreateValidatorRequest", mock.AnythingOfType("*models.ValidatorRequestInput"))
 	})
+
+	t.Run("queue full", func(t *testing.T) {
+		mockService := new(MockValidatorService)
+
+		mockService.On("CreateValidatorRequest", mock.AnythingOfType("*models.ValidatorRequestInput")).
+			Return(nil, fmt.Errorf("%w: 100 requests are waiting to be processed", models.ErrQueueFull))
+
+		handler := &ValidatorHandler{service: mockService}
+
+		body := []byte(`{"num_validators": 3, "fee_recipient": "0x1234567890abcdef1234567890abcdef12345678"}`)
+		req := httptest.NewRequest(http.MethodPost, "/validators", bytes.NewBuffer(body))
+		w := httptest.NewRecorder()
+
+		handler.CreateValidator(w, req)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	})
 }
 
 func TestGetValidatorStatus(t *testing.T) {
@@ -165,3 +218,225 @@ func TestGetValidatorStatus(t *testing.T) {
 		mockService.AssertCalled(t, "GetRequestStatus", "non-existent-id")
 	})
 }
+
+func TestGetKeystores(t *testing.T) {
+	newRequest := func(password, kdf string) *http.Request {
+		target := "/validators/test-uuid/keystores"
+		if kdf != "" {
+			target += "?kdf=" + kdf
+		}
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if password != "" {
+			req.Header.Set(keystorePasswordHeader, password)
+		}
+		return mux.SetURLVars(req, map[string]string{"request_id": "test-uuid"})
+	}
+
+	t.Run("successful export", func(t *testing.T) {
+		mockService := new(MockValidatorService)
+
+		expected := []*keystore.Keystore{{Pubkey: "9612", Path: "m/12381/3600/0/0/0", Version: 4}}
+		mockService.On("GetKeystores", "test-uuid", "secret", keystore.KDFPBKDF2).
+			Return(expected, nil)
+
+		handler := &ValidatorHandler{service: mockService}
+
+		w := httptest.NewRecorder()
+		handler.GetKeystores(w, newRequest("secret", ""))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response []*keystore.Keystore
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, expected[0].Pubkey, response[0].Pubkey)
+		assert.Equal(t, expected[0].Path, response[0].Path)
+	})
+
+	t.Run("missing password", func(t *testing.T) {
+		mockService := new(MockValidatorService)
+		handler := &ValidatorHandler{service: mockService}
+
+		w := httptest.NewRecorder()
+		handler.GetKeystores(w, newRequest("", ""))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "GetKeystores", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("unsupported kdf", func(t *testing.T) {
+		mockService := new(MockValidatorService)
+		handler := &ValidatorHandler{service: mockService}
+
+		w := httptest.NewRecorder()
+		handler.GetKeystores(w, newRequest("secret", "argon2"))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "GetKeystores", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("keys not ready", func(t *testing.T) {
+		mockService := new(MockValidatorService)
+
+		mockService.On("GetKeystores", "test-uuid", "secret", keystore.KDFScrypt).
+			Return(nil, models.ErrKeysNotReady)
+
+		handler := &ValidatorHandler{service: mockService}
+
+		w := httptest.NewRecorder()
+		handler.GetKeystores(w, newRequest("secret", keystore.KDFScrypt))
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("request not found", func(t *testing.T) {
+		mockService := new(MockValidatorService)
+
+		mockService.On("GetKeystores", "test-uuid", "secret", keystore.KDFPBKDF2).
+			Return(nil, models.ErrRequestNotFound)
+
+		handler := &ValidatorHandler{service: mockService}
+
+		w := httptest.NewRecorder()
+		handler.GetKeystores(w, newRequest("secret", ""))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestGetDepositData(t *testing.T) {
+	t.Run("successful retrieval", func(t *testing.T) {
+		mockService := new(MockValidatorService)
+
+		expected := []*models.DepositData{{
+			Pubkey:      "80df3c8c",
+			Amount:      32000000000,
+			ForkVersion: "01017000",
+			NetworkName: "holesky",
+		}}
+		mockService.On("GetDepositData", "test-uuid").Return(expected, nil)
+
+		handler := &ValidatorHandler{service: mockService}
+
+		req := httptest.NewRequest(http.MethodGet, "/validators/test-uuid/deposit-data", nil)
+		req = mux.SetURLVars(req, map[string]string{"request_id": "test-uuid"})
+		w := httptest.NewRecorder()
+
+		handler.GetDepositData(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var response []*models.DepositData
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, response)
+	})
+
+	t.Run("request not found", func(t *testing.T) {
+		mockService := new(MockValidatorService)
+
+		mockService.On("GetDepositData", "non-existent-id").Return(nil, models.ErrRequestNotFound)
+
+		handler := &ValidatorHandler{service: mockService}
+
+		req := httptest.NewRequest(http.MethodGet, "/validators/non-existent-id/deposit-data", nil)
+		req = mux.SetURLVars(req, map[string]string{"request_id": "non-existent-id"})
+		w := httptest.NewRecorder()
+
+		handler.GetDepositData(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestSignVoluntaryExit(t *testing.T) {
+	newRequest := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/validators/test-uuid/keys/"+testPubkey+"/exit", bytes.NewBufferString(body))
+		return mux.SetURLVars(req, map[string]string{"request_id": "test-uuid", "pubkey": testPubkey})
+	}
+	epoch := uint64(256)
+
+	t.Run("successful signing", func(t *testing.T) {
+		mockService := new(MockValidatorService)
+
+		exit := &models.SignedVoluntaryExit{Message: models.VoluntaryExit{Epoch: 256, ValidatorIndex: 42}, Signature: "0xab"}
+		mockService.On("SignVoluntaryExit", mock.Anything, "test-uuid", testPubkey, &models.VoluntaryExitInput{Epoch: &epoch, Broadcast: true}).
+			Return(exit, nil)
+
+		handler := &ValidatorHandler{service: mockService}
+
+		w := httptest.NewRecorder()
+		handler.SignVoluntaryExit(w, newRequest(`{"epoch": 256, "broadcast": true}`))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"message":{"epoch":"256","validator_index":"42"},"signature":"0xab"}`, w.Body.String())
+	})
+
+	t.Run("service errors", func(t *testing.T) {
+		for err, code := range map[error]int{
+			models.ErrKeyNotFound: http.StatusNotFound,
+			fmt.Errorf("%w: epoch is required", models.ErrInvalidInput): http.StatusBadRequest,
+			models.ErrKeysNotReady: http.StatusConflict,
+			fmt.Errorf("%w: connection refused", models.ErrBeaconNode): http.StatusBadGateway,
+		} {
+			mockService := new(MockValidatorService)
+			mockService.On("SignVoluntaryExit", mock.Anything, "test-uuid", testPubkey, mock.Anything).Return(nil, err)
+
+			handler := &ValidatorHandler{service: mockService}
+
+			w := httptest.NewRecorder()
+			handler.SignVoluntaryExit(w, newRequest(`{}`))
+
+			assert.Equal(t, code, w.Code, err.Error())
+		}
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		handler := &ValidatorHandler{service: new(MockValidatorService)}
+
+		w := httptest.NewRecorder()
+		handler.SignVoluntaryExit(w, newRequest(`{"epoch": "soon"}`))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+func TestSignBLSToExecutionChanges(t *testing.T) {
+	newRequest := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/validators/test-uuid/bls-to-execution-changes", bytes.NewBufferString(body))
+		return mux.SetURLVars(req, map[string]string{"request_id": "test-uuid"})
+	}
+	address := "0x1234567890abcdef1234567890abcdef12345678"
+
+	t.Run("successful signing", func(t *testing.T) {
+		mockService := new(MockValidatorService)
+
+		changes := []*models.SignedBLSToExecutionChange{{
+			Message:   models.BLSToExecutionChange{ValidatorIndex: 42, FromBLSPubkey: testPubkey, ToExecutionAddress: address},
+			Signature: "0xab",
+		}}
+		mockService.On("SignBLSToExecutionChanges", mock.Anything, "test-uuid", &models.BLSToExecutionChangeInput{ToExecutionAddress: address}).
+			Return(changes, nil)
+
+		handler := &ValidatorHandler{service: mockService}
+
+		w := httptest.NewRecorder()
+		handler.SignBLSToExecutionChanges(w, newRequest(`{"to_execution_address": "`+address+`"}`))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `[{"message":{"validator_index":"42","from_bls_pubkey":"`+testPubkey+`","to_execution_address":"`+address+`"},"signature":"0xab"}]`, w.Body.String())
+	})
+
+	t.Run("execution credentials already set", func(t *testing.T) {
+		mockService := new(MockValidatorService)
+		mockService.On("SignBLSToExecutionChanges", mock.Anything, "test-uuid", mock.Anything).
+			Return(nil, fmt.Errorf("%w: request test-uuid was not created with the service's BLS withdrawal credentials", models.ErrInvalidInput))
+
+		handler := &ValidatorHandler{service: mockService}
+
+		w := httptest.NewRecorder()
+		handler.SignBLSToExecutionChanges(w, newRequest(`{"to_execution_address": "`+address+`"}`))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
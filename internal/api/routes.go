@@ -4,31 +4,70 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"log/slog"
+	"net/http"
 	"stakeway_test_task/internal/api/handlers"
 	"stakeway_test_task/internal/api/middleware"
+	"stakeway_test_task/internal/eth2"
 	"stakeway_test_task/internal/repository"
 	services "stakeway_test_task/internal/service"
 )
 
-func SetupRoutes(repo *repository.ValidatorRepository, logger *slog.Logger) *mux.Router {
+// SetupRoutes serves validatorService, which is built by the caller since it also runs the background jobs.
+func SetupRoutes(repo *repository.ValidatorRepository, logger *slog.Logger, validatorService *services.ValidatorService, network *eth2.Network, keymanagerToken string) *mux.Router {
 	r := mux.NewRouter()
 
 	// services
-	validatorService := services.NewValidatorService(repo, logger)
+	slashingProtection := services.NewSlashingProtection(repo, network)
+	keymanagerService := services.NewKeymanagerService(repo, logger, network, slashingProtection)
+	signerService := services.NewSignerService(repo, logger, network, slashingProtection)
 
 	// handlers
 	validatorHandler := handlers.NewValidatorHandler(validatorService)
 	healthHandler := handlers.NewHealthHandler(repo)
+	keymanagerHandler := handlers.NewKeymanagerHandler(keymanagerService)
+	signerHandler := handlers.NewSignerHandler(signerService)
 
 	// middleware
 	r.Use(middleware.MetricsMiddleware)
 	r.Use(middleware.LoggingMiddleware(logger))
 
+	auth := middleware.BearerAuth(keymanagerToken)
+
 	// routes
 	r.HandleFunc("/validators", validatorHandler.CreateValidator).Methods("POST")
 	r.HandleFunc("/validators/{request_id}", validatorHandler.GetValidatorStatus).Methods("GET")
+	r.HandleFunc("/validators/{request_id}/deposit-data", validatorHandler.GetDepositData).Methods("GET")
+	// Keystores hold the secret keys, and signed exits and withdrawal credential changes are irreversible
+	// once broadcast, so they need the Keymanager API token.
+	r.Handle("/validators/{request_id}/keystores", auth(http.HandlerFunc(validatorHandler.GetKeystores))).Methods("GET")
+	r.Handle("/validators/{request_id}/keys/{pubkey}/exit", auth(http.HandlerFunc(validatorHandler.SignVoluntaryExit))).Methods("POST")
+	r.Handle("/validators/{request_id}/bls-to-execution-changes", auth(http.HandlerFunc(validatorHandler.SignBLSToExecutionChanges))).Methods("POST")
 	r.HandleFunc("/health", healthHandler.HealthCheck).Methods("GET")
 
+	// Keymanager API, https://github.com/ethereum/keymanager-APIs
+	keymanager := r.PathPrefix("/eth/v1").Subrouter()
+	keymanager.Use(auth)
+	keymanager.HandleFunc("/keystores", keymanagerHandler.ListKeystores).Methods("GET")
+	keymanager.HandleFunc("/keystores", keymanagerHandler.ImportKeystores).Methods("POST")
+	keymanager.HandleFunc("/keystores", keymanagerHandler.DeleteKeystores).Methods("DELETE")
+	keymanager.HandleFunc("/remotekeys", keymanagerHandler.ListRemoteKeys).Methods("GET")
+	keymanager.HandleFunc("/remotekeys", keymanagerHandler.ImportRemoteKeys).Methods("POST")
+	keymanager.HandleFunc("/remotekeys", keymanagerHandler.DeleteRemoteKeys).Methods("DELETE")
+	keymanager.HandleFunc("/validator/{pubkey}/feerecipient", keymanagerHandler.GetFeeRecipient).Methods("GET")
+	keymanager.HandleFunc("/validator/{pubkey}/feerecipient", keymanagerHandler.SetFeeRecipient).Methods("POST")
+	keymanager.HandleFunc("/validator/{pubkey}/feerecipient", keymanagerHandler.DeleteFeeRecipient).Methods("DELETE")
+
+	// EIP-3076 slashing protection interchange, protected by the Keymanager API token
+	r.Handle("/slashing-protection", auth(http.HandlerFunc(keymanagerHandler.ExportSlashingProtection))).Methods("GET")
+	r.Handle("/slashing-protection", auth(http.HandlerFunc(keymanagerHandler.ImportSlashingProtection))).Methods("POST")
+
+	// Web3Signer API, https://consensys.github.io/web3signer/web3signer-eth2.html. Signing needs the
+	// Keymanager API token: a single signature for a far future slot or epoch would lock a key out of
+	// signing through slashing protection.
+	r.HandleFunc("/upcheck", signerHandler.Upcheck).Methods("GET")
+	r.Handle("/api/v1/eth2/publicKeys", auth(http.HandlerFunc(signerHandler.PublicKeys))).Methods("GET")
+	r.Handle("/api/v1/eth2/sign/{identifier}", auth(http.HandlerFunc(signerHandler.Sign))).Methods("POST")
+
 	r.Handle("/metrics", promhttp.Handler())
 
 	return r
@@ -0,0 +1,166 @@
+package beacon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"stakeway_test_task/internal/models"
+	"strings"
+	"time"
+)
+
+var ErrValidatorNotFound = errors.New("validator not found")
+
+// maxValidatorIDs bounds the ids sent in a single query string, whose length some nodes and proxies limit to 8 KB.
+const maxValidatorIDs = 50
+
+// Validator statuses as defined by the Beacon Node API. withdrawal_done is final.
+const (
+	StatusPendingInitialized = "pending_initialized"
+	StatusPendingQueued      = "pending_queued"
+	StatusActiveOngoing      = "active_ongoing"
+	StatusActiveExiting      = "active_exiting"
+	StatusActiveSlashed      = "active_slashed"
+	StatusExitedUnslashed    = "exited_unslashed"
+	StatusExitedSlashed      = "exited_slashed"
+	StatusWithdrawalPossible = "withdrawal_possible"
+	StatusWithdrawalDone     = "withdrawal_done"
+)
+
+// Client talks to the standard Beacon Node REST API.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+type Validator struct {
+	Index     uint64        `json:"index,string"`
+	Balance   uint64        `json:"balance,string"`
+	Status    string        `json:"status"`
+	Validator ValidatorData `json:"validator"`
+}
+
+type ValidatorData struct {
+	Pubkey                string `json:"pubkey"`
+	WithdrawalCredentials string `json:"withdrawal_credentials"`
+	EffectiveBalance      uint64 `json:"effective_balance,string"`
+	Slashed               bool   `json:"slashed"`
+	ActivationEpoch       uint64 `json:"activation_epoch,string"`
+	ExitEpoch             uint64 `json:"exit_epoch,string"`
+	WithdrawableEpoch     uint64 `json:"withdrawable_epoch,string"`
+}
+
+type apiError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func NewClient(baseURL string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Validator returns the validator identified by a 0x-prefixed pubkey or an index at the given state ("head", "finalized", ...).
+func (c *Client) Validator(ctx context.Context, stateID, id string) (*Validator, error) {
+	var response struct {
+		Data Validator `json:"data"`
+	}
+	err := c.get(ctx, fmt.Sprintf("/eth/v1/beacon/states/%s/validators/%s", stateID, id), &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// Validators returns the validators identified by 0x-prefixed pubkeys or indices at the given state. Ids unknown
+// to the beacon node are left out of the result.
+func (c *Client) Validators(ctx context.Context, stateID string, ids []string) ([]Validator, error) {
+	var validators []Validator
+	for start := 0; start < len(ids); start += maxValidatorIDs {
+		end := min(start+maxValidatorIDs, len(ids))
+
+		var response struct {
+			Data []Validator `json:"data"`
+		}
+		query := url.Values{"id": {strings.Join(ids[start:end], ",")}}
+		err := c.get(ctx, fmt.Sprintf("/eth/v1/beacon/states/%s/validators?%s", stateID, query.Encode()), &response)
+		if err != nil {
+			return nil, err
+		}
+		validators = append(validators, response.Data...)
+	}
+	return validators, nil
+}
+
+// SubmitVoluntaryExit adds a signed voluntary exit to the beacon node's pool, from where it is gossiped
+// to the network.
+func (c *Client) SubmitVoluntaryExit(ctx context.Context, exit *models.SignedVoluntaryExit) error {
+	return c.post(ctx, "/eth/v1/beacon/pool/voluntary_exits", exit)
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("beacon node request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrValidatorNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode beacon node response: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) post(ctx context.Context, path string, in interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("beacon node request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	var apiErr apiError
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+		return fmt.Errorf("beacon node returned %d: %s", resp.StatusCode, apiErr.Message)
+	}
+	return fmt.Errorf("beacon node returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+}
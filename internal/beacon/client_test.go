@@ -0,0 +1,114 @@
+package beacon
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"stakeway_test_task/internal/models"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testPubkey = "0x80df3c8cb942676d7ade40b42a7306ea6275a9da4c3b952efb1a6dd9a9b4f2d21cca7463b7ca3cfe0dd09b6c335d5c26"
+
+func TestValidator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/eth/v1/beacon/states/head/validators/" + testPubkey:
+			w.Write([]byte(`{"execution_optimistic":false,"finalized":false,"data":{"index":"1234","balance":"32000123456","status":"active_ongoing",
+				"validator":{"pubkey":"` + testPubkey + `","withdrawal_credentials":"0x00ab","effective_balance":"32000000000","slashed":false,
+				"activation_eligibility_epoch":"10","activation_epoch":"20","exit_epoch":"18446744073709551615","withdrawable_epoch":"18446744073709551615"}}}`))
+		case "/eth/v1/beacon/states/head/validators/0x00":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":400,"message":"Invalid validator ID: 0x00"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":404,"message":"Validator not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL + "/")
+
+	t.Run("existing validator", func(t *testing.T) {
+		validator, err := client.Validator(context.Background(), "head", testPubkey)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1234), validator.Index)
+		assert.Equal(t, uint64(32000123456), validator.Balance)
+		assert.Equal(t, "active_ongoing", validator.Status)
+		assert.Equal(t, testPubkey, validator.Validator.Pubkey)
+		assert.Equal(t, uint64(20), validator.Validator.ActivationEpoch)
+		assert.Equal(t, uint64(18446744073709551615), validator.Validator.ExitEpoch)
+	})
+
+	t.Run("unknown validator", func(t *testing.T) {
+		_, err := client.Validator(context.Background(), "head", "0x1234")
+		assert.ErrorIs(t, err, ErrValidatorNotFound)
+	})
+
+	t.Run("error response", func(t *testing.T) {
+		_, err := client.Validator(context.Background(), "head", "0x00")
+		assert.ErrorContains(t, err, "Invalid validator ID")
+	})
+}
+
+func TestValidators(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/eth/v1/beacon/states/head/validators", r.URL.Path)
+		queries = append(queries, r.URL.Query().Get("id"))
+
+		// Only the first id is known to the node.
+		if strings.HasPrefix(r.URL.Query().Get("id"), "0") {
+			w.Write([]byte(`{"data":[{"index":"7","balance":"32000000000","status":"pending_queued","validator":{"pubkey":"` + testPubkey + `"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	ids := make([]string, maxValidatorIDs+10)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i)
+	}
+
+	validators, err := NewClient(server.URL).Validators(context.Background(), "head", ids)
+	require.NoError(t, err)
+	require.Len(t, validators, 1)
+	assert.Equal(t, uint64(7), validators[0].Index)
+	assert.Equal(t, StatusPendingQueued, validators[0].Status)
+
+	require.Len(t, queries, 2)
+	assert.Equal(t, strings.Join(ids[:maxValidatorIDs], ","), queries[0])
+	assert.Equal(t, strings.Join(ids[maxValidatorIDs:], ","), queries[1])
+}
+
+func TestSubmitVoluntaryExit(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/eth/v1/beacon/pool/voluntary_exits", r.URL.Path)
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+
+		if strings.Contains(body, `"validator_index":"2"`) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":400,"message":"Invalid voluntary exit: validator has not been active long enough"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL)
+
+	exit := &models.SignedVoluntaryExit{Message: models.VoluntaryExit{Epoch: 256, ValidatorIndex: 1}, Signature: "0xab"}
+	require.NoError(t, client.SubmitVoluntaryExit(context.Background(), exit))
+	assert.JSONEq(t, `{"message":{"epoch":"256","validator_index":"1"},"signature":"0xab"}`, body)
+
+	exit.Message.ValidatorIndex = 2
+	err := client.SubmitVoluntaryExit(context.Background(), exit)
+	assert.ErrorContains(t, err, "validator has not been active long enough")
+}
@@ -0,0 +1,64 @@
+package bls
+
+import (
+	"crypto/rand"
+	"errors"
+	blst "github.com/supranational/blst/bindings/go"
+)
+
+const (
+	SecretKeyLength = 32
+	PublicKeyLength = 48
+	SignatureLength = 96
+)
+
+// dst is the ciphersuite used by the Ethereum consensus layer (proof-of-possession, G2 signatures).
+var dst = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+
+type SecretKey struct {
+	sk *blst.SecretKey
+}
+
+func GenerateKey() (*SecretKey, error) {
+	ikm := make([]byte, 32)
+	if _, err := rand.Read(ikm); err != nil {
+		return nil, err
+	}
+
+	sk := blst.KeyGen(ikm)
+	if sk == nil {
+		return nil, errors.New("failed to generate BLS secret key")
+	}
+	return &SecretKey{sk: sk}, nil
+}
+
+func SecretKeyFromBytes(b []byte) (*SecretKey, error) {
+	if len(b) != SecretKeyLength {
+		return nil, errors.New("invalid BLS secret key length")
+	}
+
+	sk := new(blst.SecretKey).Deserialize(b)
+	if sk == nil {
+		return nil, errors.New("invalid BLS secret key")
+	}
+	return &SecretKey{sk: sk}, nil
+}
+
+func (k *SecretKey) Bytes() []byte {
+	return k.sk.Serialize()
+}
+
+func (k *SecretKey) PublicKey() []byte {
+	return new(blst.P1Affine).From(k.sk).Compress()
+}
+
+func (k *SecretKey) Sign(msg []byte) []byte {
+	return new(blst.P2Affine).Sign(k.sk, msg, dst).Compress()
+}
+
+func Verify(pubkey, msg, signature []byte) bool {
+	if len(pubkey) != PublicKeyLength || len(signature) != SignatureLength {
+		return false
+	}
+	return new(blst.P2Affine).VerifyCompressed(signature, true, pubkey, true, msg, dst)
+}
@@ -0,0 +1,47 @@
+package bls
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGenerateKey(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+
+	assert.Len(t, key.Bytes(), SecretKeyLength)
+	assert.Len(t, key.PublicKey(), PublicKeyLength)
+
+	restored, err := SecretKeyFromBytes(key.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, key.PublicKey(), restored.PublicKey())
+}
+
+func TestSecretKeyFromBytes(t *testing.T) {
+	t.Run("invalid length", func(t *testing.T) {
+		_, err := SecretKeyFromBytes([]byte{1, 2, 3})
+		assert.Error(t, err)
+	})
+
+	t.Run("zero key", func(t *testing.T) {
+		_, err := SecretKeyFromBytes(make([]byte, SecretKeyLength))
+		assert.Error(t, err)
+	})
+}
+
+func TestSignAndVerify(t *testing.T) {
+	key, err := GenerateKey()
+	require.NoError(t, err)
+
+	msg := []byte("signing root")
+	signature := key.Sign(msg)
+
+	assert.Len(t, signature, SignatureLength)
+	assert.True(t, Verify(key.PublicKey(), msg, signature))
+	assert.False(t, Verify(key.PublicKey(), []byte("other message"), signature))
+
+	other, err := GenerateKey()
+	require.NoError(t, err)
+	assert.False(t, Verify(other.PublicKey(), msg, signature))
+}
@@ -0,0 +1,68 @@
+package bls
+
+import (
+	"errors"
+	"fmt"
+	blst "github.com/supranational/blst/bindings/go"
+	"strconv"
+	"strings"
+)
+
+// EIP-2334 purpose and coin type of the Ethereum consensus layer.
+const (
+	purpose  = 12381
+	coinType = 3600
+)
+
+func SigningKeyPath(index int) string {
+	return fmt.Sprintf("m/%d/%d/%d/0/0", purpose, coinType, index)
+}
+
+func WithdrawalKeyPath(index int) string {
+	return fmt.Sprintf("m/%d/%d/%d/0", purpose, coinType, index)
+}
+
+func DeriveMasterKey(seed []byte) (*SecretKey, error) {
+	sk := blst.DeriveMasterEip2333(seed)
+	if sk == nil {
+		return nil, errors.New("seed must be at least 32 bytes")
+	}
+	return &SecretKey{sk: sk}, nil
+}
+
+func (k *SecretKey) DeriveChild(index uint32) *SecretKey {
+	return &SecretKey{sk: k.sk.DeriveChildEip2333(index)}
+}
+
+func DeriveKey(seed []byte, path string) (*SecretKey, error) {
+	indices, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := DeriveMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range indices {
+		key = key.DeriveChild(index)
+	}
+	return key, nil
+}
+
+func parsePath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if parts[0] != "m" {
+		return nil, fmt.Errorf("invalid derivation path %q", path)
+	}
+
+	indices := make([]uint32, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid derivation path %q", path)
+		}
+		indices = append(indices, uint32(index))
+	}
+	return indices, nil
+}
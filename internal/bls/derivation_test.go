@@ -0,0 +1,69 @@
+package bls
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+// Test vectors from EIP-2333.
+func TestDeriveChild(t *testing.T) {
+	tests := []struct {
+		name       string
+		seed       string
+		masterSK   string
+		childIndex uint32
+		childSK    string
+	}{
+		{
+			name:       "test case 0",
+			seed:       "0xc55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+			masterSK:   "6083874454709270928345386274498605044986640685124978867557563392430687146096",
+			childIndex: 0,
+			childSK:    "20397789859736650942317412262472558107875392172444076792671091975210932703118",
+		},
+		{
+			name:       "test case 1",
+			seed:       "0x3141592653589793238462643383279502884197169399375105820974944592",
+			masterSK:   "29757020647961307431480504535336562678282505419141012933316116377660817309383",
+			childIndex: 3141592653,
+			childSK:    "25457201688850691947727629385191704516744796114925897962676248250929345014287",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			master, err := DeriveMasterKey(hexutil.MustDecode(tt.seed))
+			require.NoError(t, err)
+			assert.Equal(t, tt.masterSK, new(big.Int).SetBytes(master.Bytes()).String())
+
+			child := master.DeriveChild(tt.childIndex)
+			assert.Equal(t, tt.childSK, new(big.Int).SetBytes(child.Bytes()).String())
+		})
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	seed := hexutil.MustDecode("0x3141592653589793238462643383279502884197169399375105820974944592")
+
+	key, err := DeriveKey(seed, SigningKeyPath(1))
+	require.NoError(t, err)
+
+	master, err := DeriveMasterKey(seed)
+	require.NoError(t, err)
+	expected := master.DeriveChild(12381).DeriveChild(3600).DeriveChild(1).DeriveChild(0).DeriveChild(0)
+	assert.Equal(t, expected.Bytes(), key.Bytes())
+
+	_, err = DeriveKey(seed, "m/12381/x")
+	assert.Error(t, err)
+
+	_, err = DeriveKey([]byte("short"), SigningKeyPath(0))
+	assert.Error(t, err)
+}
+
+func TestKeyPaths(t *testing.T) {
+	assert.Equal(t, "m/12381/3600/7/0/0", SigningKeyPath(7))
+	assert.Equal(t, "m/12381/3600/7/0", WithdrawalKeyPath(7))
+}
@@ -0,0 +1,25 @@
+package deposit
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/params"
+	"math/big"
+	"stakeway_test_task/internal/eth2"
+	"strings"
+)
+
+// Value converts a deposit amount in gwei to the transaction value in wei. The deposit contract
+// derives the amount from msg.value, so it has to match the signed amount exactly.
+func Value(amountGwei uint64) *big.Int {
+	return new(big.Int).Mul(new(big.Int).SetUint64(amountGwei), big.NewInt(params.GWei))
+}
+
+func WeiToGwei(wei *big.Int) uint64 {
+	return new(big.Int).Div(wei, big.NewInt(params.GWei)).Uint64()
+}
+
+// FormatGwei formats a gwei amount as ETH.
+func FormatGwei(amountGwei uint64) string {
+	eth := fmt.Sprintf("%d.%09d", amountGwei/eth2.GweiPerEth, amountGwei%eth2.GweiPerEth)
+	return strings.TrimSuffix(strings.TrimRight(eth, "0"), ".")
+}
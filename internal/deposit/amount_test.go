@@ -0,0 +1,17 @@
+package deposit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValue(t *testing.T) {
+	assert.Equal(t, "32000000000000000000", Value(32000000000).String())
+	assert.Equal(t, "1000000000000000000", Value(1000000000).String())
+}
+
+func TestFormatGwei(t *testing.T) {
+	assert.Equal(t, "32", FormatGwei(32000000000))
+	assert.Equal(t, "1.5", FormatGwei(1500000000))
+	assert.Equal(t, "0.000000001", FormatGwei(1))
+}
@@ -0,0 +1,146 @@
+// Package deposit loads and verifies deposit data and submits it to the deposit contract, either
+// directly through a node or as transactions signed offline and broadcast later.
+package deposit
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"stakeway_test_task/internal/eth2"
+	"strings"
+)
+
+// CustomNetwork is the name of user-defined network profiles. They have no canonical name, so deposit data
+// is only bound to them by the fork version.
+const CustomNetwork = "custom"
+
+// Data is one entry of a deposit_data.json file as produced by the staking deposit CLI.
+type Data struct {
+	Pubkey                string `json:"pubkey"`
+	WithdrawalCredentials string `json:"withdrawal_credentials"`
+	Amount                uint64 `json:"amount"`
+	Signature             string `json:"signature"`
+	DepositMessageRoot    string `json:"deposit_message_root"`
+	DepositDataRoot       string `json:"deposit_data_root"`
+	ForkVersion           string `json:"fork_version"`
+	NetworkName           string `json:"network_name"`
+}
+
+func Load(path string) ([]Data, error) {
+	jsonFile, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+
+	var depositDataList []Data
+	if err := json.Unmarshal(jsonFile, &depositDataList); err != nil {
+		return nil, fmt.Errorf("error parsing JSON: %w", err)
+	}
+
+	if len(depositDataList) == 0 {
+		return nil, fmt.Errorf("file does not contain any deposit data")
+	}
+
+	return depositDataList, nil
+}
+
+// Verify performs the checks the deposit contract and the beacon chain apply to a deposit,
+// so that nothing is submitted that would be rejected or whose funds would be lost.
+func Verify(depositData Data, network *eth2.Network) error {
+	if network.Name != CustomNetwork && depositData.NetworkName != network.Name {
+		return fmt.Errorf("deposit data is for network %q, but the target network is %q", depositData.NetworkName, network.Name)
+	}
+
+	var forkVersion [4]byte
+	if err := decodeHex(depositData.ForkVersion, forkVersion[:], "fork version"); err != nil {
+		return err
+	}
+	if forkVersion != network.GenesisForkVersion {
+		return fmt.Errorf("fork version %x does not match %s genesis fork version %x", forkVersion, network.Name, network.GenesisForkVersion)
+	}
+
+	data, err := decode(depositData)
+	if err != nil {
+		return err
+	}
+
+	if err := eth2.ValidateWithdrawalCredentials(data.WithdrawalCredentials); err != nil {
+		return err
+	}
+
+	if data.Amount < eth2.MinDepositAmount {
+		return fmt.Errorf("amount %d gwei is below the minimum deposit of %d gwei", data.Amount, eth2.MinDepositAmount)
+	}
+
+	messageRoot := data.Message().HashTreeRoot()
+	if err := compareRoot(depositData.DepositMessageRoot, messageRoot, "deposit message root"); err != nil {
+		return err
+	}
+
+	dataRoot := data.HashTreeRoot()
+	if err := compareRoot(depositData.DepositDataRoot, dataRoot, "deposit data root"); err != nil {
+		return err
+	}
+
+	if !eth2.VerifyDeposit(data, network) {
+		return fmt.Errorf("invalid deposit signature")
+	}
+
+	return nil
+}
+
+func VerifyAll(depositDataList []Data, network *eth2.Network) error {
+	for i, depositData := range depositDataList {
+		if err := Verify(depositData, network); err != nil {
+			return fmt.Errorf("deposit %d (%s): %w", i, depositData.Pubkey, err)
+		}
+	}
+	return nil
+}
+
+func ParseForkVersion(value string) ([4]byte, error) {
+	var forkVersion [4]byte
+	err := decodeHex(value, forkVersion[:], "fork version")
+	return forkVersion, err
+}
+
+func decode(depositData Data) (*eth2.DepositData, error) {
+	data := &eth2.DepositData{Amount: depositData.Amount}
+
+	if err := decodeHex(depositData.Pubkey, data.Pubkey[:], "pubkey"); err != nil {
+		return nil, err
+	}
+	if err := decodeHex(depositData.WithdrawalCredentials, data.WithdrawalCredentials[:], "withdrawal credentials"); err != nil {
+		return nil, err
+	}
+	if err := decodeHex(depositData.Signature, data.Signature[:], "signature"); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func decodeHex(value string, dst []byte, field string) error {
+	decoded, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil {
+		return fmt.Errorf("error decoding %s: %w", field, err)
+	}
+	if len(decoded) != len(dst) {
+		return fmt.Errorf("invalid %s length: expected %d bytes, got %d", field, len(dst), len(decoded))
+	}
+	copy(dst, decoded)
+	return nil
+}
+
+func compareRoot(expected string, actual eth2.Root, field string) error {
+	var root eth2.Root
+	if err := decodeHex(expected, root[:], field); err != nil {
+		return err
+	}
+	if !bytes.Equal(root[:], actual[:]) {
+		return fmt.Errorf("%s mismatch: file has %x, computed %x", field, root, actual)
+	}
+	return nil
+}
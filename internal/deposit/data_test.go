@@ -0,0 +1,88 @@
+package deposit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"stakeway_test_task/internal/eth2"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	depositDataList, err := Load("../../deposit_data.json")
+	require.NoError(t, err)
+
+	holesky, err := eth2.NetworkByName("holesky")
+	require.NoError(t, err)
+
+	t.Run("valid deposit data", func(t *testing.T) {
+		assert.NoError(t, VerifyAll(depositDataList, holesky))
+	})
+
+	t.Run("wrong network", func(t *testing.T) {
+		mainnet, err := eth2.NetworkByName("mainnet")
+		require.NoError(t, err)
+
+		err = Verify(depositDataList[0], mainnet)
+		assert.ErrorContains(t, err, "target network is \"mainnet\"")
+	})
+
+	t.Run("custom network ignores network name", func(t *testing.T) {
+		custom := &eth2.Network{Name: CustomNetwork, GenesisForkVersion: holesky.GenesisForkVersion}
+		assert.NoError(t, Verify(depositDataList[0], custom))
+
+		custom.GenesisForkVersion = [4]byte{0x10, 0x00, 0x00, 0x38}
+		assert.Error(t, Verify(depositDataList[0], custom))
+	})
+
+	t.Run("tampered amount", func(t *testing.T) {
+		depositData := depositDataList[0]
+		depositData.Amount = 1000000000
+
+		err := Verify(depositData, holesky)
+		assert.ErrorContains(t, err, "deposit message root mismatch")
+	})
+
+	t.Run("amount below minimum", func(t *testing.T) {
+		depositData := depositDataList[0]
+		depositData.Amount = 1
+
+		err := Verify(depositData, holesky)
+		assert.ErrorContains(t, err, "below the minimum deposit")
+	})
+
+	t.Run("unsupported withdrawal credentials prefix", func(t *testing.T) {
+		depositData := depositDataList[0]
+		depositData.WithdrawalCredentials = "03" + depositData.WithdrawalCredentials[2:]
+
+		err := Verify(depositData, holesky)
+		assert.ErrorContains(t, err, "unsupported withdrawal credentials prefix 0x03")
+	})
+
+	t.Run("withdrawal credentials not zero-padded", func(t *testing.T) {
+		depositData := depositDataList[0]
+		depositData.WithdrawalCredentials = "01ff0000000000000000000000" + depositData.WithdrawalCredentials[26:]
+
+		err := Verify(depositData, holesky)
+		assert.ErrorContains(t, err, "must be zero-padded")
+	})
+
+	t.Run("tampered data root", func(t *testing.T) {
+		depositData := depositDataList[0]
+		depositData.DepositDataRoot = "00" + depositData.DepositDataRoot[2:]
+
+		err := Verify(depositData, holesky)
+		assert.ErrorContains(t, err, "deposit data root mismatch")
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		depositData := depositDataList[0]
+		depositData.ForkVersion = "00000000"
+		depositData.NetworkName = "mainnet"
+
+		mainnet, err := eth2.NetworkByName("mainnet")
+		require.NoError(t, err)
+
+		err = Verify(depositData, mainnet)
+		assert.ErrorContains(t, err, "invalid deposit signature")
+	})
+}
@@ -0,0 +1,218 @@
+package deposit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"io"
+	"sort"
+	"stakeway_test_task/internal/beacon"
+	"stakeway_test_task/internal/depositcontract"
+	"strings"
+)
+
+const (
+	SourceLogs   = "logs"
+	SourceBeacon = "beacon"
+
+	DefaultLogChunkSize = 10000
+)
+
+// LogReader is the part of the node API needed to scan deposit contract logs.
+type LogReader interface {
+	bind.ContractFilterer
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
+// ExistingDeposit describes where a pubkey was found to be deposited already.
+type ExistingDeposit struct {
+	Source      string
+	TxHash      common.Hash
+	BlockNumber uint64
+	Status      string
+}
+
+func (d ExistingDeposit) String() string {
+	if d.Source == SourceBeacon {
+		return fmt.Sprintf("known to the beacon node with status %s", d.Status)
+	}
+	return fmt.Sprintf("deposited in transaction %s (block %d)", d.TxHash.Hex(), d.BlockNumber)
+}
+
+// DuplicateChecker looks up pubkeys in the DepositEvent logs of Contract, on a beacon node, or both.
+// A nil Logs or Beacon skips that source.
+type DuplicateChecker struct {
+	Logs      LogReader
+	Beacon    *beacon.Client
+	Contract  common.Address
+	FromBlock uint64
+	// ChunkSize is the largest block range queried at once, DefaultLogChunkSize if zero.
+	ChunkSize uint64
+	// Progress, if set, is told about long log scans.
+	Progress io.Writer
+}
+
+// Find returns the pubkeys (lowercase hex without 0x) that already have a deposit on chain. The beacon node
+// only knows deposits it has processed, while the logs also cover deposits still waiting in the queue.
+func (c *DuplicateChecker) Find(ctx context.Context, pubkeys []string) (map[string]ExistingDeposit, error) {
+	wanted := make(map[string]bool, len(pubkeys))
+	for _, pubkey := range pubkeys {
+		wanted[normalizePubkey(pubkey)] = true
+	}
+	found := make(map[string]ExistingDeposit)
+
+	if c.Beacon != nil {
+		for pubkey := range wanted {
+			validator, err := c.Beacon.Validator(ctx, "head", "0x"+pubkey)
+			if errors.Is(err, beacon.ErrValidatorNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("error querying beacon node for %s: %w", pubkey, err)
+			}
+			found[pubkey] = ExistingDeposit{Source: SourceBeacon, Status: validator.Status}
+		}
+	}
+
+	if c.Logs != nil {
+		if err := c.scanLogs(ctx, wanted, found); err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// scanLogs walks the DepositEvent logs in block ranges, halving the range whenever the node rejects a query
+// as too large and growing it back after successful queries.
+func (c *DuplicateChecker) scanLogs(ctx context.Context, wanted map[string]bool, found map[string]ExistingDeposit) error {
+	head, err := c.Logs.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting latest block: %w", err)
+	}
+	if c.Progress != nil {
+		fmt.Fprintf(c.Progress, "Scanning DepositEvent logs of %s from block %d to %d\n", c.Contract.Hex(), c.FromBlock, head)
+	}
+
+	filterer, err := depositcontract.NewDepositContractFilterer(c.Contract, c.Logs)
+	if err != nil {
+		return err
+	}
+
+	chunkSize := c.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = DefaultLogChunkSize
+	}
+	chunk := chunkSize
+	for from := c.FromBlock; from <= head; {
+		to := from + chunk - 1
+		if to > head {
+			to = head
+		}
+
+		events, err := filterer.FilterDepositEvent(&bind.FilterOpts{Start: from, End: &to, Context: ctx})
+		if err != nil {
+			if chunk > 1 && ctx.Err() == nil {
+				chunk /= 2
+				continue
+			}
+			return fmt.Errorf("error reading deposit logs in blocks %d-%d: %w", from, to, err)
+		}
+
+		for events.Next() {
+			pubkey := normalizePubkey(hexutil.Encode(events.Event.Pubkey))
+			if _, seen := found[pubkey]; wanted[pubkey] && !seen {
+				found[pubkey] = ExistingDeposit{Source: SourceLogs, TxHash: events.Event.Raw.TxHash, BlockNumber: events.Event.Raw.BlockNumber}
+			}
+		}
+		if err := events.Error(); err != nil {
+			return fmt.Errorf("error decoding DepositEvent: %w", err)
+		}
+		events.Close()
+		from = to + 1
+		if chunk < chunkSize {
+			chunk = min(chunk*2, chunkSize)
+		}
+	}
+	return nil
+}
+
+// Candidate is a deposit about to be sent. TxHash is set when its transaction was already signed,
+// so that a log of that very transaction is not reported as a duplicate.
+type Candidate struct {
+	Label  string
+	Pubkey string
+	TxHash common.Hash
+}
+
+// NewCandidates lists the deposits that are not tracked in state yet (state may be nil).
+func NewCandidates(depositDataList []Data, state Store) []Candidate {
+	var candidates []Candidate
+	for i, depositData := range depositDataList {
+		if state != nil {
+			if _, ok := state.Get(depositData.DepositDataRoot); ok {
+				continue
+			}
+		}
+		candidates = append(candidates, Candidate{Label: fmt.Sprintf("deposit %d", i), Pubkey: depositData.Pubkey})
+	}
+	return candidates
+}
+
+// ReportDuplicates prints every candidate whose pubkey is already deposited or repeated among the candidates
+// and returns how many were found. A nil checker only looks for repeats.
+func ReportDuplicates(ctx context.Context, checker *DuplicateChecker, candidates []Candidate, out io.Writer) (int, error) {
+	pubkeys := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		pubkeys = append(pubkeys, candidate.Pubkey)
+	}
+
+	existing := make(map[string]ExistingDeposit)
+	if checker != nil && len(candidates) > 0 {
+		var err error
+		if existing, err = checker.Find(ctx, pubkeys); err != nil {
+			return 0, err
+		}
+	}
+
+	repeated := make(map[string]bool)
+	for _, pubkey := range duplicatesInFile(pubkeys) {
+		repeated[pubkey] = true
+	}
+
+	count := 0
+	for _, candidate := range candidates {
+		pubkey := normalizePubkey(candidate.Pubkey)
+		if deposit, ok := existing[pubkey]; ok && (deposit.TxHash == (common.Hash{}) || deposit.TxHash != candidate.TxHash) {
+			fmt.Fprintf(out, "%s (%s): already %s\n", candidate.Label, candidate.Pubkey, deposit)
+			count++
+		} else if repeated[pubkey] {
+			fmt.Fprintf(out, "%s (%s): pubkey appears more than once in the input\n", candidate.Label, candidate.Pubkey)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// duplicatesInFile reports pubkeys that appear more than once in the list.
+func duplicatesInFile(pubkeys []string) []string {
+	counts := make(map[string]int)
+	for _, pubkey := range pubkeys {
+		counts[normalizePubkey(pubkey)]++
+	}
+
+	var duplicates []string
+	for pubkey, count := range counts {
+		if count > 1 {
+			duplicates = append(duplicates, pubkey)
+		}
+	}
+	sort.Strings(duplicates)
+	return duplicates
+}
+
+func normalizePubkey(pubkey string) string {
+	return strings.ToLower(strings.TrimPrefix(pubkey, "0x"))
+}
@@ -0,0 +1,140 @@
+package deposit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"stakeway_test_task/internal/beacon"
+	"stakeway_test_task/internal/depositcontract"
+	"strings"
+	"testing"
+)
+
+// fakeLogs serves DepositEvent logs and rejects queries spanning more than maxRange blocks, like public RPCs do.
+type fakeLogs struct {
+	head     uint64
+	maxRange uint64
+	logs     []types.Log
+	queries  int
+}
+
+func (f *fakeLogs) BlockNumber(ctx context.Context) (uint64, error) {
+	return f.head, nil
+}
+
+func (f *fakeLogs) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	f.queries++
+	from, to := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+	if to-from+1 > f.maxRange {
+		return nil, errors.New("query exceeds max block range")
+	}
+
+	var logs []types.Log
+	for _, log := range f.logs {
+		if log.BlockNumber >= from && log.BlockNumber <= to && log.Address == q.Addresses[0] && log.Topics[0] == q.Topics[0][0] {
+			logs = append(logs, log)
+		}
+	}
+	return logs, nil
+}
+
+func (f *fakeLogs) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("not supported")
+}
+
+func depositEventLog(t *testing.T, contractABI *abi.ABI, contract common.Address, pubkey string, block uint64, txHash common.Hash) types.Log {
+	event := contractABI.Events["DepositEvent"]
+	data, err := event.Inputs.Pack(hexutil.MustDecode("0x"+pubkey), make([]byte, 32), make([]byte, 8), make([]byte, 96), make([]byte, 8))
+	require.NoError(t, err)
+
+	return types.Log{Address: contract, Topics: []common.Hash{event.ID}, Data: data, BlockNumber: block, TxHash: txHash}
+}
+
+func TestDuplicateChecker(t *testing.T) {
+	contractABI, err := depositcontract.DepositContractMetaData.GetAbi()
+	require.NoError(t, err)
+	contract := common.HexToAddress("0x4242424242424242424242424242424242424242")
+
+	deposited := strings.Repeat("aa", 48)
+	fresh := strings.Repeat("bb", 48)
+	other := strings.Repeat("cc", 48)
+
+	logs := &fakeLogs{
+		head:     250,
+		maxRange: 30,
+		logs: []types.Log{
+			depositEventLog(t, contractABI, contract, other, 50, common.HexToHash("0x01")),
+			depositEventLog(t, contractABI, contract, deposited, 180, common.HexToHash("0x02")),
+			depositEventLog(t, contractABI, common.HexToAddress("0x1111111111111111111111111111111111111111"), fresh, 200, common.HexToHash("0x03")),
+		},
+	}
+
+	checker := &DuplicateChecker{Logs: logs, Contract: contract, FromBlock: 10, ChunkSize: 100}
+
+	t.Run("log scan", func(t *testing.T) {
+		found, err := checker.Find(context.Background(), []string{"0x" + deposited, fresh})
+		require.NoError(t, err)
+
+		require.Len(t, found, 1)
+		assert.Equal(t, common.HexToHash("0x02"), found[deposited].TxHash)
+		assert.Equal(t, uint64(180), found[deposited].BlockNumber)
+		assert.Greater(t, logs.queries, 1)
+	})
+
+	t.Run("beacon node", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if strings.HasSuffix(r.URL.Path, "/validators/0x"+fresh) {
+				w.Write([]byte(`{"data":{"index":"7","balance":"32000000000","status":"pending_queued","validator":{"pubkey":"0x` + fresh + `"}}}`))
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		checker := &DuplicateChecker{Beacon: beacon.NewClient(server.URL)}
+		found, err := checker.Find(context.Background(), []string{deposited, fresh})
+		require.NoError(t, err)
+
+		require.Len(t, found, 1)
+		assert.Equal(t, "pending_queued", found[fresh].Status)
+	})
+
+	t.Run("report", func(t *testing.T) {
+		candidates := []Candidate{
+			{Label: "deposit 0", Pubkey: deposited},
+			{Label: "deposit 1", Pubkey: fresh},
+			{Label: "deposit 2", Pubkey: fresh},
+			{Label: "deposit 3", Pubkey: other, TxHash: common.HexToHash("0x01")},
+		}
+
+		var out bytes.Buffer
+		count, err := ReportDuplicates(context.Background(), checker, candidates, &out)
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+		assert.Contains(t, out.String(), "deposit 0 ("+deposited+"): already deposited in transaction")
+		assert.Contains(t, out.String(), "deposit 2 ("+fresh+"): pubkey appears more than once")
+		assert.NotContains(t, out.String(), "deposit 3")
+	})
+
+	t.Run("deposits tracked in state are skipped", func(t *testing.T) {
+		depositDataList, err := Load("../../deposit_data.json")
+		require.NoError(t, err)
+
+		state, err := LoadState(filepath.Join(t.TempDir(), "state.json"))
+		require.NoError(t, err)
+		assert.Len(t, NewCandidates(depositDataList, state), 1)
+
+		require.NoError(t, state.Put(depositDataList[0].DepositDataRoot, Record{Status: StatusSent}))
+		assert.Empty(t, NewCandidates(depositDataList, state))
+	})
+}
@@ -0,0 +1,101 @@
+package deposit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/params"
+	"math/big"
+	"strings"
+)
+
+// FeeSettings holds the fee overrides of a submitter. Nil fees and a zero gas limit mean "ask the node".
+type FeeSettings struct {
+	MaxFee         *big.Int
+	MaxPriorityFee *big.Int
+	GasLimit       uint64
+	// GasMargin is added to the estimated gas, in percent.
+	GasMargin uint64
+}
+
+type FeeCaps struct {
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+// Caps computes the EIP-1559 fee caps from the latest base fee and the suggested tip. The default fee
+// cap of twice the base fee keeps the transaction includable through six consecutive full blocks.
+func (s FeeSettings) Caps(baseFee, suggestedTip *big.Int) (FeeCaps, error) {
+	tip := suggestedTip
+	if s.MaxPriorityFee != nil {
+		tip = s.MaxPriorityFee
+	}
+
+	feeCap := new(big.Int).Add(new(big.Int).Mul(baseFee, big.NewInt(2)), tip)
+	if s.MaxFee != nil {
+		feeCap = s.MaxFee
+		if tip.Cmp(feeCap) > 0 {
+			tip = feeCap
+		}
+	}
+
+	if feeCap.Cmp(baseFee) < 0 {
+		return FeeCaps{}, fmt.Errorf("max fee %s gwei is below the current base fee %s gwei", FormatWeiAsGwei(feeCap), FormatWeiAsGwei(baseFee))
+	}
+	return FeeCaps{GasTipCap: new(big.Int).Set(tip), GasFeeCap: new(big.Int).Set(feeCap)}, nil
+}
+
+// FixedCaps returns the fee caps for offline signing, where there is no node to ask for the base fee.
+func (s FeeSettings) FixedCaps() (FeeCaps, error) {
+	if s.MaxFee == nil || s.MaxPriorityFee == nil || s.GasLimit == 0 {
+		return FeeCaps{}, errors.New("max fee, max priority fee and gas limit are required without a node connection")
+	}
+	return FeeCaps{GasTipCap: s.MaxPriorityFee, GasFeeCap: s.MaxFee}, nil
+}
+
+func (s FeeSettings) WithMargin(estimated uint64) uint64 {
+	return estimated + estimated*s.GasMargin/100
+}
+
+// suggestFees queries the node for the latest base fee and a priority fee suggestion, unless both are overridden.
+func (s *Submitter) suggestFees(ctx context.Context) (FeeCaps, error) {
+	head, err := s.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return FeeCaps{}, fmt.Errorf("error getting latest block: %w", err)
+	}
+	if head.BaseFee == nil {
+		return FeeCaps{}, errors.New("the connected chain does not support EIP-1559 transactions")
+	}
+
+	suggestedTip := s.fees.MaxPriorityFee
+	if suggestedTip == nil {
+		suggestedTip, err = s.backend.SuggestGasTipCap(ctx)
+		if err != nil {
+			return FeeCaps{}, fmt.Errorf("error getting priority fee suggestion: %w", err)
+		}
+	}
+	return s.fees.Caps(head.BaseFee, suggestedTip)
+}
+
+// ParseGwei parses a decimal gwei amount such as "1.5" into wei.
+func ParseGwei(value string) (*big.Int, error) {
+	whole, fraction, _ := strings.Cut(strings.TrimSpace(value), ".")
+	if len(fraction) > 9 {
+		return nil, fmt.Errorf("%q has more than 9 decimal places", value)
+	}
+
+	wei, ok := new(big.Int).SetString(whole+fraction+strings.Repeat("0", 9-len(fraction)), 10)
+	if !ok || wei.Sign() < 0 || whole == "" && fraction == "" {
+		return nil, fmt.Errorf("%q is not a gwei amount", value)
+	}
+	return wei, nil
+}
+
+// FormatWeiAsGwei formats a wei amount, typically a fee per gas, as gwei.
+func FormatWeiAsGwei(wei *big.Int) string {
+	gwei, rest := new(big.Int).QuoRem(wei, big.NewInt(params.GWei), new(big.Int))
+	if rest.Sign() == 0 {
+		return gwei.String()
+	}
+	return strings.TrimRight(fmt.Sprintf("%s.%09d", gwei, rest), "0")
+}
@@ -0,0 +1,76 @@
+package deposit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"math/big"
+	"testing"
+)
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1000000000))
+}
+
+func TestParseGwei(t *testing.T) {
+	for value, expected := range map[string]*big.Int{
+		"30":          gwei(30),
+		"1.5":         big.NewInt(1500000000),
+		"0.000000001": big.NewInt(1),
+		".25":         big.NewInt(250000000),
+	} {
+		wei, err := ParseGwei(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, expected, wei, value)
+	}
+
+	for _, value := range []string{"", ".", "-1", "abc", "1.0000000001", "1.2.3"} {
+		_, err := ParseGwei(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestFormatWeiAsGwei(t *testing.T) {
+	assert.Equal(t, "30", FormatWeiAsGwei(gwei(30)))
+	assert.Equal(t, "1.5", FormatWeiAsGwei(big.NewInt(1500000000)))
+	assert.Equal(t, "0.000000001", FormatWeiAsGwei(big.NewInt(1)))
+}
+
+func TestFeeCaps(t *testing.T) {
+	baseFee := gwei(10)
+	suggestedTip := gwei(2)
+
+	t.Run("suggested fees", func(t *testing.T) {
+		caps, err := FeeSettings{}.Caps(baseFee, suggestedTip)
+		require.NoError(t, err)
+		assert.Equal(t, gwei(2), caps.GasTipCap)
+		assert.Equal(t, gwei(22), caps.GasFeeCap)
+	})
+
+	t.Run("priority fee override", func(t *testing.T) {
+		caps, err := FeeSettings{MaxPriorityFee: gwei(1)}.Caps(baseFee, suggestedTip)
+		require.NoError(t, err)
+		assert.Equal(t, gwei(1), caps.GasTipCap)
+		assert.Equal(t, gwei(21), caps.GasFeeCap)
+	})
+
+	t.Run("max fee override caps the tip", func(t *testing.T) {
+		caps, err := FeeSettings{MaxFee: gwei(11), MaxPriorityFee: gwei(5)}.Caps(baseFee, suggestedTip)
+		require.NoError(t, err)
+		assert.Equal(t, gwei(5), caps.GasTipCap)
+		assert.Equal(t, gwei(11), caps.GasFeeCap)
+
+		caps, err = FeeSettings{MaxFee: gwei(11)}.Caps(baseFee, gwei(20))
+		require.NoError(t, err)
+		assert.Equal(t, gwei(11), caps.GasTipCap)
+	})
+
+	t.Run("max fee below base fee", func(t *testing.T) {
+		_, err := FeeSettings{MaxFee: gwei(5)}.Caps(baseFee, suggestedTip)
+		assert.ErrorContains(t, err, "below the current base fee")
+	})
+
+	t.Run("gas margin", func(t *testing.T) {
+		assert.Equal(t, uint64(120000), FeeSettings{GasMargin: 20}.WithMargin(100000))
+		assert.Equal(t, uint64(100000), FeeSettings{}.WithMargin(100000))
+	})
+}
@@ -0,0 +1,219 @@
+package deposit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"io"
+	"stakeway_test_task/internal/depositcontract"
+	"strings"
+)
+
+const (
+	TxFormatHex  = "hex"
+	TxFormatJSON = "json"
+)
+
+// OfflineTx is one signed deposit transaction in the JSON output of offline signing, and the input of a broadcast.
+type OfflineTx struct {
+	Index  int    `json:"index"`
+	Pubkey string `json:"pubkey"`
+	Nonce  uint64 `json:"nonce"`
+	TxHash string `json:"tx_hash"`
+	RawTx  string `json:"raw_tx"`
+}
+
+// SignOffline signs a transaction for every deposit with consecutive nonces starting at nonce, without
+// talking to a node: gas and fees must be fixed by the fee settings.
+func (s *Submitter) SignOffline(depositDataList []Data, nonce uint64) ([]OfflineTx, error) {
+	caps, err := s.fees.FixedCaps()
+	if err != nil {
+		return nil, err
+	}
+
+	signed := make([]OfflineTx, 0, len(depositDataList))
+	for i, depositData := range depositDataList {
+		signedTx, err := s.depositTx(s.transactOpts(context.Background(), nonce, caps, s.fees.GasLimit, s.sign), depositData)
+		if err != nil {
+			return nil, fmt.Errorf("deposit %d (%s): %w", i, depositData.Pubkey, err)
+		}
+
+		rawTx, err := signedTx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		signed = append(signed, OfflineTx{
+			Index:  i,
+			Pubkey: depositData.Pubkey,
+			Nonce:  nonce,
+			TxHash: signedTx.Hash().Hex(),
+			RawTx:  hexutil.Encode(rawTx),
+		})
+		nonce++
+	}
+	return signed, nil
+}
+
+func WriteTxs(w io.Writer, txs []OfflineTx, format string) error {
+	switch format {
+	case TxFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(txs)
+	case TxFormatHex:
+		for _, tx := range txs {
+			if _, err := fmt.Fprintln(w, tx.RawTx); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown transaction format %q, expected %s or %s", format, TxFormatHex, TxFormatJSON)
+	}
+}
+
+// ReadTxs accepts both formats written by WriteTxs: a JSON array, or one raw transaction per line.
+func ReadTxs(r io.Reader) ([]*types.Transaction, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var rawTxs []string
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var txs []OfflineTx
+		if err := json.Unmarshal(trimmed, &txs); err != nil {
+			return nil, fmt.Errorf("error parsing transactions: %w", err)
+		}
+		for _, tx := range txs {
+			rawTxs = append(rawTxs, tx.RawTx)
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				rawTxs = append(rawTxs, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	txs := make([]*types.Transaction, 0, len(rawTxs))
+	for i, rawTx := range rawTxs {
+		if !strings.HasPrefix(rawTx, "0x") {
+			rawTx = "0x" + rawTx
+		}
+		raw, err := hexutil.Decode(rawTx)
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			return nil, fmt.Errorf("transaction %d: %w", i, err)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// DryRun prints the transaction that would be sent for every deposit, without signing or sending anything.
+func (s *Submitter) DryRun(ctx context.Context, depositDataList []Data, nonce uint64, out io.Writer) error {
+	for i, depositData := range depositDataList {
+		tx, err := s.buildTx(ctx, depositData, nonce, unsigned)
+		if err != nil {
+			return fmt.Errorf("deposit %d (%s): %w", i, depositData.Pubkey, err)
+		}
+		if err := DescribeTx(out, i, len(depositDataList), tx); err != nil {
+			return err
+		}
+		nonce++
+	}
+	return nil
+}
+
+func DescribeTx(out io.Writer, index, total int, tx *types.Transaction) error {
+	contractABI, err := depositcontract.DepositContractMetaData.GetAbi()
+	if err != nil {
+		return err
+	}
+	method, err := contractABI.MethodById(tx.Data())
+	if err != nil {
+		return err
+	}
+	args, err := method.Inputs.Unpack(tx.Data()[4:])
+	if err != nil {
+		return fmt.Errorf("error decoding call data: %w", err)
+	}
+
+	fmt.Fprintf(out, "[%d/%d] %s to %s\n", index+1, total, method.Sig, tx.To().Hex())
+	for i, input := range method.Inputs {
+		var value string
+		switch arg := args[i].(type) {
+		case []byte:
+			value = hexutil.Encode(arg)
+		case [32]byte:
+			value = hexutil.Encode(arg[:])
+		default:
+			value = fmt.Sprint(arg)
+		}
+		fmt.Fprintf(out, "  %-24s %s\n", input.Name, value)
+	}
+	fmt.Fprintf(out, "  %-24s %s ETH\n", "value", FormatGwei(WeiToGwei(tx.Value())))
+	fmt.Fprintf(out, "  %-24s %d\n", "nonce", tx.Nonce())
+	fmt.Fprintf(out, "  %-24s %d\n", "gas", tx.Gas())
+	fmt.Fprintf(out, "  %-24s %s gwei\n", "max fee per gas", FormatWeiAsGwei(tx.GasFeeCap()))
+	fmt.Fprintf(out, "  %-24s %s gwei\n", "max priority fee per gas", FormatWeiAsGwei(tx.GasTipCap()))
+	fmt.Fprintf(out, "  %-24s %s\n", "call data", hexutil.Encode(tx.Data()))
+	return nil
+}
+
+// TxCandidates extracts the validator pubkey from the deposit call of every transaction.
+func TxCandidates(txs []*types.Transaction) ([]Candidate, error) {
+	contractABI, err := depositcontract.DepositContractMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	method := contractABI.Methods["deposit"]
+
+	candidates := make([]Candidate, 0, len(txs))
+	for i, tx := range txs {
+		if len(tx.Data()) < 4 || !bytes.Equal(tx.Data()[:4], method.ID) {
+			return nil, fmt.Errorf("transaction %d does not call deposit", i)
+		}
+		args, err := method.Inputs.Unpack(tx.Data()[4:])
+		if err != nil {
+			return nil, fmt.Errorf("transaction %d: error decoding call data: %w", i, err)
+		}
+		candidates = append(candidates, Candidate{
+			Label:  fmt.Sprintf("transaction %d", i),
+			Pubkey: hexutil.Encode(args[0].([]byte)),
+			TxHash: tx.Hash(),
+		})
+	}
+	return candidates, nil
+}
+
+// CheckTxs makes sure transactions signed elsewhere target the selected network and deposit contract.
+func CheckTxs(txs []*types.Transaction, chainID uint64, contract common.Address) error {
+	if len(txs) == 0 {
+		return errors.New("no transactions to broadcast")
+	}
+	for i, tx := range txs {
+		if tx.ChainId().Uint64() != chainID {
+			return fmt.Errorf("transaction %d is signed for chain ID %d, expected %d", i, tx.ChainId(), chainID)
+		}
+		if tx.To() == nil || *tx.To() != contract {
+			return fmt.Errorf("transaction %d is not sent to the deposit contract %s", i, contract.Hex())
+		}
+	}
+	return nil
+}
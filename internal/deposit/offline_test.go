@@ -0,0 +1,110 @@
+package deposit
+
+import (
+	"bytes"
+	"context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"stakeway_test_task/internal/eth2"
+	"strings"
+	"testing"
+)
+
+const testPrivateKey = "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
+
+var testDepositContract = common.HexToAddress("0x4242424242424242424242424242424242424242")
+
+func newOfflineSubmitter(t *testing.T) *Submitter {
+	privateKey, err := crypto.HexToECDSA(testPrivateKey)
+	require.NoError(t, err)
+
+	network := &eth2.Network{Name: CustomNetwork, ChainID: 17000, DepositContract: testDepositContract}
+	s, err := NewSubmitter(nil, NewKeySigner(privateKey), network, FeeSettings{MaxFee: gwei(30), MaxPriorityFee: gwei(2), GasLimit: 100000})
+	require.NoError(t, err)
+	return s
+}
+
+func TestSignOffline(t *testing.T) {
+	depositDataList, err := Load("../../deposit_data.json")
+	require.NoError(t, err)
+	depositDataList = append(depositDataList, depositDataList[0])
+
+	s := newOfflineSubmitter(t)
+
+	signed, err := s.SignOffline(depositDataList, 5)
+	require.NoError(t, err)
+	require.Len(t, signed, 2)
+	assert.Equal(t, uint64(5), signed[0].Nonce)
+	assert.Equal(t, uint64(6), signed[1].Nonce)
+	assert.Equal(t, depositDataList[0].Pubkey, signed[0].Pubkey)
+
+	for _, format := range []string{TxFormatHex, TxFormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, WriteTxs(&buf, signed, format))
+
+			txs, err := ReadTxs(&buf)
+			require.NoError(t, err)
+			require.Len(t, txs, 2)
+
+			for i, tx := range txs {
+				assert.Equal(t, signed[i].TxHash, tx.Hash().Hex())
+				assert.Equal(t, uint8(types.DynamicFeeTxType), tx.Type())
+				assert.Equal(t, Value(depositDataList[i].Amount), tx.Value())
+				assert.Equal(t, gwei(30), tx.GasFeeCap())
+				assert.Equal(t, gwei(2), tx.GasTipCap())
+				assert.Equal(t, uint64(100000), tx.Gas())
+
+				from, err := types.Sender(types.LatestSignerForChainID(s.chainID), tx)
+				require.NoError(t, err)
+				assert.Equal(t, s.signer.Address(), from)
+			}
+
+			assert.NoError(t, CheckTxs(txs, 17000, testDepositContract))
+			assert.ErrorContains(t, CheckTxs(txs, 1, testDepositContract), "chain ID")
+			assert.ErrorContains(t, CheckTxs(txs, 17000, common.Address{}), "deposit contract")
+		})
+	}
+
+	t.Run("missing fixed fees", func(t *testing.T) {
+		s := newOfflineSubmitter(t)
+		s.fees.GasLimit = 0
+
+		_, err := s.SignOffline(depositDataList, 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("garbage input", func(t *testing.T) {
+		_, err := ReadTxs(strings.NewReader("0x1234\n"))
+		assert.Error(t, err)
+
+		txs, err := ReadTxs(strings.NewReader(""))
+		require.NoError(t, err)
+		assert.Error(t, CheckTxs(txs, 17000, testDepositContract))
+	})
+}
+
+func TestDescribeTx(t *testing.T) {
+	depositDataList, err := Load("../../deposit_data.json")
+	require.NoError(t, err)
+	depositData := depositDataList[0]
+
+	s := newOfflineSubmitter(t)
+	caps, err := s.fees.FixedCaps()
+	require.NoError(t, err)
+	tx, err := s.depositTx(s.transactOpts(context.Background(), 3, caps, s.fees.GasLimit, unsigned), depositData)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, DescribeTx(&out, 0, 1, tx))
+
+	assert.Contains(t, out.String(), "deposit(bytes,bytes,bytes,bytes32)")
+	assert.Contains(t, out.String(), "0x"+depositData.Pubkey)
+	assert.Contains(t, out.String(), "0x"+depositData.WithdrawalCredentials)
+	assert.Contains(t, out.String(), "0x"+depositData.DepositDataRoot)
+	assert.Contains(t, out.String(), "32 ETH")
+	assert.Contains(t, out.String(), "30 gwei")
+}
@@ -0,0 +1,160 @@
+package deposit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"math/big"
+	"time"
+)
+
+var (
+	// ErrReverted is returned for transactions that were mined but rejected by the deposit contract.
+	ErrReverted = errors.New("transaction reverted")
+	// ErrReplaced is returned for transactions that will never be mined, since another transaction of the
+	// sender was mined with their nonce.
+	ErrReplaced = errors.New("transaction replaced")
+)
+
+// Result is the outcome of a single deposit transaction, in the form written by the JSON output.
+type Result struct {
+	Index       int    `json:"index"`
+	Pubkey      string `json:"pubkey"`
+	Status      string `json:"status"`
+	TxHash      string `json:"tx_hash,omitempty"`
+	BlockNumber uint64 `json:"block_number,omitempty"`
+	GasUsed     uint64 `json:"gas_used,omitempty"`
+	// EffectiveGasPrice is the price paid per gas, in wei.
+	EffectiveGasPrice string `json:"effective_gas_price,omitempty"`
+	// Skipped is set for deposits confirmed by an earlier run.
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+func (r *Result) setReceipt(receipt *types.Receipt) {
+	r.BlockNumber = receipt.BlockNumber.Uint64()
+	r.GasUsed = receipt.GasUsed
+	if receipt.EffectiveGasPrice != nil {
+		r.EffectiveGasPrice = receipt.EffectiveGasPrice.String()
+	}
+}
+
+// Confirm waits until tx is mined with s.Confirmations confirmations and fails with ErrReverted, including
+// the revert reason, if the deposit contract rejected it. The receipt is returned in both cases.
+func (s *Submitter) Confirm(ctx context.Context, tx *types.Transaction, result *Result) (*types.Receipt, error) {
+	receipt, err := s.WaitMined(ctx, tx)
+	if err != nil {
+		return nil, fmt.Errorf("error waiting for confirmation: %w", err)
+	}
+	if result != nil {
+		result.setReceipt(receipt)
+	}
+
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return receipt, fmt.Errorf("%w in block %d: %s", ErrReverted, receipt.BlockNumber, s.revertReason(ctx, tx, receipt))
+	}
+	return receipt, nil
+}
+
+// WaitMined polls for the receipt of tx until the block that includes it has s.Confirmations confirmations.
+// The receipt is fetched on every poll, so a transaction that is reorged out is waited for again. Waiting
+// stops with ErrReplaced once the sender's nonce moved past the nonce of tx without tx being mined, which
+// happens to a transaction that was dropped and whose nonce was then used by another one.
+func (s *Submitter) WaitMined(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return nil, err
+	}
+
+	ticker := time.NewTicker(s.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		receipt, err := s.backend.TransactionReceipt(ctx, tx.Hash())
+		if errors.Is(err, ethereum.NotFound) {
+			receipt, err = s.checkReplaced(ctx, tx, from)
+		}
+		switch {
+		case err == nil:
+			head, err := s.backend.BlockNumber(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("error getting latest block: %w", err)
+			}
+			if head+1 >= receipt.BlockNumber.Uint64()+max(s.Confirmations, 1) {
+				return receipt, nil
+			}
+		case !errors.Is(err, ethereum.NotFound):
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkReplaced fails with ErrReplaced if a transaction of from with the nonce of tx was mined and tx was not.
+// The receipt of tx is fetched again after the nonce, in case tx was mined in between. It returns
+// ethereum.NotFound while tx may still be mined.
+func (s *Submitter) checkReplaced(ctx context.Context, tx *types.Transaction, from common.Address) (*types.Receipt, error) {
+	nonce, err := s.backend.NonceAt(ctx, from, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting nonce: %w", err)
+	}
+	if nonce <= tx.Nonce() {
+		return nil, ethereum.NotFound
+	}
+
+	receipt, err := s.backend.TransactionReceipt(ctx, tx.Hash())
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, fmt.Errorf("%w: nonce %d of %s was used by another transaction", ErrReplaced, tx.Nonce(), from.Hex())
+	}
+	return receipt, err
+}
+
+// revertReason replays tx on the state before the block that included it. The deposit contract only reverts
+// on invalid input, so the replay fails with the same reason.
+func (s *Submitter) revertReason(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) string {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return "unknown reason"
+	}
+
+	call := ethereum.CallMsg{
+		From:      from,
+		To:        tx.To(),
+		Gas:       tx.Gas(),
+		GasFeeCap: tx.GasFeeCap(),
+		GasTipCap: tx.GasTipCap(),
+		Value:     tx.Value(),
+		Data:      tx.Data(),
+	}
+	parent := new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1))
+	if _, err := s.backend.CallContract(ctx, call, parent); err != nil {
+		return revertReason(err)
+	}
+	if receipt.GasUsed == tx.Gas() {
+		return "out of gas"
+	}
+	return "unknown reason"
+}
+
+// revertReason decodes the Error(string) revert data returned by a node, falling back to the error message.
+func revertReason(err error) string {
+	var dataErr rpc.DataError
+	if errors.As(err, &dataErr) {
+		if data, ok := dataErr.ErrorData().(string); ok {
+			if reason, err := abi.UnpackRevert(common.FromHex(data)); err == nil {
+				return reason
+			}
+		}
+	}
+	return err.Error()
+}
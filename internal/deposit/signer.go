@@ -0,0 +1,80 @@
+package deposit
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/external"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"math/big"
+)
+
+// Signer signs deposit transactions on behalf of the funding account.
+type Signer interface {
+	Address() common.Address
+	SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+type KeySigner struct {
+	key *ecdsa.PrivateKey
+}
+
+func NewKeySigner(key *ecdsa.PrivateKey) *KeySigner {
+	return &KeySigner{key: key}
+}
+
+func (s *KeySigner) Address() common.Address {
+	return crypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+func (s *KeySigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// ExternalSigner forwards transactions to a Clef-compatible signer over JSON-RPC (account_signTransaction).
+type ExternalSigner struct {
+	signer  *external.ExternalSigner
+	account accounts.Account
+}
+
+func NewExternalSigner(signer *external.ExternalSigner, account accounts.Account) *ExternalSigner {
+	return &ExternalSigner{signer: signer, account: account}
+}
+
+func (s *ExternalSigner) Address() common.Address {
+	return s.account.Address
+}
+
+func (s *ExternalSigner) SignTx(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	signedTx, err := s.signer.SignTx(s.account, tx, chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Do not trust the signer to return what it was asked to sign.
+	from, err := types.Sender(types.LatestSignerForChainID(chainID), signedTx)
+	if err != nil {
+		return nil, fmt.Errorf("external signer returned an invalid transaction: %w", err)
+	}
+	if from != s.account.Address {
+		return nil, fmt.Errorf("external signer signed with %s instead of %s", from.Hex(), s.account.Address.Hex())
+	}
+	if !sameCall(tx, signedTx) {
+		return nil, errors.New("external signer modified the transaction")
+	}
+	return signedTx, nil
+}
+
+func sameCall(a, b *types.Transaction) bool {
+	if a.Nonce() != b.Nonce() || a.Gas() != b.Gas() || a.Value().Cmp(b.Value()) != 0 || !bytes.Equal(a.Data(), b.Data()) {
+		return false
+	}
+	if a.To() == nil || b.To() == nil {
+		return a.To() == b.To()
+	}
+	return *a.To() == *b.To()
+}
@@ -0,0 +1,111 @@
+package deposit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	StatusSent      = "sent"
+	StatusConfirmed = "confirmed"
+	StatusFailed    = "failed"
+	// StatusRefused marks a transaction that will not be mined: the node would not accept it, or another
+	// transaction was mined with its nonce. The deposit was not made, so it is signed again on the next run.
+	StatusRefused = "refused"
+)
+
+// Record tracks a single deposit transaction. The raw signed transaction is persisted before it
+// is broadcast, so a crash at any point can be resumed by rebroadcasting the very same transaction
+// (same nonce) instead of creating a second deposit.
+type Record struct {
+	Pubkey      string `json:"pubkey"`
+	Nonce       uint64 `json:"nonce"`
+	TxHash      string `json:"tx_hash"`
+	RawTx       string `json:"raw_tx"`
+	Status      string `json:"status"`
+	BlockNumber uint64 `json:"block_number,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// Store persists the records of a batch, keyed by deposit data root. Put must not return before the
+// record is durable, since a transaction is only broadcast once its record has been stored.
+type Store interface {
+	Get(depositDataRoot string) (Record, bool)
+	Put(depositDataRoot string, record Record) error
+}
+
+// State is the state file of a batch, keyed by deposit data root.
+type State struct {
+	path string
+
+	mu       sync.Mutex
+	Deposits map[string]*Record `json:"deposits"`
+}
+
+func LoadState(path string) (*State, error) {
+	state := &State{path: path, Deposits: map[string]*Record{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading state file: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("error parsing state file: %w", err)
+	}
+	if state.Deposits == nil {
+		state.Deposits = map[string]*Record{}
+	}
+	return state, nil
+}
+
+// Get returns a copy of the record for the deposit with the given deposit data root.
+func (s *State) Get(depositDataRoot string) (Record, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.Deposits[depositDataRoot]
+	if !ok {
+		return Record{}, false
+	}
+	return *record, true
+}
+
+func (s *State) Put(depositDataRoot string, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Deposits[depositDataRoot] = &record
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated state file behind.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
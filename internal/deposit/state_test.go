@@ -0,0 +1,38 @@
+package deposit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	state, err := LoadState(path)
+	require.NoError(t, err)
+
+	_, ok := state.Get("root")
+	assert.False(t, ok)
+
+	record := Record{Pubkey: "aa", Nonce: 7, TxHash: "0x01", RawTx: "0x02", Status: StatusSent}
+	require.NoError(t, state.Put("root", record))
+
+	reloaded, err := LoadState(path)
+	require.NoError(t, err)
+
+	stored, ok := reloaded.Get("root")
+	assert.True(t, ok)
+	assert.Equal(t, record, stored)
+
+	record.Status = StatusConfirmed
+	record.BlockNumber = 42
+	require.NoError(t, reloaded.Put("root", record))
+
+	reloaded, err = LoadState(path)
+	require.NoError(t, err)
+	stored, _ = reloaded.Get("root")
+	assert.Equal(t, StatusConfirmed, stored.Status)
+	assert.Equal(t, uint64(42), stored.BlockNumber)
+}
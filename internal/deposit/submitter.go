@@ -0,0 +1,385 @@
+package deposit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"io"
+	"math/big"
+	"os"
+	"stakeway_test_task/internal/depositcontract"
+	"stakeway_test_task/internal/eth2"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Backend is the part of the node API used to submit deposits. It is implemented by *ethclient.Client
+// and by the client of go-ethereum's simulated backend.
+type Backend interface {
+	bind.ContractBackend
+	TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+}
+
+// Submitter builds, signs and sends deposit transactions to the deposit contract of a network.
+type Submitter struct {
+	backend  Backend
+	signer   Signer
+	chainID  *big.Int
+	contract *depositcontract.DepositContractTransactor
+	fees     FeeSettings
+
+	// Out receives a line per deposit as it progresses.
+	Out io.Writer
+	// PollInterval is how often receipts are polled while waiting for a transaction to be mined.
+	PollInterval time.Duration
+	// Confirmations is the number of blocks, including the one with the transaction, to wait for
+	// before a deposit is reported as confirmed.
+	Confirmations uint64
+}
+
+// NewSubmitter returns a submitter for the deposit contract of network. backend may be nil for
+// offline signing, where every transaction field comes from fees.
+func NewSubmitter(backend Backend, signer Signer, network *eth2.Network, fees FeeSettings) (*Submitter, error) {
+	var transactor bind.ContractTransactor
+	if backend != nil {
+		transactor = backend
+	}
+	contract, err := depositcontract.NewDepositContractTransactor(network.DepositContract, transactor)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Submitter{
+		backend:       backend,
+		signer:        signer,
+		chainID:       new(big.Int).SetUint64(network.ChainID),
+		contract:      contract,
+		fees:          fees,
+		Out:           os.Stdout,
+		PollInterval:  time.Second,
+		Confirmations: 1,
+	}, nil
+}
+
+type job struct {
+	index       int
+	depositData Data
+	record      Record
+	resumed     bool
+	// tx is the signed transaction of a new deposit, set once it is broadcast.
+	tx *types.Transaction
+}
+
+// SubmitAll submits every deposit that has not been confirmed yet. New transactions are sent one at a time
+// in nonce order, then awaited concurrently with the transactions of resumed deposits. The returned results
+// are in the order of depositDataList, including the failed deposits listed in the error.
+func (s *Submitter) SubmitAll(ctx context.Context, depositDataList []Data, state Store, concurrency int) ([]Result, error) {
+	total := len(depositDataList)
+	results := make([]Result, total)
+
+	var jobs []job
+	var pending []int
+	for i, depositData := range depositDataList {
+		results[i] = Result{Index: i, Pubkey: depositData.Pubkey}
+		record, ok := state.Get(depositData.DepositDataRoot)
+		switch {
+		case !ok:
+			pending = append(pending, i)
+		case record.Status == StatusConfirmed:
+			fmt.Fprintf(s.Out, "[%d/%d] %s: already confirmed in transaction %s, skipping\n", i+1, total, depositData.Pubkey, record.TxHash)
+			results[i].Status = StatusConfirmed
+			results[i].TxHash = record.TxHash
+			results[i].BlockNumber = record.BlockNumber
+			results[i].Skipped = true
+		case record.Status == StatusFailed:
+			return nil, fmt.Errorf("deposit %d (%s) previously failed (%s), inspect transaction %s and the state file before retrying",
+				i, depositData.Pubkey, record.Error, record.TxHash)
+		case record.Status == StatusRefused:
+			// The refused transaction is only awaited if it reached the network after all, otherwise the
+			// deposit is signed again with the next free nonce.
+			known, err := s.knownTx(ctx, record.TxHash)
+			if err != nil {
+				return nil, fmt.Errorf("deposit %d (%s): error looking up refused transaction %s: %w", i, depositData.Pubkey, record.TxHash, err)
+			}
+			if !known {
+				pending = append(pending, i)
+				continue
+			}
+			record.Status = StatusSent
+			jobs = append(jobs, job{index: i, depositData: depositData, record: record, resumed: true})
+		default:
+			jobs = append(jobs, job{index: i, depositData: depositData, record: record, resumed: true})
+		}
+	}
+
+	var (
+		mu       sync.Mutex
+		failures []error
+	)
+	fail := func(index int, err error) {
+		result := &results[index]
+		// A deposit whose transaction is still pending stays "sent" and is resumed by the next run, one whose
+		// transaction was refused is signed again.
+		result.Status = StatusFailed
+		if record, ok := state.Get(depositDataList[index].DepositDataRoot); ok && (record.Status == StatusSent || record.Status == StatusRefused) {
+			result.Status = record.Status
+		}
+		result.Error = err.Error()
+		mu.Lock()
+		fmt.Fprintf(s.Out, "[%d/%d] %s: FAILED: %v\n", index+1, total, depositDataList[index].Pubkey, err)
+		failures = append(failures, fmt.Errorf("deposit %d (%s): %w", index, depositDataList[index].Pubkey, err))
+		mu.Unlock()
+	}
+
+	if len(pending) > 0 {
+		nonce, err := s.backend.PendingNonceAt(ctx, s.signer.Address())
+		if err != nil {
+			return nil, fmt.Errorf("error getting nonce: %w", err)
+		}
+
+		// A nonce is only used once its transaction is sent. After a deposit that cannot be sent, the
+		// later ones are left for the next run: behind the unused nonce they would never be mined.
+		for n, i := range pending {
+			j := job{
+				index:       i,
+				depositData: depositDataList[i],
+				record:      Record{Pubkey: depositDataList[i].Pubkey, Nonce: nonce},
+			}
+			if err := s.send(ctx, &j, state, total, &results[i]); err != nil {
+				fail(i, err)
+				for _, later := range pending[n+1:] {
+					fail(later, fmt.Errorf("not sent since deposit %d failed", i))
+				}
+				break
+			}
+			jobs = append(jobs, j)
+			nonce++
+		}
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+	queue := make(chan job)
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				if err := s.await(ctx, j, state, total, &results[j.index]); err != nil {
+					fail(j.index, err)
+				}
+			}
+		}()
+	}
+
+	for _, j := range jobs {
+		queue <- j
+	}
+	close(queue)
+	wg.Wait()
+
+	return results, errors.Join(failures...)
+}
+
+// send builds the transaction of a new deposit, records it and broadcasts it.
+func (s *Submitter) send(ctx context.Context, j *job, state Store, total int, result *Result) error {
+	signedTx, err := s.buildTx(ctx, j.depositData, j.record.Nonce, s.sign)
+	if err != nil {
+		return err
+	}
+
+	rawTx, err := signedTx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	j.record.TxHash = signedTx.Hash().Hex()
+	j.record.RawTx = hexutil.Encode(rawTx)
+	j.record.Status = StatusSent
+	result.TxHash = j.record.TxHash
+	if err := state.Put(j.depositData.DepositDataRoot, j.record); err != nil {
+		return fmt.Errorf("error saving state: %w", err)
+	}
+
+	if err := s.broadcast(ctx, *j, signedTx, state, total); err != nil {
+		return err
+	}
+	j.tx = signedTx
+	return nil
+}
+
+// broadcast sends the recorded transaction of a deposit, which fails if the node refuses it.
+func (s *Submitter) broadcast(ctx context.Context, j job, signedTx *types.Transaction, state Store, total int) error {
+	record := j.record
+	if err := s.Broadcast(ctx, signedTx); err != nil {
+		record.Status = StatusRefused
+		record.Error = err.Error()
+		if stateErr := state.Put(j.depositData.DepositDataRoot, record); stateErr != nil {
+			return errors.Join(err, stateErr)
+		}
+		return err
+	}
+	fmt.Fprintf(s.Out, "[%d/%d] %s: depositing %s ETH in transaction %s (nonce %d, gas %d, max fee %s gwei, priority fee %s gwei)\n",
+		j.index+1, total, j.depositData.Pubkey, FormatGwei(j.depositData.Amount), record.TxHash, record.Nonce,
+		signedTx.Gas(), FormatWeiAsGwei(signedTx.GasFeeCap()), FormatWeiAsGwei(signedTx.GasTipCap()))
+	return nil
+}
+
+// await waits for the transaction of a deposit to be confirmed. The transaction of a resumed deposit is
+// broadcast again first, in case it never reached the network.
+func (s *Submitter) await(ctx context.Context, j job, state Store, total int, result *Result) error {
+	root := j.depositData.DepositDataRoot
+	record := j.record
+	result.TxHash = record.TxHash
+
+	signedTx := j.tx
+	if j.resumed {
+		rawTx, err := hexutil.Decode(record.RawTx)
+		if err != nil {
+			return fmt.Errorf("error decoding stored transaction: %w", err)
+		}
+		signedTx = new(types.Transaction)
+		if err := signedTx.UnmarshalBinary(rawTx); err != nil {
+			return fmt.Errorf("error decoding stored transaction: %w", err)
+		}
+		fmt.Fprintf(s.Out, "[%d/%d] %s: resuming transaction %s (nonce %d)\n", j.index+1, total, j.depositData.Pubkey, record.TxHash, record.Nonce)
+		if err := s.broadcast(ctx, j, signedTx, state, total); err != nil {
+			return err
+		}
+	}
+
+	receipt, err := s.Confirm(ctx, signedTx, result)
+	if errors.Is(err, ErrReverted) {
+		record.Status = StatusFailed
+		record.BlockNumber = receipt.BlockNumber.Uint64()
+		record.Error = err.Error()
+		if stateErr := state.Put(root, record); stateErr != nil {
+			return errors.Join(err, stateErr)
+		}
+		return err
+	}
+	if errors.Is(err, ErrReplaced) {
+		record.Status = StatusRefused
+		record.Error = err.Error()
+		if stateErr := state.Put(root, record); stateErr != nil {
+			return errors.Join(err, stateErr)
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	record.Status = StatusConfirmed
+	record.BlockNumber = receipt.BlockNumber.Uint64()
+	if err := state.Put(root, record); err != nil {
+		return fmt.Errorf("error saving state: %w", err)
+	}
+	result.Status = StatusConfirmed
+
+	fmt.Fprintf(s.Out, "[%d/%d] %s: confirmed in block %d, gas used %d\n",
+		j.index+1, total, j.depositData.Pubkey, receipt.BlockNumber, receipt.GasUsed)
+	return nil
+}
+
+// Broadcast sends tx unless the node already knows it, which is the case when resuming a deposit
+// whose transaction made it to the network before the previous run stopped.
+func (s *Submitter) Broadcast(ctx context.Context, tx *types.Transaction) error {
+	if _, _, err := s.backend.TransactionByHash(ctx, tx.Hash()); err == nil {
+		return nil
+	}
+
+	err := s.backend.SendTransaction(ctx, tx)
+	if err != nil && strings.Contains(err.Error(), "already known") {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error sending transaction: %w", err)
+	}
+	return nil
+}
+
+// knownTx reports whether the node knows the transaction with the given hash, pending or mined.
+func (s *Submitter) knownTx(ctx context.Context, hash string) (bool, error) {
+	_, _, err := s.backend.TransactionByHash(ctx, common.HexToHash(hash))
+	if errors.Is(err, ethereum.NotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// buildTx prepares the deposit transaction with estimated gas and current fees. signer is s.sign, or unsigned
+// to only inspect the transaction.
+func (s *Submitter) buildTx(ctx context.Context, depositData Data, nonce uint64, signer bind.SignerFn) (*types.Transaction, error) {
+	caps, err := s.suggestFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := s.transactOpts(ctx, nonce, caps, s.fees.GasLimit, unsigned)
+	if opts.GasLimit == 0 {
+		// Without a gas limit the binding estimates it, the margin is added on top of that estimate.
+		estimated, err := s.depositTx(opts, depositData)
+		if err != nil {
+			return nil, fmt.Errorf("error estimating gas: %w", err)
+		}
+		opts.GasLimit = s.fees.WithMargin(estimated.Gas())
+	}
+
+	opts.Signer = signer
+	return s.depositTx(opts, depositData)
+}
+
+func (s *Submitter) transactOpts(ctx context.Context, nonce uint64, caps FeeCaps, gasLimit uint64, signer bind.SignerFn) *bind.TransactOpts {
+	return &bind.TransactOpts{
+		From:      s.signer.Address(),
+		Nonce:     new(big.Int).SetUint64(nonce),
+		Signer:    signer,
+		GasFeeCap: caps.GasFeeCap,
+		GasTipCap: caps.GasTipCap,
+		GasLimit:  gasLimit,
+		Context:   ctx,
+		// Transactions are recorded in the state file before they are broadcast.
+		NoSend: true,
+	}
+}
+
+// depositTx calls deposit through the contract binding with the value and arguments of depositData.
+func (s *Submitter) depositTx(opts *bind.TransactOpts, depositData Data) (*types.Transaction, error) {
+	data, err := decode(depositData)
+	if err != nil {
+		return nil, err
+	}
+
+	var depositDataRoot [32]byte
+	if err := decodeHex(depositData.DepositDataRoot, depositDataRoot[:], "deposit data root"); err != nil {
+		return nil, err
+	}
+
+	opts.Value = Value(depositData.Amount)
+	return s.contract.Deposit(opts, data.Pubkey[:], data.WithdrawalCredentials[:], data.Signature[:], depositDataRoot)
+}
+
+func (s *Submitter) sign(_ common.Address, tx *types.Transaction) (*types.Transaction, error) {
+	signedTx, err := s.signer.SignTx(tx, s.chainID)
+	if err != nil {
+		return nil, fmt.Errorf("error signing transaction: %w", err)
+	}
+	return signedTx, nil
+}
+
+// unsigned is a bind.SignerFn that leaves the transaction unsigned, for gas estimation and dry runs.
+func unsigned(_ common.Address, tx *types.Transaction) (*types.Transaction, error) {
+	return tx, nil
+}
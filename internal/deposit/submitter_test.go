@@ -0,0 +1,449 @@
+package deposit
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"math/big"
+	"path/filepath"
+	"stakeway_test_task/internal/bls"
+	"stakeway_test_task/internal/depositcontract"
+	"stakeway_test_task/internal/eth2"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// simulatedChainID is the chain ID of go-ethereum's simulated backend.
+const simulatedChainID = 1337
+
+// simulatedChain runs the official deposit contract on go-ethereum's simulated backend. A block is mined
+// every few milliseconds, so submissions wait for their receipts the same way they do against a real node.
+type simulatedChain struct {
+	client   simulated.Client
+	network  *eth2.Network
+	signer   *KeySigner
+	contract *depositcontract.DepositContract
+}
+
+func newSimulatedChain(t *testing.T) *simulatedChain {
+	key, err := crypto.HexToECDSA(testPrivateKey)
+	require.NoError(t, err)
+	signer := NewKeySigner(key)
+
+	funds := new(big.Int).Mul(big.NewInt(1000), big.NewInt(params.Ether))
+	backend := simulated.NewBackend(types.GenesisAlloc{signer.Address(): {Balance: funds}})
+
+	auth, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(simulatedChainID))
+	require.NoError(t, err)
+	address, _, contract, err := depositcontract.DeployDepositContract(auth, backend.Client())
+	require.NoError(t, err)
+	backend.Commit()
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				backend.Commit()
+			}
+		}
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		<-done
+		backend.Close()
+	})
+
+	holesky, err := eth2.NetworkByName("holesky")
+	require.NoError(t, err)
+
+	return &simulatedChain{
+		client: backend.Client(),
+		network: &eth2.Network{
+			Name:               CustomNetwork,
+			ChainID:            simulatedChainID,
+			DepositContract:    address,
+			GenesisForkVersion: holesky.GenesisForkVersion,
+		},
+		signer:   signer,
+		contract: contract,
+	}
+}
+
+func (c *simulatedChain) submitter(t *testing.T, fees FeeSettings) *Submitter {
+	s, err := NewSubmitter(c.client, c.signer, c.network, fees)
+	require.NoError(t, err)
+	s.Out = io.Discard
+	s.PollInterval = 10 * time.Millisecond
+	return s
+}
+
+func (c *simulatedChain) depositEvents(t *testing.T) []*depositcontract.DepositContractDepositEvent {
+	events, err := c.contract.FilterDepositEvent(&bind.FilterOpts{Start: 0})
+	require.NoError(t, err)
+	defer events.Close()
+
+	var deposits []*depositcontract.DepositContractDepositEvent
+	for events.Next() {
+		deposits = append(deposits, events.Event)
+	}
+	require.NoError(t, events.Error())
+	return deposits
+}
+
+// assertDeposits checks that the contract logged exactly the given deposits, in order, and that its
+// deposit count and root match a deposit tree built from their deposit data roots.
+func (c *simulatedChain) assertDeposits(t *testing.T, depositDataList []Data) {
+	events := c.depositEvents(t)
+	require.Len(t, events, len(depositDataList))
+
+	roots := make([]eth2.Root, len(depositDataList))
+	for i, depositData := range depositDataList {
+		event := events[i]
+		assert.Equal(t, depositData.Pubkey, hex.EncodeToString(event.Pubkey))
+		assert.Equal(t, depositData.WithdrawalCredentials, hex.EncodeToString(event.WithdrawalCredentials))
+		assert.Equal(t, depositData.Signature, hex.EncodeToString(event.Signature))
+
+		amount, err := depositcontract.DecodeUint64(event.Amount)
+		require.NoError(t, err)
+		assert.Equal(t, depositData.Amount, amount)
+
+		index, err := depositcontract.DecodeUint64(event.Index)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(i), index)
+
+		require.NoError(t, decodeHex(depositData.DepositDataRoot, roots[i][:], "deposit data root"))
+	}
+
+	count, err := c.contract.GetDepositCount(nil)
+	require.NoError(t, err)
+	decodedCount, err := depositcontract.DecodeUint64(count)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(len(depositDataList)), decodedCount)
+
+	root, err := c.contract.GetDepositRoot(nil)
+	require.NoError(t, err)
+	assert.Equal(t, eth2.DepositRoot(roots), eth2.Root(root))
+}
+
+// newDepositData signs a deposit of amount gwei for a fresh validator key.
+func newDepositData(t *testing.T, network *eth2.Network, amount uint64) Data {
+	key, err := bls.GenerateKey()
+	require.NoError(t, err)
+
+	var withdrawalCredentials [32]byte
+	withdrawalCredentials[0] = eth2.ExecutionWithdrawalPrefix
+	data := eth2.SignDeposit(key, withdrawalCredentials, amount, network)
+	messageRoot := data.Message().HashTreeRoot()
+	dataRoot := data.HashTreeRoot()
+
+	return Data{
+		Pubkey:                hex.EncodeToString(data.Pubkey[:]),
+		WithdrawalCredentials: hex.EncodeToString(data.WithdrawalCredentials[:]),
+		Amount:                data.Amount,
+		Signature:             hex.EncodeToString(data.Signature[:]),
+		DepositMessageRoot:    hex.EncodeToString(messageRoot[:]),
+		DepositDataRoot:       hex.EncodeToString(dataRoot[:]),
+		ForkVersion:           hex.EncodeToString(network.GenesisForkVersion[:]),
+		NetworkName:           network.Name,
+	}
+}
+
+func TestSubmitAllSimulated(t *testing.T) {
+	chain := newSimulatedChain(t)
+	ctx := context.Background()
+
+	depositDataList, err := Load("../../deposit_data.json")
+	require.NoError(t, err)
+	depositDataList = append(depositDataList,
+		newDepositData(t, chain.network, eth2.MaxEffectiveBalance),
+		newDepositData(t, chain.network, eth2.MinDepositAmount),
+	)
+	require.NoError(t, VerifyAll(depositDataList, chain.network))
+
+	state, err := LoadState(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, err)
+
+	s := chain.submitter(t, FeeSettings{GasMargin: 20})
+	results, err := s.SubmitAll(ctx, depositDataList, state, 2)
+	require.NoError(t, err)
+	chain.assertDeposits(t, depositDataList)
+
+	require.Len(t, results, len(depositDataList))
+	for i, depositData := range depositDataList {
+		record, ok := state.Get(depositData.DepositDataRoot)
+		require.True(t, ok)
+		assert.Equal(t, StatusConfirmed, record.Status)
+		assert.NotZero(t, record.BlockNumber)
+
+		result := results[i]
+		assert.Equal(t, i, result.Index)
+		assert.Equal(t, depositData.Pubkey, result.Pubkey)
+		assert.Equal(t, StatusConfirmed, result.Status)
+		assert.Equal(t, record.TxHash, result.TxHash)
+		assert.Equal(t, record.BlockNumber, result.BlockNumber)
+		assert.NotZero(t, result.GasUsed)
+		assert.NotEmpty(t, result.EffectiveGasPrice)
+		assert.Empty(t, result.Error)
+	}
+
+	t.Run("rerun skips confirmed deposits", func(t *testing.T) {
+		var out bytes.Buffer
+		s.Out = &out
+		results, err := s.SubmitAll(ctx, depositDataList, state, 1)
+		require.NoError(t, err)
+		assert.Contains(t, out.String(), "already confirmed")
+		for _, result := range results {
+			assert.True(t, result.Skipped)
+			assert.Equal(t, StatusConfirmed, result.Status)
+		}
+		chain.assertDeposits(t, depositDataList)
+	})
+
+	t.Run("duplicates are found in the logs", func(t *testing.T) {
+		checker := &DuplicateChecker{Logs: chain.client, Contract: chain.network.DepositContract}
+		count, err := ReportDuplicates(ctx, checker, NewCandidates(depositDataList, nil), io.Discard)
+		require.NoError(t, err)
+		assert.Equal(t, len(depositDataList), count)
+	})
+
+	t.Run("contract rejects a wrong deposit data root", func(t *testing.T) {
+		depositData := newDepositData(t, chain.network, eth2.MaxEffectiveBalance)
+		depositData.DepositDataRoot = newDepositData(t, chain.network, eth2.MaxEffectiveBalance).DepositDataRoot
+
+		results, err := s.SubmitAll(ctx, []Data{depositData}, state, 1)
+		assert.ErrorContains(t, err, "error estimating gas")
+		assert.Equal(t, StatusFailed, results[0].Status)
+		_, ok := state.Get(depositData.DepositDataRoot)
+		assert.False(t, ok)
+		chain.assertDeposits(t, depositDataList)
+	})
+
+	t.Run("reverted deposit fails", func(t *testing.T) {
+		// With a fixed gas limit nothing is estimated, so the invalid deposit is mined and reverts.
+		depositData := newDepositData(t, chain.network, eth2.MaxEffectiveBalance)
+		depositData.Amount = eth2.MinDepositAmount / 2
+
+		s := chain.submitter(t, FeeSettings{GasLimit: 200000})
+		results, err := s.SubmitAll(ctx, []Data{depositData}, state, 1)
+		require.ErrorIs(t, err, ErrReverted)
+		assert.ErrorContains(t, err, "deposit value too low")
+
+		record, ok := state.Get(depositData.DepositDataRoot)
+		require.True(t, ok)
+		assert.Equal(t, StatusFailed, record.Status)
+		assert.Contains(t, record.Error, "deposit value too low")
+
+		assert.Equal(t, StatusFailed, results[0].Status)
+		assert.Equal(t, record.TxHash, results[0].TxHash)
+		assert.Equal(t, record.BlockNumber, results[0].BlockNumber)
+		assert.NotZero(t, results[0].GasUsed)
+		chain.assertDeposits(t, depositDataList)
+
+		_, err = s.SubmitAll(ctx, []Data{depositData}, state, 1)
+		assert.ErrorContains(t, err, "previously failed")
+	})
+
+	t.Run("deposits after one that cannot be sent wait for the next run", func(t *testing.T) {
+		first := newDepositData(t, chain.network, eth2.MaxEffectiveBalance)
+		rejected := newDepositData(t, chain.network, eth2.MaxEffectiveBalance)
+		rejected.DepositDataRoot = newDepositData(t, chain.network, eth2.MaxEffectiveBalance).DepositDataRoot
+		last := newDepositData(t, chain.network, eth2.MaxEffectiveBalance)
+
+		results, err := s.SubmitAll(ctx, []Data{first, rejected, last}, state, 2)
+		assert.ErrorContains(t, err, "error estimating gas")
+		assert.ErrorContains(t, err, "not sent since deposit 1 failed")
+		assert.Equal(t, StatusConfirmed, results[0].Status)
+		assert.Equal(t, StatusFailed, results[1].Status)
+		assert.Equal(t, StatusFailed, results[2].Status)
+		_, ok := state.Get(last.DepositDataRoot)
+		assert.False(t, ok)
+
+		// No nonce was skipped, so the remaining deposit goes through on its own.
+		results, err = s.SubmitAll(ctx, []Data{last}, state, 1)
+		require.NoError(t, err)
+		assert.Equal(t, StatusConfirmed, results[0].Status)
+		chain.assertDeposits(t, append(depositDataList, first, last))
+	})
+}
+
+// refusingBackend refuses to send transactions while refuse is set, the way a node does for a sender
+// without enough funds.
+type refusingBackend struct {
+	Backend
+	refuse atomic.Bool
+}
+
+func (b *refusingBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if b.refuse.Load() {
+		return errors.New("insufficient funds for gas * price + value")
+	}
+	return b.Backend.SendTransaction(ctx, tx)
+}
+
+func TestSubmitAllRefusedSimulated(t *testing.T) {
+	chain := newSimulatedChain(t)
+	ctx := context.Background()
+
+	depositDataList := []Data{
+		newDepositData(t, chain.network, eth2.MaxEffectiveBalance),
+		newDepositData(t, chain.network, eth2.MaxEffectiveBalance),
+	}
+	state, err := LoadState(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, err)
+
+	backend := &refusingBackend{Backend: chain.client}
+	backend.refuse.Store(true)
+	s, err := NewSubmitter(backend, chain.signer, chain.network, FeeSettings{})
+	require.NoError(t, err)
+	s.Out = io.Discard
+	s.PollInterval = 10 * time.Millisecond
+
+	results, err := s.SubmitAll(ctx, depositDataList, state, 1)
+	assert.ErrorContains(t, err, "insufficient funds")
+	assert.ErrorContains(t, err, "not sent since deposit 0 failed")
+	assert.Equal(t, StatusRefused, results[0].Status)
+	assert.Equal(t, StatusFailed, results[1].Status)
+	refused, ok := state.Get(depositDataList[0].DepositDataRoot)
+	require.True(t, ok)
+	assert.Equal(t, StatusRefused, refused.Status)
+	assert.Contains(t, refused.Error, "insufficient funds")
+
+	// The rerun signs the refused deposit again, with the nonce it never used.
+	backend.refuse.Store(false)
+	results, err = s.SubmitAll(ctx, depositDataList, state, 1)
+	require.NoError(t, err)
+	for i, result := range results {
+		assert.Equal(t, StatusConfirmed, result.Status)
+		assert.False(t, result.Skipped)
+
+		record, ok := state.Get(depositDataList[i].DepositDataRoot)
+		require.True(t, ok)
+		assert.Equal(t, StatusConfirmed, record.Status)
+		assert.Equal(t, refused.Nonce+uint64(i), record.Nonce)
+	}
+	chain.assertDeposits(t, depositDataList)
+}
+
+func TestWaitMinedReplacedSimulated(t *testing.T) {
+	chain := newSimulatedChain(t)
+	ctx := context.Background()
+	s := chain.submitter(t, FeeSettings{})
+
+	nonce, err := chain.client.PendingNonceAt(ctx, chain.signer.Address())
+	require.NoError(t, err)
+	depositTx, err := s.buildTx(ctx, newDepositData(t, chain.network, eth2.MaxEffectiveBalance), nonce, s.sign)
+	require.NoError(t, err)
+
+	// The deposit never reaches the node, and a transfer is mined with its nonce instead.
+	to := common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+	transfer, err := chain.signer.SignTx(types.NewTx(&types.DynamicFeeTx{
+		ChainID:   big.NewInt(simulatedChainID),
+		Nonce:     nonce,
+		GasTipCap: depositTx.GasTipCap(),
+		GasFeeCap: depositTx.GasFeeCap(),
+		Gas:       21000,
+		To:        &to,
+		Value:     big.NewInt(1),
+	}), big.NewInt(simulatedChainID))
+	require.NoError(t, err)
+	require.NoError(t, chain.client.SendTransaction(ctx, transfer))
+	_, err = s.WaitMined(ctx, transfer)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	_, err = s.WaitMined(ctx, depositTx)
+	assert.ErrorIs(t, err, ErrReplaced)
+}
+
+func TestConfirmationsSimulated(t *testing.T) {
+	chain := newSimulatedChain(t)
+	ctx := context.Background()
+
+	depositDataList := []Data{newDepositData(t, chain.network, eth2.MaxEffectiveBalance)}
+	state, err := LoadState(filepath.Join(t.TempDir(), "state.json"))
+	require.NoError(t, err)
+
+	s := chain.submitter(t, FeeSettings{})
+	s.Confirmations = 5
+	results, err := s.SubmitAll(ctx, depositDataList, state, 1)
+	require.NoError(t, err)
+
+	head, err := chain.client.BlockNumber(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, head, results[0].BlockNumber+4)
+}
+
+func TestDryRunSimulated(t *testing.T) {
+	chain := newSimulatedChain(t)
+	ctx := context.Background()
+
+	depositData := newDepositData(t, chain.network, eth2.MaxEffectiveBalance)
+	nonce, err := chain.client.PendingNonceAt(ctx, chain.signer.Address())
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	require.NoError(t, chain.submitter(t, FeeSettings{}).DryRun(ctx, []Data{depositData}, nonce, &out))
+	assert.Contains(t, out.String(), "0x"+depositData.Pubkey)
+	assert.Contains(t, out.String(), "32 ETH")
+
+	after, err := chain.client.PendingNonceAt(ctx, chain.signer.Address())
+	require.NoError(t, err)
+	assert.Equal(t, nonce, after)
+	assert.Empty(t, chain.depositEvents(t))
+}
+
+func TestSignOfflineSimulated(t *testing.T) {
+	chain := newSimulatedChain(t)
+	ctx := context.Background()
+
+	depositDataList := []Data{
+		newDepositData(t, chain.network, eth2.MaxEffectiveBalance),
+		newDepositData(t, chain.network, eth2.MaxEffectiveBalance),
+	}
+	nonce, err := chain.client.PendingNonceAt(ctx, chain.signer.Address())
+	require.NoError(t, err)
+
+	offline, err := NewSubmitter(nil, chain.signer, chain.network, FeeSettings{MaxFee: gwei(10), MaxPriorityFee: gwei(1), GasLimit: 200000})
+	require.NoError(t, err)
+	signed, err := offline.SignOffline(depositDataList, nonce)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteTxs(&buf, signed, TxFormatHex))
+	txs, err := ReadTxs(&buf)
+	require.NoError(t, err)
+	require.NoError(t, CheckTxs(txs, chain.network.ChainID, chain.network.DepositContract))
+
+	s := chain.submitter(t, FeeSettings{})
+	for _, tx := range txs {
+		require.NoError(t, s.Broadcast(ctx, tx))
+		// Broadcasting a transaction the node already knows is a no-op, as when resuming.
+		require.NoError(t, s.Broadcast(ctx, tx))
+	}
+	for _, tx := range txs {
+		receipt, err := s.WaitMined(ctx, tx)
+		require.NoError(t, err)
+		assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	}
+
+	chain.assertDeposits(t, depositDataList)
+}
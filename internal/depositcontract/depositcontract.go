@@ -0,0 +1,21 @@
+// Package depositcontract contains Go bindings for the official Ethereum deposit contract.
+//
+// deposit_contract.sol, deposit_contract.abi and deposit_contract.bin are the contract source (CC0), ABI and
+// bytecode from the consensus specs; deposit_contract.go is generated from them by abigen.
+package depositcontract
+
+//go:generate abigen --abi deposit_contract.abi --bin deposit_contract.bin --pkg depositcontract --type DepositContract --out deposit_contract.go
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// DecodeUint64 decodes the 8-byte little-endian values the contract uses for the deposit count and for the
+// amount and index fields of DepositEvent.
+func DecodeUint64(b []byte) (uint64, error) {
+	if len(b) != 8 {
+		return 0, fmt.Errorf("expected 8 bytes, got %d", len(b))
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
@@ -0,0 +1,41 @@
+package depositcontract
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestDecodeUint64(t *testing.T) {
+	value, err := DecodeUint64([]byte{0x00, 0x40, 0x59, 0x73, 0x07, 0x00, 0x00, 0x00})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(32000000000), value)
+
+	_, err = DecodeUint64([]byte{0x01})
+	assert.Error(t, err)
+}
+
+func TestParseDepositEvent(t *testing.T) {
+	parsed, err := DepositContractMetaData.GetAbi()
+	require.NoError(t, err)
+	event := parsed.Events["DepositEvent"]
+
+	pubkey := common.FromHex("0x80df3c8cb942676d7ade40b42a7306ea6275a9da4c3b952efb1a6dd9a9b4f2d21cca7463b7ca3cfe0dd09b6c335d5c26")
+	amount := []byte{0x00, 0x40, 0x59, 0x73, 0x07, 0x00, 0x00, 0x00}
+	index := []byte{0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	data, err := event.Inputs.Pack(pubkey, make([]byte, 32), amount, make([]byte, 96), index)
+	require.NoError(t, err)
+
+	filterer, err := NewDepositContractFilterer(common.Address{}, nil)
+	require.NoError(t, err)
+
+	deposit, err := filterer.ParseDepositEvent(types.Log{Topics: []common.Hash{event.ID}, Data: data})
+	require.NoError(t, err)
+	assert.Equal(t, pubkey, deposit.Pubkey)
+
+	value, err := DecodeUint64(deposit.Index)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), value)
+}
@@ -0,0 +1,209 @@
+package eth2
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+const (
+	SlotsPerEpoch = 32
+
+	MaxValidatorsPerCommittee = 2048
+	MaxCommitteesPerSlot      = 64
+	// SyncSubcommitteeSize is SYNC_COMMITTEE_SIZE / SYNC_COMMITTEE_SUBNET_COUNT.
+	SyncSubcommitteeSize = 128
+)
+
+func EpochAtSlot(slot uint64) uint64 {
+	return slot / SlotsPerEpoch
+}
+
+type Fork struct {
+	PreviousVersion [4]byte
+	CurrentVersion  [4]byte
+	Epoch           uint64
+}
+
+// Version returns the fork version that messages for epoch are signed with.
+func (f *Fork) Version(epoch uint64) [4]byte {
+	if epoch < f.Epoch {
+		return f.PreviousVersion
+	}
+	return f.CurrentVersion
+}
+
+type BeaconBlockHeader struct {
+	Slot          uint64
+	ProposerIndex uint64
+	ParentRoot    Root
+	StateRoot     Root
+	BodyRoot      Root
+}
+
+func (h *BeaconBlockHeader) HashTreeRoot() Root {
+	return containerRoot(
+		uint64Root(h.Slot),
+		uint64Root(h.ProposerIndex),
+		h.ParentRoot,
+		h.StateRoot,
+		h.BodyRoot,
+	)
+}
+
+type Checkpoint struct {
+	Epoch uint64
+	Root  Root
+}
+
+func (c *Checkpoint) HashTreeRoot() Root {
+	return containerRoot(uint64Root(c.Epoch), c.Root)
+}
+
+type AttestationData struct {
+	Slot            uint64
+	Index           uint64
+	BeaconBlockRoot Root
+	Source          Checkpoint
+	Target          Checkpoint
+}
+
+func (d *AttestationData) HashTreeRoot() Root {
+	return containerRoot(
+		uint64Root(d.Slot),
+		uint64Root(d.Index),
+		d.BeaconBlockRoot,
+		d.Source.HashTreeRoot(),
+		d.Target.HashTreeRoot(),
+	)
+}
+
+// Attestation covers both the phase0 and the Electra container. CommitteeBits is only set for
+// Electra attestations, whose aggregation bits span every committee of the slot.
+type Attestation struct {
+	// AggregationBits is the serialized bitlist, including its length delimiter bit.
+	AggregationBits []byte
+	Data            AttestationData
+	Signature       [96]byte
+	CommitteeBits   *[MaxCommitteesPerSlot / 8]byte
+}
+
+func (a *Attestation) HashTreeRoot() (Root, error) {
+	if a.CommitteeBits == nil {
+		aggregationBits, err := bitlistRoot(a.AggregationBits, MaxValidatorsPerCommittee)
+		if err != nil {
+			return Root{}, err
+		}
+		return containerRoot(aggregationBits, a.Data.HashTreeRoot(), bytesRoot(a.Signature[:])), nil
+	}
+
+	aggregationBits, err := bitlistRoot(a.AggregationBits, MaxValidatorsPerCommittee*MaxCommitteesPerSlot)
+	if err != nil {
+		return Root{}, err
+	}
+	return containerRoot(
+		aggregationBits,
+		a.Data.HashTreeRoot(),
+		bytesRoot(a.Signature[:]),
+		bitvectorRoot(a.CommitteeBits[:], MaxCommitteesPerSlot),
+	), nil
+}
+
+type AggregateAndProof struct {
+	AggregatorIndex uint64
+	Aggregate       Attestation
+	SelectionProof  [96]byte
+}
+
+func (a *AggregateAndProof) HashTreeRoot() (Root, error) {
+	aggregate, err := a.Aggregate.HashTreeRoot()
+	if err != nil {
+		return Root{}, err
+	}
+	return containerRoot(uint64Root(a.AggregatorIndex), aggregate, bytesRoot(a.SelectionProof[:])), nil
+}
+
+type SyncAggregatorSelectionData struct {
+	Slot              uint64
+	SubcommitteeIndex uint64
+}
+
+func (d *SyncAggregatorSelectionData) HashTreeRoot() Root {
+	return containerRoot(uint64Root(d.Slot), uint64Root(d.SubcommitteeIndex))
+}
+
+type SyncCommitteeContribution struct {
+	Slot              uint64
+	BeaconBlockRoot   Root
+	SubcommitteeIndex uint64
+	AggregationBits   [SyncSubcommitteeSize / 8]byte
+	Signature         [96]byte
+}
+
+func (c *SyncCommitteeContribution) HashTreeRoot() Root {
+	return containerRoot(
+		uint64Root(c.Slot),
+		c.BeaconBlockRoot,
+		uint64Root(c.SubcommitteeIndex),
+		bitvectorRoot(c.AggregationBits[:], SyncSubcommitteeSize),
+		bytesRoot(c.Signature[:]),
+	)
+}
+
+type ContributionAndProof struct {
+	AggregatorIndex uint64
+	Contribution    SyncCommitteeContribution
+	SelectionProof  [96]byte
+}
+
+func (c *ContributionAndProof) HashTreeRoot() Root {
+	return containerRoot(uint64Root(c.AggregatorIndex), c.Contribution.HashTreeRoot(), bytesRoot(c.SelectionProof[:]))
+}
+
+type VoluntaryExit struct {
+	Epoch          uint64
+	ValidatorIndex uint64
+}
+
+func (e *VoluntaryExit) HashTreeRoot() Root {
+	return containerRoot(uint64Root(e.Epoch), uint64Root(e.ValidatorIndex))
+}
+
+type BLSToExecutionChange struct {
+	ValidatorIndex     uint64
+	FromBLSPubkey      [48]byte
+	ToExecutionAddress [20]byte
+}
+
+func (c *BLSToExecutionChange) HashTreeRoot() Root {
+	return containerRoot(uint64Root(c.ValidatorIndex), bytesRoot(c.FromBLSPubkey[:]), bytesRoot(c.ToExecutionAddress[:]))
+}
+
+// Uint64Root is the hash tree root of a slot or an epoch, the object signed for selection proofs
+// and RANDAO reveals.
+func Uint64Root(v uint64) Root {
+	return uint64Root(v)
+}
+
+// bitlistRoot computes the root of a serialized SSZ bitlist with at most limit bits. The highest set
+// bit of the last byte marks the length of the list and is not part of it.
+func bitlistRoot(b []byte, limit uint64) (Root, error) {
+	if len(b) == 0 || b[len(b)-1] == 0 {
+		return Root{}, errors.New("bitlist is missing its length bit")
+	}
+
+	delimiter := bits.Len8(b[len(b)-1]) - 1
+	length := uint64(len(b)-1)*8 + uint64(delimiter)
+	if length > limit {
+		return Root{}, fmt.Errorf("bitlist has %d bits, at most %d are allowed", length, limit)
+	}
+
+	listBits := append([]byte(nil), b...)
+	listBits[len(listBits)-1] &^= 1 << delimiter
+	listBits = listBits[:(length+7)/8]
+	return mixInLength(merkleize(pack(listBits), int((limit+255)/256)), length), nil
+}
+
+func bitvectorRoot(b []byte, size int) Root {
+	return merkleize(pack(b), (size+255)/256)
+}
@@ -0,0 +1,112 @@
+package eth2
+
+import (
+	"bytes"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func filledRoot(b byte) Root {
+	var root Root
+	copy(root[:], bytes.Repeat([]byte{b}, 32))
+	return root
+}
+
+func testAttestationData() AttestationData {
+	return AttestationData{
+		Slot:            3232,
+		Index:           7,
+		BeaconBlockRoot: filledRoot(6),
+		Source:          Checkpoint{Epoch: 100, Root: filledRoot(4)},
+		Target:          Checkpoint{Epoch: 101, Root: filledRoot(5)},
+	}
+}
+
+func testSignature() [96]byte {
+	var signature [96]byte
+	for i := range signature {
+		signature[i] = byte(i)
+	}
+	return signature
+}
+
+func TestConsensusRoots(t *testing.T) {
+	header := BeaconBlockHeader{Slot: 123456, ProposerIndex: 42, ParentRoot: filledRoot(1), StateRoot: filledRoot(2), BodyRoot: filledRoot(3)}
+	headerRoot := header.HashTreeRoot()
+	assert.Equal(t, "985633ee51134e8361bcbf86d1f947e0fdb8b7db1a27dae9606eeb9edbc47d28", hex.EncodeToString(headerRoot[:]))
+
+	data := testAttestationData()
+	dataRoot := data.HashTreeRoot()
+	assert.Equal(t, "1632770eabad027a6e2afaa5610f750e4d73b3d4999ca43c1bf62318afa2b79c", hex.EncodeToString(dataRoot[:]))
+
+	selectionProof := [96]byte(bytes.Repeat([]byte{0xaa}, 96))
+	aggregateAndProof := AggregateAndProof{
+		AggregatorIndex: 9,
+		Aggregate:       Attestation{AggregationBits: mustDecodeHex(t, "4906"), Data: data, Signature: testSignature()},
+		SelectionProof:  selectionProof,
+	}
+	aggregateRoot, err := aggregateAndProof.Aggregate.HashTreeRoot()
+	require.NoError(t, err)
+	assert.Equal(t, "8910f829ce11a5edb720837e72f71e4c5a79be8001ea70547c6b22b9eed2828a", hex.EncodeToString(aggregateRoot[:]))
+	aggregateAndProofRoot, err := aggregateAndProof.HashTreeRoot()
+	require.NoError(t, err)
+	assert.Equal(t, "8baf6011fc9c22ea768695b5eaf7a9c79eb6f083faed35196a5d712ef6934a6c", hex.EncodeToString(aggregateAndProofRoot[:]))
+
+	contributionAndProof := ContributionAndProof{
+		AggregatorIndex: 9,
+		Contribution: SyncCommitteeContribution{
+			Slot:              3232,
+			BeaconBlockRoot:   filledRoot(6),
+			SubcommitteeIndex: 2,
+			AggregationBits:   [16]byte(bytes.Repeat([]byte{0xff}, 16)),
+			Signature:         testSignature(),
+		},
+		SelectionProof: selectionProof,
+	}
+	contributionRoot := contributionAndProof.HashTreeRoot()
+	assert.Equal(t, "a44d4c908cc92b7e882de043636a3e3ceba2cf1b84eef17b4828f9e7ef2077f4", hex.EncodeToString(contributionRoot[:]))
+}
+
+func TestElectraAttestationRoot(t *testing.T) {
+	attestation := Attestation{
+		AggregationBits: mustDecodeHex(t, "2184104208218410420821841042082184104208218410420821841042082184104208218410"),
+		Data:            testAttestationData(),
+		Signature:       testSignature(),
+		CommitteeBits:   &[8]byte{0b101},
+	}
+
+	root, err := attestation.HashTreeRoot()
+
+	require.NoError(t, err)
+	assert.Equal(t, "a9d2c52424288f592517ff32632aaedfddcbdb31db8ef78670883fd05f74781d", hex.EncodeToString(root[:]))
+}
+
+func TestBitlistRoot(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		root, err := bitlistRoot([]byte{0x01}, MaxValidatorsPerCommittee)
+		require.NoError(t, err)
+		assert.Equal(t, "e8e527e84f666163a90ef900e013f56b0a4d020148b2224057b719f351b003a6", hex.EncodeToString(root[:]))
+	})
+
+	t.Run("missing length bit", func(t *testing.T) {
+		_, err := bitlistRoot([]byte{0x49, 0x00}, MaxValidatorsPerCommittee)
+		assert.Error(t, err)
+		_, err = bitlistRoot(nil, MaxValidatorsPerCommittee)
+		assert.Error(t, err)
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		_, err := bitlistRoot([]byte{0xff, 0x02}, 8)
+		assert.ErrorContains(t, err, "9 bits")
+	})
+}
+
+func TestForkVersion(t *testing.T) {
+	fork := Fork{PreviousVersion: [4]byte{0x03}, CurrentVersion: [4]byte{0x04}, Epoch: 100}
+
+	assert.Equal(t, [4]byte{0x03}, fork.Version(99))
+	assert.Equal(t, [4]byte{0x04}, fork.Version(100))
+	assert.Equal(t, uint64(3), EpochAtSlot(127))
+}
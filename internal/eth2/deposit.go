@@ -0,0 +1,83 @@
+package eth2
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"stakeway_test_task/internal/bls"
+)
+
+const (
+	GweiPerEth = 1_000_000_000
+
+	MinDepositAmount    uint64 = 1 * GweiPerEth
+	MaxEffectiveBalance uint64 = 32 * GweiPerEth
+	// MaxEffectiveBalanceElectra is the maximum effective balance of validators with compounding credentials.
+	MaxEffectiveBalanceElectra uint64 = 2048 * GweiPerEth
+
+	BLSWithdrawalPrefix         byte = 0x00
+	ExecutionWithdrawalPrefix   byte = 0x01
+	CompoundingWithdrawalPrefix byte = 0x02
+
+	DepositContractTreeDepth = 32
+)
+
+func BLSWithdrawalCredentials(withdrawalPubkey []byte) [32]byte {
+	credentials := sha256.Sum256(withdrawalPubkey)
+	credentials[0] = BLSWithdrawalPrefix
+	return credentials
+}
+
+// ExecutionWithdrawalCredentials returns withdrawal credentials paying out to address, 0x01 credentials with
+// ExecutionWithdrawalPrefix or compounding 0x02 credentials with CompoundingWithdrawalPrefix.
+func ExecutionWithdrawalCredentials(prefix byte, address common.Address) [32]byte {
+	var credentials [32]byte
+	credentials[0] = prefix
+	copy(credentials[12:], address[:])
+	return credentials
+}
+
+// ValidateWithdrawalCredentials checks that credentials have a known prefix, and that execution credentials
+// are zero between the prefix and the address. Funds deposited with other credentials cannot be withdrawn.
+func ValidateWithdrawalCredentials(credentials [32]byte) error {
+	switch credentials[0] {
+	case BLSWithdrawalPrefix:
+	case ExecutionWithdrawalPrefix, CompoundingWithdrawalPrefix:
+		if !bytes.Equal(credentials[1:12], make([]byte, 11)) {
+			return fmt.Errorf("execution withdrawal credentials must be zero-padded between prefix and address")
+		}
+	default:
+		return fmt.Errorf("unsupported withdrawal credentials prefix 0x%02x", credentials[0])
+	}
+	return nil
+}
+
+func DepositDomain(network *Network) [32]byte {
+	return ComputeDomain(DomainDeposit, network.GenesisForkVersion, Root{})
+}
+
+// SignDeposit builds deposit data for key. Deposits are signed over the genesis fork version
+// with an empty genesis validators root so that they stay valid across forks.
+func SignDeposit(key *bls.SecretKey, withdrawalCredentials [32]byte, amount uint64, network *Network) *DepositData {
+	data := &DepositData{
+		WithdrawalCredentials: withdrawalCredentials,
+		Amount:                amount,
+	}
+	copy(data.Pubkey[:], key.PublicKey())
+
+	signingRoot := ComputeSigningRoot(data.Message().HashTreeRoot(), DepositDomain(network))
+	copy(data.Signature[:], key.Sign(signingRoot[:]))
+	return data
+}
+
+func VerifyDeposit(data *DepositData, network *Network) bool {
+	signingRoot := ComputeSigningRoot(data.Message().HashTreeRoot(), DepositDomain(network))
+	return bls.Verify(data.Pubkey[:], signingRoot[:], data.Signature[:])
+}
+
+// DepositRoot computes the root the deposit contract reports from get_deposit_root after the deposits
+// with the given deposit data roots were made, in order.
+func DepositRoot(depositDataRoots []Root) Root {
+	return mixInLength(merkleize(depositDataRoots, 1<<DepositContractTreeDepth), uint64(len(depositDataRoots)))
+}
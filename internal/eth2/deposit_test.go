@@ -0,0 +1,98 @@
+package eth2
+
+import (
+	"encoding/hex"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"stakeway_test_task/internal/bls"
+	"testing"
+)
+
+// Holesky deposit produced by the staking deposit CLI.
+func holeskyDeposit(t *testing.T) *DepositData {
+	var data DepositData
+	copy(data.Pubkey[:], mustDecodeHex(t, "80df3c8cb942676d7ade40b42a7306ea6275a9da4c3b952efb1a6dd9a9b4f2d21cca7463b7ca3cfe0dd09b6c335d5c26"))
+	copy(data.WithdrawalCredentials[:], mustDecodeHex(t, "010000000000000000000000e8011087b85953f2b0816a236876a9779462f10b"))
+	data.Amount = 32000000000
+	copy(data.Signature[:], mustDecodeHex(t, "b9e17630cb463eadb0e7f38f20d55de70b3e86eccec83b11370543bf807e3d418a87b79be470097f9777a10f396c142a0039fbbf083c56c2b40e95a431a161faeb3ba9d978790492c399af286b414bd23cff17682eb86bb3d8a701b07d31a15a"))
+	return &data
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func TestDepositRoots(t *testing.T) {
+	data := holeskyDeposit(t)
+
+	messageRoot := data.Message().HashTreeRoot()
+	dataRoot := data.HashTreeRoot()
+
+	assert.Equal(t, "4d76a0d4bb0a15d08acdc5275fd9e8dd05e8f9885791c4ad824cead637962d7d", hex.EncodeToString(messageRoot[:]))
+	assert.Equal(t, "84807f8376d45efcf002f7fd695fca00a48b0da60d67ab71c7a82d94341eb11e", hex.EncodeToString(dataRoot[:]))
+}
+
+func TestDepositRoot(t *testing.T) {
+	// Root reported by every deposit contract before its first deposit.
+	empty := DepositRoot(nil)
+	assert.Equal(t, "d70a234731285c6804c2a4f56711ddb8c82c99740f207854891028af34e27e5e", hex.EncodeToString(empty[:]))
+
+	dataRoot := holeskyDeposit(t).HashTreeRoot()
+	assert.NotEqual(t, empty, DepositRoot([]Root{dataRoot}))
+}
+
+func TestVerifyDeposit(t *testing.T) {
+	holesky, err := NetworkByName("holesky")
+	require.NoError(t, err)
+	mainnet, err := NetworkByName("mainnet")
+	require.NoError(t, err)
+
+	data := holeskyDeposit(t)
+	assert.True(t, VerifyDeposit(data, holesky))
+	assert.False(t, VerifyDeposit(data, mainnet))
+
+	data.Amount = MinDepositAmount
+	assert.False(t, VerifyDeposit(data, holesky))
+}
+
+func TestSignDeposit(t *testing.T) {
+	network, err := NetworkByName("hoodi")
+	require.NoError(t, err)
+
+	key, err := bls.GenerateKey()
+	require.NoError(t, err)
+	withdrawalKey, err := bls.GenerateKey()
+	require.NoError(t, err)
+
+	credentials := BLSWithdrawalCredentials(withdrawalKey.PublicKey())
+	assert.Equal(t, BLSWithdrawalPrefix, credentials[0])
+
+	data := SignDeposit(key, credentials, MaxEffectiveBalance, network)
+
+	assert.Equal(t, key.PublicKey(), data.Pubkey[:])
+	assert.Equal(t, credentials, data.WithdrawalCredentials)
+	assert.Equal(t, MaxEffectiveBalance, data.Amount)
+	assert.True(t, VerifyDeposit(data, network))
+}
+
+func TestExecutionWithdrawalCredentials(t *testing.T) {
+	address := common.HexToAddress("0xe8011087b85953f2b0816a236876a9779462f10b")
+
+	execution := ExecutionWithdrawalCredentials(ExecutionWithdrawalPrefix, address)
+	assert.Equal(t, "010000000000000000000000e8011087b85953f2b0816a236876a9779462f10b", hex.EncodeToString(execution[:]))
+
+	compounding := ExecutionWithdrawalCredentials(CompoundingWithdrawalPrefix, address)
+	assert.Equal(t, "020000000000000000000000e8011087b85953f2b0816a236876a9779462f10b", hex.EncodeToString(compounding[:]))
+}
+
+func TestNetworkByName(t *testing.T) {
+	network, err := NetworkByName("Holesky")
+	require.NoError(t, err)
+	assert.Equal(t, "holesky", network.Name)
+
+	_, err = NetworkByName("ropsten")
+	assert.Error(t, err)
+}
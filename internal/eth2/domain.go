@@ -0,0 +1,30 @@
+package eth2
+
+type DomainType [4]byte
+
+var (
+	DomainBeaconProposer              = DomainType{0x00, 0x00, 0x00, 0x00}
+	DomainBeaconAttester              = DomainType{0x01, 0x00, 0x00, 0x00}
+	DomainRandao                      = DomainType{0x02, 0x00, 0x00, 0x00}
+	DomainDeposit                     = DomainType{0x03, 0x00, 0x00, 0x00}
+	DomainVoluntaryExit               = DomainType{0x04, 0x00, 0x00, 0x00}
+	DomainSelectionProof              = DomainType{0x05, 0x00, 0x00, 0x00}
+	DomainAggregateAndProof           = DomainType{0x06, 0x00, 0x00, 0x00}
+	DomainSyncCommittee               = DomainType{0x07, 0x00, 0x00, 0x00}
+	DomainSyncCommitteeSelectionProof = DomainType{0x08, 0x00, 0x00, 0x00}
+	DomainContributionAndProof        = DomainType{0x09, 0x00, 0x00, 0x00}
+	DomainBLSToExecutionChange        = DomainType{0x0a, 0x00, 0x00, 0x00}
+)
+
+func ComputeDomain(domainType DomainType, forkVersion [4]byte, genesisValidatorsRoot Root) [32]byte {
+	forkDataRoot := (&ForkData{CurrentVersion: forkVersion, GenesisValidatorsRoot: genesisValidatorsRoot}).HashTreeRoot()
+
+	var domain [32]byte
+	copy(domain[:4], domainType[:])
+	copy(domain[4:], forkDataRoot[:28])
+	return domain
+}
+
+func ComputeSigningRoot(objectRoot Root, domain [32]byte) Root {
+	return (&SigningData{ObjectRoot: objectRoot, Domain: domain}).HashTreeRoot()
+}
@@ -0,0 +1,24 @@
+package eth2
+
+import (
+	"stakeway_test_task/internal/bls"
+)
+
+// VoluntaryExitDomain is the domain of voluntary exits. Since Deneb (EIP-7044) exits are signed over the
+// Capella fork version whatever the current fork, so that a pre-signed exit never expires.
+func VoluntaryExitDomain(network *Network) [32]byte {
+	return ComputeDomain(DomainVoluntaryExit, network.CapellaForkVersion, network.GenesisValidatorsRoot)
+}
+
+func SignVoluntaryExit(key *bls.SecretKey, exit *VoluntaryExit, network *Network) [96]byte {
+	signingRoot := ComputeSigningRoot(exit.HashTreeRoot(), VoluntaryExitDomain(network))
+
+	var signature [96]byte
+	copy(signature[:], key.Sign(signingRoot[:]))
+	return signature
+}
+
+func VerifyVoluntaryExit(pubkey []byte, exit *VoluntaryExit, signature [96]byte, network *Network) bool {
+	signingRoot := ComputeSigningRoot(exit.HashTreeRoot(), VoluntaryExitDomain(network))
+	return bls.Verify(pubkey, signingRoot[:], signature[:])
+}
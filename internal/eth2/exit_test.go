@@ -0,0 +1,38 @@
+package eth2
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"stakeway_test_task/internal/bls"
+	"testing"
+)
+
+func TestVoluntaryExitDomain(t *testing.T) {
+	mainnet, err := NetworkByName("mainnet")
+	require.NoError(t, err)
+
+	exit := VoluntaryExit{Epoch: 194048, ValidatorIndex: 123456}
+	exitRoot := exit.HashTreeRoot()
+	assert.Equal(t, "4e6eb6f024c6cbf84d81345bb6726696a93eca5a088e8a798fc2f9d20c152698", hex.EncodeToString(exitRoot[:]))
+
+	domain := VoluntaryExitDomain(mainnet)
+	assert.Equal(t, "04000000bba4da96354c9f25476cf1bc69bf583a7f9e0af049305b62de676640", hex.EncodeToString(domain[:]))
+}
+
+func TestSignVoluntaryExit(t *testing.T) {
+	holesky, err := NetworkByName("holesky")
+	require.NoError(t, err)
+	hoodi, err := NetworkByName("hoodi")
+	require.NoError(t, err)
+
+	key, err := bls.GenerateKey()
+	require.NoError(t, err)
+
+	exit := &VoluntaryExit{Epoch: 256, ValidatorIndex: 42}
+	signature := SignVoluntaryExit(key, exit, holesky)
+
+	assert.True(t, VerifyVoluntaryExit(key.PublicKey(), exit, signature, holesky))
+	assert.False(t, VerifyVoluntaryExit(key.PublicKey(), exit, signature, hoodi))
+	assert.False(t, VerifyVoluntaryExit(key.PublicKey(), &VoluntaryExit{Epoch: 256, ValidatorIndex: 43}, signature, holesky))
+}
@@ -0,0 +1,86 @@
+package eth2
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"sort"
+	"strings"
+)
+
+type Network struct {
+	Name            string
+	ChainID         uint64
+	DepositContract common.Address
+	// DepositContractBlock is the block the deposit contract was deployed in, the first block with deposit logs.
+	DepositContractBlock uint64
+	GenesisForkVersion   [4]byte
+	// CapellaForkVersion signs voluntary exits, which EIP-7044 pins to the Capella fork.
+	CapellaForkVersion [4]byte
+	// GenesisValidatorsRoot identifies the beacon chain in signing domains and slashing protection data.
+	GenesisValidatorsRoot Root
+}
+
+var networks = map[string]*Network{
+	"mainnet": {
+		Name:                  "mainnet",
+		ChainID:               1,
+		DepositContract:       common.HexToAddress("0x00000000219ab540356cBB839Cbe05303d7705Fa"),
+		DepositContractBlock:  11052984,
+		GenesisForkVersion:    [4]byte{0x00, 0x00, 0x00, 0x00},
+		CapellaForkVersion:    [4]byte{0x03, 0x00, 0x00, 0x00},
+		GenesisValidatorsRoot: common.HexToHash("0x4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95"),
+	},
+	"holesky": {
+		Name:                  "holesky",
+		ChainID:               17000,
+		DepositContract:       common.HexToAddress("0x4242424242424242424242424242424242424242"),
+		DepositContractBlock:  0,
+		GenesisForkVersion:    [4]byte{0x01, 0x01, 0x70, 0x00},
+		CapellaForkVersion:    [4]byte{0x04, 0x01, 0x70, 0x00},
+		GenesisValidatorsRoot: common.HexToHash("0x9143aa7c615a7f7115e2b6aac319c03529df8242ae705fba9df39b79c59fa8b1"),
+	},
+	"sepolia": {
+		Name:                  "sepolia",
+		ChainID:               11155111,
+		DepositContract:       common.HexToAddress("0x7f02C3E3c98b133055B8B348B2Ac625669Ed295D"),
+		DepositContractBlock:  1273020,
+		GenesisForkVersion:    [4]byte{0x90, 0x00, 0x00, 0x69},
+		CapellaForkVersion:    [4]byte{0x90, 0x00, 0x00, 0x72},
+		GenesisValidatorsRoot: common.HexToHash("0xd8ea171f3c94aea21ebc42a1ed61052acf3f9209c00e4efbaaddac09ed9b8078"),
+	},
+	"hoodi": {
+		Name:                  "hoodi",
+		ChainID:               560048,
+		DepositContract:       common.HexToAddress("0x00000000219ab540356cBB839Cbe05303d7705Fa"),
+		DepositContractBlock:  0,
+		GenesisForkVersion:    [4]byte{0x10, 0x00, 0x09, 0x10},
+		CapellaForkVersion:    [4]byte{0x40, 0x00, 0x09, 0x10},
+		GenesisValidatorsRoot: common.HexToHash("0x212f13fc4df078b6cb7db228f1c8307566dcecf900867401a92023d7ba99cb5f"),
+	},
+}
+
+func NetworkByName(name string) (*Network, error) {
+	network, ok := networks[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown network %q, supported networks: %s", name, strings.Join(NetworkNames(), ", "))
+	}
+	return network, nil
+}
+
+func NetworkByChainID(chainID uint64) (*Network, error) {
+	for _, network := range networks {
+		if network.ChainID == chainID {
+			return network, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported chain ID %d", chainID)
+}
+
+func NetworkNames() []string {
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,77 @@
+package eth2
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+type Root = [32]byte
+
+var zeroHashes = func() [64]Root {
+	var hashes [64]Root
+	for i := 1; i < len(hashes); i++ {
+		hashes[i] = hashPair(hashes[i-1], hashes[i-1])
+	}
+	return hashes
+}()
+
+func hashPair(a, b Root) Root {
+	return sha256.Sum256(append(a[:], b[:]...))
+}
+
+// merkleize computes the SSZ merkle root of chunks padded with zero chunks up to limit
+// (or up to the next power of two when limit is smaller than the number of chunks).
+func merkleize(chunks []Root, limit int) Root {
+	if limit < len(chunks) {
+		limit = len(chunks)
+	}
+
+	depth := 0
+	for 1<<depth < limit {
+		depth++
+	}
+	if len(chunks) == 0 {
+		return zeroHashes[depth]
+	}
+
+	layer := append([]Root(nil), chunks...)
+	for d := 0; d < depth; d++ {
+		if len(layer)%2 == 1 {
+			layer = append(layer, zeroHashes[d])
+		}
+		next := make([]Root, len(layer)/2)
+		for i := range next {
+			next[i] = hashPair(layer[2*i], layer[2*i+1])
+		}
+		layer = next
+	}
+	return layer[0]
+}
+
+func mixInLength(root Root, length uint64) Root {
+	var lengthChunk Root
+	binary.LittleEndian.PutUint64(lengthChunk[:], length)
+	return hashPair(root, lengthChunk)
+}
+
+func pack(b []byte) []Root {
+	chunks := make([]Root, (len(b)+31)/32)
+	for i := range chunks {
+		copy(chunks[i][:], b[i*32:])
+	}
+	return chunks
+}
+
+func bytesRoot(b []byte) Root {
+	return merkleize(pack(b), 0)
+}
+
+func uint64Root(v uint64) Root {
+	var chunk Root
+	binary.LittleEndian.PutUint64(chunk[:], v)
+	return chunk
+}
+
+func containerRoot(fields ...Root) Root {
+	return merkleize(fields, 0)
+}
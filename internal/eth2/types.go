@@ -0,0 +1,57 @@
+package eth2
+
+type DepositMessage struct {
+	Pubkey                [48]byte
+	WithdrawalCredentials [32]byte
+	Amount                uint64
+}
+
+func (m *DepositMessage) HashTreeRoot() Root {
+	return containerRoot(
+		bytesRoot(m.Pubkey[:]),
+		m.WithdrawalCredentials,
+		uint64Root(m.Amount),
+	)
+}
+
+type DepositData struct {
+	Pubkey                [48]byte
+	WithdrawalCredentials [32]byte
+	Amount                uint64
+	Signature             [96]byte
+}
+
+func (d *DepositData) Message() *DepositMessage {
+	return &DepositMessage{
+		Pubkey:                d.Pubkey,
+		WithdrawalCredentials: d.WithdrawalCredentials,
+		Amount:                d.Amount,
+	}
+}
+
+func (d *DepositData) HashTreeRoot() Root {
+	return containerRoot(
+		bytesRoot(d.Pubkey[:]),
+		d.WithdrawalCredentials,
+		uint64Root(d.Amount),
+		bytesRoot(d.Signature[:]),
+	)
+}
+
+type ForkData struct {
+	CurrentVersion        [4]byte
+	GenesisValidatorsRoot Root
+}
+
+func (f *ForkData) HashTreeRoot() Root {
+	return containerRoot(bytesRoot(f.CurrentVersion[:]), f.GenesisValidatorsRoot)
+}
+
+type SigningData struct {
+	ObjectRoot Root
+	Domain     [32]byte
+}
+
+func (s *SigningData) HashTreeRoot() Root {
+	return containerRoot(s.ObjectRoot, s.Domain)
+}
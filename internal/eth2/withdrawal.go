@@ -0,0 +1,26 @@
+package eth2
+
+import (
+	"stakeway_test_task/internal/bls"
+)
+
+// BLSToExecutionChangeDomain is the domain of BLS to execution changes, which are signed over the genesis
+// fork version so that they are valid in every fork.
+func BLSToExecutionChangeDomain(network *Network) [32]byte {
+	return ComputeDomain(DomainBLSToExecutionChange, network.GenesisForkVersion, network.GenesisValidatorsRoot)
+}
+
+// SignBLSToExecutionChange signs change with withdrawalKey, the key the validator's 0x00 withdrawal
+// credentials were computed from.
+func SignBLSToExecutionChange(withdrawalKey *bls.SecretKey, change *BLSToExecutionChange, network *Network) [96]byte {
+	signingRoot := ComputeSigningRoot(change.HashTreeRoot(), BLSToExecutionChangeDomain(network))
+
+	var signature [96]byte
+	copy(signature[:], withdrawalKey.Sign(signingRoot[:]))
+	return signature
+}
+
+func VerifyBLSToExecutionChange(change *BLSToExecutionChange, signature [96]byte, network *Network) bool {
+	signingRoot := ComputeSigningRoot(change.HashTreeRoot(), BLSToExecutionChangeDomain(network))
+	return bls.Verify(change.FromBLSPubkey[:], signingRoot[:], signature[:])
+}
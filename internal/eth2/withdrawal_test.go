@@ -0,0 +1,47 @@
+package eth2
+
+import (
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"stakeway_test_task/internal/bls"
+	"testing"
+)
+
+func TestBLSToExecutionChangeDomain(t *testing.T) {
+	holesky, err := NetworkByName("holesky")
+	require.NoError(t, err)
+
+	change := BLSToExecutionChange{ValidatorIndex: 123456}
+	for i := range change.FromBLSPubkey {
+		change.FromBLSPubkey[i] = byte(i)
+	}
+	for i := range change.ToExecutionAddress {
+		change.ToExecutionAddress[i] = 0xab
+	}
+	changeRoot := change.HashTreeRoot()
+	assert.Equal(t, "dfb5713ab127f3084c19b2cc4bb36b2a66e5c9226bb74bbd2f7f47b6a4c9f1f6", hex.EncodeToString(changeRoot[:]))
+
+	domain := BLSToExecutionChangeDomain(holesky)
+	assert.Equal(t, "0a000000219d34fee03d4513e55e0d6186071458a1bf5fd6ff3c59aa9a6fc45b", hex.EncodeToString(domain[:]))
+}
+
+func TestSignBLSToExecutionChange(t *testing.T) {
+	hoodi, err := NetworkByName("hoodi")
+	require.NoError(t, err)
+	sepolia, err := NetworkByName("sepolia")
+	require.NoError(t, err)
+
+	withdrawalKey, err := bls.GenerateKey()
+	require.NoError(t, err)
+
+	change := &BLSToExecutionChange{ValidatorIndex: 42, ToExecutionAddress: [20]byte{0x01}}
+	copy(change.FromBLSPubkey[:], withdrawalKey.PublicKey())
+	signature := SignBLSToExecutionChange(withdrawalKey, change, hoodi)
+
+	assert.True(t, VerifyBLSToExecutionChange(change, signature, hoodi))
+	assert.False(t, VerifyBLSToExecutionChange(change, signature, sepolia))
+
+	change.ToExecutionAddress = [20]byte{0x02}
+	assert.False(t, VerifyBLSToExecutionChange(change, signature, hoodi))
+}
@@ -0,0 +1,285 @@
+package keystore
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/sync/semaphore"
+	"golang.org/x/text/unicode/norm"
+	"strings"
+)
+
+const (
+	KDFScrypt = "scrypt"
+	KDFPBKDF2 = "pbkdf2"
+
+	version = 4
+)
+
+// KDF parameters above the EIP-2335 ones are refused. They come from the keystore, and would otherwise let
+// a single keystore take unbounded memory and CPU to decrypt.
+const (
+	maxScryptN = 262144
+	maxScryptR = 8
+	maxScryptP = 1
+	maxPBKDF2C = 262144
+	maxDKLen   = 32
+)
+
+// scryptMemoryBudget bounds the memory scrypt uses for all keystores encrypted or decrypted at the same
+// time. It fits one derivation with the EIP-2335 parameters, which takes 128·r·n = 256 MiB, so the server
+// stays within the 512Mi limit of kuber/deployment.yaml.
+const scryptMemoryBudget = 128 * maxScryptR * maxScryptN
+
+// ScryptConcurrency is the number of keystores with the EIP-2335 scrypt parameters that fit the scrypt memory
+// budget at the same time. Further scrypt keystores wait for one of them to be derived.
+const ScryptConcurrency = scryptMemoryBudget / (128 * maxScryptR * maxScryptN)
+
+var (
+	ErrUnsupportedKDF  = errors.New("unsupported key derivation function")
+	ErrInvalidPassword = errors.New("invalid keystore password")
+	ErrKDFParams       = errors.New("kdf parameters exceed the EIP-2335 defaults")
+
+	scryptMemory = semaphore.NewWeighted(scryptMemoryBudget)
+)
+
+type Keystore struct {
+	Crypto      Crypto `json:"crypto"`
+	Description string `json:"description"`
+	Pubkey      string `json:"pubkey"`
+	Path        string `json:"path"`
+	UUID        string `json:"uuid"`
+	Version     int    `json:"version"`
+}
+
+type Crypto struct {
+	KDF      Module `json:"kdf"`
+	Checksum Module `json:"checksum"`
+	Cipher   Module `json:"cipher"`
+}
+
+type Module struct {
+	Function string                 `json:"function"`
+	Params   map[string]interface{} `json:"params"`
+	Message  string                 `json:"message"`
+}
+
+// Encrypt produces an EIP-2335 keystore for secret using the recommended KDF parameters.
+func Encrypt(secret, pubkey []byte, path, password, kdf string) (*Keystore, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	var kdfModule Module
+	switch kdf {
+	case KDFScrypt:
+		kdfModule = Module{
+			Function: KDFScrypt,
+			Params:   map[string]interface{}{"dklen": 32, "n": 262144, "r": 8, "p": 1, "salt": hex.EncodeToString(salt)},
+		}
+	case KDFPBKDF2:
+		kdfModule = Module{
+			Function: KDFPBKDF2,
+			Params:   map[string]interface{}{"dklen": 32, "c": 262144, "prf": "hmac-sha256", "salt": hex.EncodeToString(salt)},
+		}
+	default:
+		return nil, ErrUnsupportedKDF
+	}
+
+	decryptionKey, err := deriveKey(kdfModule, password)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherText, err := aes128CTR(decryptionKey[:16], iv, secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Keystore{
+		Crypto: Crypto{
+			KDF: kdfModule,
+			Checksum: Module{
+				Function: "sha256",
+				Params:   map[string]interface{}{},
+				Message:  hex.EncodeToString(checksum(decryptionKey, cipherText)),
+			},
+			Cipher: Module{
+				Function: "aes-128-ctr",
+				Params:   map[string]interface{}{"iv": hex.EncodeToString(iv)},
+				Message:  hex.EncodeToString(cipherText),
+			},
+		},
+		Pubkey:  hex.EncodeToString(pubkey),
+		Path:    path,
+		UUID:    uuid.New().String(),
+		Version: version,
+	}, nil
+}
+
+func Decrypt(ks *Keystore, password string) ([]byte, error) {
+	if ks.Version != version {
+		return nil, fmt.Errorf("unsupported keystore version %d", ks.Version)
+	}
+	if ks.Crypto.Checksum.Function != "sha256" {
+		return nil, fmt.Errorf("unsupported checksum function %q", ks.Crypto.Checksum.Function)
+	}
+	if ks.Crypto.Cipher.Function != "aes-128-ctr" {
+		return nil, fmt.Errorf("unsupported cipher function %q", ks.Crypto.Cipher.Function)
+	}
+
+	decryptionKey, err := deriveKey(ks.Crypto.KDF, password)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherText, err := hex.DecodeString(ks.Crypto.Cipher.Message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cipher message: %w", err)
+	}
+	expectedChecksum, err := hex.DecodeString(ks.Crypto.Checksum.Message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checksum message: %w", err)
+	}
+	if !bytes.Equal(checksum(decryptionKey, cipherText), expectedChecksum) {
+		return nil, ErrInvalidPassword
+	}
+
+	iv, err := hexParam(ks.Crypto.Cipher.Params, "iv")
+	if err != nil {
+		return nil, err
+	}
+	return aes128CTR(decryptionKey[:16], iv, cipherText)
+}
+
+func deriveKey(kdf Module, password string) ([]byte, error) {
+	salt, err := hexParam(kdf.Params, "salt")
+	if err != nil {
+		return nil, err
+	}
+	dklen, err := intParam(kdf.Params, "dklen")
+	if err != nil {
+		return nil, err
+	}
+	if dklen < 32 {
+		return nil, errors.New("kdf dklen must be at least 32")
+	}
+	if dklen > maxDKLen {
+		return nil, fmt.Errorf("%w: dklen %d", ErrKDFParams, dklen)
+	}
+	pass := normalizePassword(password)
+
+	switch kdf.Function {
+	case KDFScrypt:
+		n, err := intParam(kdf.Params, "n")
+		if err != nil {
+			return nil, err
+		}
+		r, err := intParam(kdf.Params, "r")
+		if err != nil {
+			return nil, err
+		}
+		p, err := intParam(kdf.Params, "p")
+		if err != nil {
+			return nil, err
+		}
+		if n <= 0 || r <= 0 || p <= 0 {
+			return nil, fmt.Errorf("invalid scrypt parameters n=%d r=%d p=%d", n, r, p)
+		}
+		if n > maxScryptN || r > maxScryptR || p > maxScryptP {
+			return nil, fmt.Errorf("%w: scrypt n=%d r=%d p=%d", ErrKDFParams, n, r, p)
+		}
+		memory := 128 * int64(r) * int64(n)
+		if err := scryptMemory.Acquire(context.Background(), memory); err != nil {
+			return nil, err
+		}
+		defer scryptMemory.Release(memory)
+		return scrypt.Key(pass, salt, n, r, p, dklen)
+	case KDFPBKDF2:
+		if prf, _ := kdf.Params["prf"].(string); prf != "hmac-sha256" {
+			return nil, fmt.Errorf("unsupported pbkdf2 prf %q", prf)
+		}
+		c, err := intParam(kdf.Params, "c")
+		if err != nil {
+			return nil, err
+		}
+		if c <= 0 {
+			return nil, fmt.Errorf("invalid pbkdf2 parameter c=%d", c)
+		}
+		if c > maxPBKDF2C {
+			return nil, fmt.Errorf("%w: pbkdf2 c=%d", ErrKDFParams, c)
+		}
+		return pbkdf2.Key(pass, salt, c, dklen, sha256.New), nil
+	default:
+		return nil, ErrUnsupportedKDF
+	}
+}
+
+// normalizePassword applies the EIP-2335 password processing: NFKD normalization followed by
+// stripping C0, C1 and Delete control codes.
+func normalizePassword(password string) []byte {
+	normalized := norm.NFKD.String(password)
+	return []byte(strings.Map(func(r rune) rune {
+		if r <= 0x1f || (r >= 0x7f && r <= 0x9f) {
+			return -1
+		}
+		return r
+	}, normalized))
+}
+
+func checksum(decryptionKey, cipherText []byte) []byte {
+	h := sha256.New()
+	h.Write(decryptionKey[16:32])
+	h.Write(cipherText)
+	return h.Sum(nil)
+}
+
+func aes128CTR(key, iv, in []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, errors.New("invalid cipher iv length")
+	}
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out, nil
+}
+
+func hexParam(params map[string]interface{}, name string) ([]byte, error) {
+	value, ok := params[name].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing keystore parameter %q", name)
+	}
+	decoded, err := hex.DecodeString(strings.TrimPrefix(value, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid keystore parameter %q: %w", name, err)
+	}
+	return decoded, nil
+}
+
+func intParam(params map[string]interface{}, name string) (int, error) {
+	switch value := params[name].(type) {
+	case float64:
+		return int(value), nil
+	case int:
+		return value, nil
+	default:
+		return 0, fmt.Errorf("missing keystore parameter %q", name)
+	}
+}
@@ -0,0 +1,159 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// Test vectors from EIP-2335.
+const (
+	testPassword = "\U0001d531\U0001d522\U0001d530\U0001d531\U0001d52d\U0001d51e\U0001d530\U0001d530\U0001d534\U0001d52c\U0001d52f\U0001d521\U0001f511"
+	testSecret   = "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"
+
+	scryptKeystore = `{
+		"crypto": {
+			"kdf": {
+				"function": "scrypt",
+				"params": {
+					"dklen": 32,
+					"n": 262144,
+					"p": 1,
+					"r": 8,
+					"salt": "d4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3"
+				},
+				"message": ""
+			},
+			"checksum": {
+				"function": "sha256",
+				"params": {},
+				"message": "d2217fe5f3e9a1e34581ef8a78f7c9928e436d36dacc5e846690a5581e8ea484"
+			},
+			"cipher": {
+				"function": "aes-128-ctr",
+				"params": {
+					"iv": "264daa3f303d7259501c93d997d84fe6"
+				},
+				"message": "06ae90d55fe0a6e9c5c3bc5b170827b2e5cce3929ed3f116c2811e6366dfe20f"
+			}
+		},
+		"description": "This is a test keystore that uses scrypt to secure the secret.",
+		"pubkey": "9612d7a727c9d0a22e185a1c768478dfe919cada9266988cb32359c11f2b7b27f4ae4040902382ae2910c15e2b420d07",
+		"path": "m/12381/60/3141592653/589793238",
+		"uuid": "1d85ae20-35c5-4611-98e8-aa14a633906f",
+		"version": 4
+	}`
+
+	pbkdf2Keystore = `{
+		"crypto": {
+			"kdf": {
+				"function": "pbkdf2",
+				"params": {
+					"dklen": 32,
+					"c": 262144,
+					"prf": "hmac-sha256",
+					"salt": "d4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3"
+				},
+				"message": ""
+			},
+			"checksum": {
+				"function": "sha256",
+				"params": {},
+				"message": "8a9f5d9912ed7e75ea794bc5a89bca5f193721d30868ade6f73043c6ea6febf1"
+			},
+			"cipher": {
+				"function": "aes-128-ctr",
+				"params": {
+					"iv": "264daa3f303d7259501c93d997d84fe6"
+				},
+				"message": "cee03fde2af33149775b7223e7845e4fb2c8ae1792e5f99fe9ecf474cc8c16ad"
+			}
+		},
+		"description": "This is a test keystore that uses PBKDF2 to secure the secret.",
+		"pubkey": "9612d7a727c9d0a22e185a1c768478dfe919cada9266988cb32359c11f2b7b27f4ae4040902382ae2910c15e2b420d07",
+		"path": "m/12381/60/0/0",
+		"uuid": "64625def-3331-4eea-ab6f-782f3ed16a83",
+		"version": 4
+	}`
+)
+
+func TestDecrypt(t *testing.T) {
+	for name, raw := range map[string]string{"scrypt": scryptKeystore, "pbkdf2": pbkdf2Keystore} {
+		t.Run(name, func(t *testing.T) {
+			var ks Keystore
+			require.NoError(t, json.Unmarshal([]byte(raw), &ks))
+
+			secret, err := Decrypt(&ks, testPassword)
+			require.NoError(t, err)
+			assert.Equal(t, testSecret, hex.EncodeToString(secret))
+
+			_, err = Decrypt(&ks, "wrong password")
+			assert.ErrorIs(t, err, ErrInvalidPassword)
+		})
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	secret, _ := hex.DecodeString(testSecret)
+	pubkey := []byte{0x96, 0x12}
+
+	t.Run("round trip", func(t *testing.T) {
+		ks, err := Encrypt(secret, pubkey, "m/12381/3600/0/0/0", "password", KDFPBKDF2)
+		require.NoError(t, err)
+
+		assert.Equal(t, 4, ks.Version)
+		assert.Equal(t, "9612", ks.Pubkey)
+		assert.Equal(t, "m/12381/3600/0/0/0", ks.Path)
+		assert.NotEmpty(t, ks.UUID)
+
+		raw, err := json.Marshal(ks)
+		require.NoError(t, err)
+
+		var decoded Keystore
+		require.NoError(t, json.Unmarshal(raw, &decoded))
+
+		decrypted, err := Decrypt(&decoded, "password")
+		require.NoError(t, err)
+		assert.Equal(t, secret, decrypted)
+	})
+
+	t.Run("unsupported kdf", func(t *testing.T) {
+		_, err := Encrypt(secret, pubkey, "", "password", "argon2")
+		assert.ErrorIs(t, err, ErrUnsupportedKDF)
+	})
+}
+
+func TestNormalizePassword(t *testing.T) {
+	assert.Equal(t, []byte("testpassword\U0001f511"), normalizePassword(testPassword))
+	assert.Equal(t, []byte("pass"), normalizePassword("pa\x00s\x7fs\u0085"))
+}
+
+func TestDecryptKDFParams(t *testing.T) {
+	for name, tt := range map[string]struct {
+		function string
+		params   map[string]interface{}
+	}{
+		"scrypt n":     {KDFScrypt, map[string]interface{}{"n": 524288}},
+		"scrypt r":     {KDFScrypt, map[string]interface{}{"r": 16}},
+		"scrypt p":     {KDFScrypt, map[string]interface{}{"p": 1 << 20}},
+		"pbkdf2 c":     {KDFPBKDF2, map[string]interface{}{"c": 1 << 30}},
+		"pbkdf2 dklen": {KDFPBKDF2, map[string]interface{}{"dklen": 1 << 20}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			raw := scryptKeystore
+			if tt.function == KDFPBKDF2 {
+				raw = pbkdf2Keystore
+			}
+			var ks Keystore
+			require.NoError(t, json.Unmarshal([]byte(raw), &ks))
+			for key, value := range tt.params {
+				ks.Crypto.KDF.Params[key] = value
+			}
+
+			_, err := Decrypt(&ks, testPassword)
+			assert.ErrorIs(t, err, ErrKDFParams)
+		})
+	}
+}
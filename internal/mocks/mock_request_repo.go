@@ -13,6 +13,64 @@ type RequestRepo struct {
 	mock.Mock
 }
 
+// ClaimJob provides a mock function with given fields: stage
+func (_m *RequestRepo) ClaimJob(stage models.JobStage) (*models.Job, error) {
+	ret := _m.Called(stage)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimJob")
+	}
+
+	var r0 *models.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.JobStage) (*models.Job, error)); ok {
+		return rf(stage)
+	}
+	if rf, ok := ret.Get(0).(func(models.JobStage) *models.Job); ok {
+		r0 = rf(stage)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.Job)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(models.JobStage) error); ok {
+		r1 = rf(stage)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountJobs provides a mock function with given fields: stage, status
+func (_m *RequestRepo) CountJobs(stage models.JobStage, status models.JobStatus) (int, error) {
+	ret := _m.Called(stage, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountJobs")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.JobStage, models.JobStatus) (int, error)); ok {
+		return rf(stage, status)
+	}
+	if rf, ok := ret.Get(0).(func(models.JobStage, models.JobStatus) int); ok {
+		r0 = rf(stage, status)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(models.JobStage, models.JobStatus) error); ok {
+		r1 = rf(stage, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateRequest provides a mock function with given fields: request
 func (_m *RequestRepo) CreateRequest(request *models.ValidatorRequest) error {
 	ret := _m.Called(request)
@@ -31,29 +89,57 @@ func (_m *RequestRepo) CreateRequest(request *models.ValidatorRequest) error {
 	return r0
 }
 
-// GetKeysByRequestID provides a mock function with given fields: requestID
-func (_m *RequestRepo) GetKeysByRequestID(requestID string) ([]string, error) {
-	ret := _m.Called(requestID)
+// EnqueueUnfinishedRequests provides a mock function with no fields
+func (_m *RequestRepo) EnqueueUnfinishedRequests() (int64, error) {
+	ret := _m.Called()
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetKeysByRequestID")
+		panic("no return value specified for EnqueueUnfinishedRequests")
 	}
 
-	var r0 []string
+	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]string, error)); ok {
-		return rf(requestID)
+	if rf, ok := ret.Get(0).(func() (int64, error)); ok {
+		return rf()
 	}
-	if rf, ok := ret.Get(0).(func(string) []string); ok {
-		r0 = rf(requestID)
+	if rf, ok := ret.Get(0).(func() int64); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetJobsByStatus provides a mock function with given fields: status
+func (_m *RequestRepo) GetJobsByStatus(status models.JobStatus) ([]*models.Job, error) {
+	ret := _m.Called(status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetJobsByStatus")
+	}
+
+	var r0 []*models.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(models.JobStatus) ([]*models.Job, error)); ok {
+		return rf(status)
+	}
+	if rf, ok := ret.Get(0).(func(models.JobStatus) []*models.Job); ok {
+		r0 = rf(status)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]string)
+			r0 = ret.Get(0).([]*models.Job)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(requestID)
+	if rf, ok := ret.Get(1).(func(models.JobStatus) error); ok {
+		r1 = rf(status)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -91,6 +177,82 @@ func (_m *RequestRepo) GetRequestByID(id string) (*models.ValidatorRequest, erro
 	return r0, r1
 }
 
+// GetValidatorKeysByRequestID provides a mock function with given fields: requestID
+func (_m *RequestRepo) GetValidatorKeysByRequestID(requestID string) ([]*models.ValidatorKey, error) {
+	ret := _m.Called(requestID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetValidatorKeysByRequestID")
+	}
+
+	var r0 []*models.ValidatorKey
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) ([]*models.ValidatorKey, error)); ok {
+		return rf(requestID)
+	}
+	if rf, ok := ret.Get(0).(func(string) []*models.ValidatorKey); ok {
+		r0 = rf(requestID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.ValidatorKey)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(requestID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NextKeyIndex provides a mock function with no fields
+func (_m *RequestRepo) NextKeyIndex() (int, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for NextKeyIndex")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (int, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RequeueJob provides a mock function with given fields: requestID, stage
+func (_m *RequestRepo) RequeueJob(requestID string, stage models.JobStage) error {
+	ret := _m.Called(requestID, stage)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RequeueJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, models.JobStage) error); ok {
+		r0 = rf(requestID, stage)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SaveValidatorKey provides a mock function with given fields: key
 func (_m *RequestRepo) SaveValidatorKey(key *models.ValidatorKey) error {
 	ret := _m.Called(key)
@@ -109,6 +271,42 @@ func (_m *RequestRepo) SaveValidatorKey(key *models.ValidatorKey) error {
 	return r0
 }
 
+// UpdateJobStatus provides a mock function with given fields: requestID, status, errorMessage
+func (_m *RequestRepo) UpdateJobStatus(requestID string, status models.JobStatus, errorMessage string) error {
+	ret := _m.Called(requestID, status, errorMessage)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateJobStatus")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, models.JobStatus, string) error); ok {
+		r0 = rf(requestID, status, errorMessage)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateKeyDeposit provides a mock function with given fields: keyID, deposit
+func (_m *RequestRepo) UpdateKeyDeposit(keyID string, deposit *models.KeyDeposit) error {
+	ret := _m.Called(keyID, deposit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateKeyDeposit")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, *models.KeyDeposit) error); ok {
+		r0 = rf(keyID, deposit)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateRequestStatus provides a mock function with given fields: id, status, errorMessage
 func (_m *RequestRepo) UpdateRequestStatus(id string, status models.Status, errorMessage string) error {
 	ret := _m.Called(id, status, errorMessage)
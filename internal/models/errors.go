@@ -0,0 +1,14 @@
+package models
+
+import "errors"
+
+var (
+	ErrRequestNotFound = errors.New("request not found")
+	ErrKeysNotReady    = errors.New("validator keys are not ready")
+	ErrKeyNotFound     = errors.New("validator key not found")
+	ErrNoFeeRecipient  = errors.New("no fee recipient set for validator key")
+	ErrInvalidInput    = errors.New("invalid input")
+	ErrSlashable       = errors.New("refused by slashing protection")
+	ErrBeaconNode      = errors.New("beacon node request failed")
+	ErrQueueFull       = errors.New("too many validator requests in progress")
+)
@@ -0,0 +1,29 @@
+package models
+
+type JobStatus string
+
+const (
+	JobStatusPending JobStatus = "pending"
+	JobStatusRunning JobStatus = "running"
+	JobStatusDone    JobStatus = "done"
+	JobStatusFailed  JobStatus = "failed"
+)
+
+// JobStage is the part of a request's processing a job is waiting for. Each stage has its own workers.
+type JobStage string
+
+const (
+	JobStageKeys     JobStage = "keys"
+	JobStageDeposits JobStage = "deposits"
+)
+
+// Job is the stored work item of a validator request: generating its keys, then submitting its deposits
+// if it asks for them. A request has at most one job.
+type Job struct {
+	RequestID string
+	Stage     JobStage
+	Status    JobStatus
+	// Attempts counts how often the job was claimed, including claims cut short by a restart.
+	Attempts int
+	Error    string
+}
@@ -0,0 +1,56 @@
+package models
+
+// Statuses reported per key by the Keymanager API.
+const (
+	KeyStatusImported  = "imported"
+	KeyStatusDuplicate = "duplicate"
+	KeyStatusDeleted   = "deleted"
+	KeyStatusNotActive = "not_active"
+	KeyStatusNotFound  = "not_found"
+	KeyStatusError     = "error"
+)
+
+type KeystoreInfo struct {
+	ValidatingPubkey string `json:"validating_pubkey"`
+	DerivationPath   string `json:"derivation_path,omitempty"`
+	Readonly         bool   `json:"readonly"`
+}
+
+type ImportKeystoresInput struct {
+	// Keystores are EIP-2335 keystores, each encoded as a JSON string.
+	Keystores []string `json:"keystores"`
+	Passwords []string `json:"passwords"`
+	// SlashingProtection is EIP-3076 interchange JSON.
+	SlashingProtection string `json:"slashing_protection,omitempty"`
+}
+
+type DeleteKeysInput struct {
+	Pubkeys []string `json:"pubkeys"`
+}
+
+type KeyStatus struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type DeleteKeystoresResponse struct {
+	Data []KeyStatus `json:"data"`
+	// SlashingProtection is EIP-3076 interchange JSON covering the deleted keys.
+	SlashingProtection string `json:"slashing_protection"`
+}
+
+// RemoteKey is a validator key held by a remote signer the validator client should use.
+type RemoteKey struct {
+	Pubkey   string `json:"pubkey"`
+	URL      string `json:"url"`
+	Readonly bool   `json:"readonly"`
+}
+
+type ImportRemoteKeysInput struct {
+	RemoteKeys []RemoteKey `json:"remote_keys"`
+}
+
+type FeeRecipient struct {
+	Pubkey     string `json:"pubkey"`
+	EthAddress string `json:"ethaddress"`
+}
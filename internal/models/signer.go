@@ -0,0 +1,133 @@
+package models
+
+import (
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// SignType is the kind of message a validator client asks the Web3Signer API to sign.
+type SignType string
+
+const (
+	SignTypeBlockV2                           SignType = "BLOCK_V2"
+	SignTypeAttestation                       SignType = "ATTESTATION"
+	SignTypeAggregationSlot                   SignType = "AGGREGATION_SLOT"
+	SignTypeAggregateAndProof                 SignType = "AGGREGATE_AND_PROOF"
+	SignTypeAggregateAndProofV2               SignType = "AGGREGATE_AND_PROOF_V2"
+	SignTypeRandaoReveal                      SignType = "RANDAO_REVEAL"
+	SignTypeSyncCommitteeMessage              SignType = "SYNC_COMMITTEE_MESSAGE"
+	SignTypeSyncCommitteeSelectionProof       SignType = "SYNC_COMMITTEE_SELECTION_PROOF"
+	SignTypeSyncCommitteeContributionAndProof SignType = "SYNC_COMMITTEE_CONTRIBUTION_AND_PROOF"
+)
+
+// SignRequest is the body of POST /api/v1/eth2/sign/{identifier}. Only the field that matches Type is set.
+// AggregateAndProof is kept raw because its shape depends on the type: AGGREGATE_AND_PROOF_V2 wraps
+// the message in a versioned envelope.
+type SignRequest struct {
+	Type                        SignType                     `json:"type"`
+	ForkInfo                    *ForkInfo                    `json:"fork_info"`
+	SigningRoot                 *common.Hash                 `json:"signingRoot,omitempty"`
+	BeaconBlock                 *BeaconBlock                 `json:"beacon_block,omitempty"`
+	Attestation                 *AttestationData             `json:"attestation,omitempty"`
+	AggregationSlot             *AggregationSlot             `json:"aggregation_slot,omitempty"`
+	AggregateAndProof           json.RawMessage              `json:"aggregate_and_proof,omitempty"`
+	RandaoReveal                *RandaoReveal                `json:"randao_reveal,omitempty"`
+	SyncCommitteeMessage        *SyncCommitteeMessage        `json:"sync_committee_message,omitempty"`
+	SyncAggregatorSelectionData *SyncAggregatorSelectionData `json:"sync_aggregator_selection_data,omitempty"`
+	ContributionAndProof        *ContributionAndProof        `json:"contribution_and_proof,omitempty"`
+}
+
+type SignResponse struct {
+	Signature string `json:"signature"`
+}
+
+type ForkInfo struct {
+	Fork                  Fork        `json:"fork"`
+	GenesisValidatorsRoot common.Hash `json:"genesis_validators_root"`
+}
+
+type Fork struct {
+	PreviousVersion hexutil.Bytes `json:"previous_version"`
+	CurrentVersion  hexutil.Bytes `json:"current_version"`
+	Epoch           uint64        `json:"epoch,string"`
+}
+
+// BeaconBlock only accepts the block header, which is what clients send for BLOCK_V2 since Bellatrix.
+type BeaconBlock struct {
+	Version     string             `json:"version"`
+	BlockHeader *BeaconBlockHeader `json:"block_header"`
+}
+
+type BeaconBlockHeader struct {
+	Slot          uint64      `json:"slot,string"`
+	ProposerIndex uint64      `json:"proposer_index,string"`
+	ParentRoot    common.Hash `json:"parent_root"`
+	StateRoot     common.Hash `json:"state_root"`
+	BodyRoot      common.Hash `json:"body_root"`
+}
+
+type Checkpoint struct {
+	Epoch uint64      `json:"epoch,string"`
+	Root  common.Hash `json:"root"`
+}
+
+type AttestationData struct {
+	Slot            uint64      `json:"slot,string"`
+	Index           uint64      `json:"index,string"`
+	BeaconBlockRoot common.Hash `json:"beacon_block_root"`
+	Source          Checkpoint  `json:"source"`
+	Target          Checkpoint  `json:"target"`
+}
+
+type Attestation struct {
+	AggregationBits hexutil.Bytes   `json:"aggregation_bits"`
+	Data            AttestationData `json:"data"`
+	Signature       hexutil.Bytes   `json:"signature"`
+	// CommitteeBits is only present in Electra attestations.
+	CommitteeBits hexutil.Bytes `json:"committee_bits,omitempty"`
+}
+
+type AggregateAndProof struct {
+	AggregatorIndex uint64        `json:"aggregator_index,string"`
+	Aggregate       Attestation   `json:"aggregate"`
+	SelectionProof  hexutil.Bytes `json:"selection_proof"`
+}
+
+// VersionedAggregateAndProof is the AGGREGATE_AND_PROOF_V2 envelope.
+type VersionedAggregateAndProof struct {
+	Version string            `json:"version"`
+	Data    AggregateAndProof `json:"data"`
+}
+
+type AggregationSlot struct {
+	Slot uint64 `json:"slot,string"`
+}
+
+type RandaoReveal struct {
+	Epoch uint64 `json:"epoch,string"`
+}
+
+type SyncCommitteeMessage struct {
+	BeaconBlockRoot common.Hash `json:"beacon_block_root"`
+	Slot            uint64      `json:"slot,string"`
+}
+
+type SyncAggregatorSelectionData struct {
+	Slot              uint64 `json:"slot,string"`
+	SubcommitteeIndex uint64 `json:"subcommittee_index,string"`
+}
+
+type SyncCommitteeContribution struct {
+	Slot              uint64        `json:"slot,string"`
+	BeaconBlockRoot   common.Hash   `json:"beacon_block_root"`
+	SubcommitteeIndex uint64        `json:"subcommittee_index,string"`
+	AggregationBits   hexutil.Bytes `json:"aggregation_bits"`
+	Signature         hexutil.Bytes `json:"signature"`
+}
+
+type ContributionAndProof struct {
+	AggregatorIndex uint64                    `json:"aggregator_index,string"`
+	Contribution    SyncCommitteeContribution `json:"contribution"`
+	SelectionProof  hexutil.Bytes             `json:"selection_proof"`
+}
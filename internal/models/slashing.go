@@ -0,0 +1,30 @@
+package models
+
+type SignedBlock struct {
+	Slot uint64 `json:"slot,string"`
+	// SigningRoot is empty when the history was imported without it.
+	SigningRoot string `json:"signing_root,omitempty"`
+}
+
+type SignedAttestation struct {
+	SourceEpoch uint64 `json:"source_epoch,string"`
+	TargetEpoch uint64 `json:"target_epoch,string"`
+	SigningRoot string `json:"signing_root,omitempty"`
+}
+
+// Interchange is the EIP-3076 slashing protection interchange format.
+type Interchange struct {
+	Metadata InterchangeMetadata `json:"metadata"`
+	Data     []InterchangeData   `json:"data"`
+}
+
+type InterchangeMetadata struct {
+	InterchangeFormatVersion string `json:"interchange_format_version"`
+	GenesisValidatorsRoot    string `json:"genesis_validators_root"`
+}
+
+type InterchangeData struct {
+	Pubkey             string              `json:"pubkey"`
+	SignedBlocks       []SignedBlock       `json:"signed_blocks"`
+	SignedAttestations []SignedAttestation `json:"signed_attestations"`
+}
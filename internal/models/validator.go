@@ -10,28 +10,97 @@ const (
 	StatusStarted    Status = "started"
 	StatusSuccessful Status = "successful"
 	StatusFailed     Status = "failed"
+
+	// Requests with Deposit set go on from key generation to submitting their deposits.
+	StatusDepositing    Status = "depositing"
+	StatusDeposited     Status = "deposited"
+	StatusDepositFailed Status = "deposit_failed"
 )
 
+// KeysReady reports whether all keys of a request in this status have been generated.
+func (s Status) KeysReady() bool {
+	switch s {
+	case StatusSuccessful, StatusDepositing, StatusDeposited, StatusDepositFailed:
+		return true
+	}
+	return false
+}
+
 type ValidatorRequest struct {
-	ID            string    `json:"request_id"`
-	NumValidators int       `json:"num_validators"`
-	FeeRecipient  string    `json:"fee_recipient"`
-	Status        Status    `json:"status"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
-	ErrorMessage  string    `json:"error_message,omitempty"`
+	ID                    string    `json:"request_id"`
+	NumValidators         int       `json:"num_validators"`
+	FeeRecipient          string    `json:"fee_recipient"`
+	WithdrawalCredentials string    `json:"withdrawal_credentials,omitempty"`
+	Amount                uint64    `json:"amount"`
+	Deposit               bool      `json:"deposit"`
+	StartIndex            int       `json:"-"`
+	Status                Status    `json:"status"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+	ErrorMessage          string    `json:"error_message,omitempty"`
 }
 
 type ValidatorKey struct {
 	ID           string `json:"id"`
 	RequestID    string `json:"request_id"`
 	Key          string `json:"key"`
+	KeyIndex     int    `json:"key_index"`
+	SecretKey    string `json:"-"`
 	FeeRecipient string `json:"fee_recipient"`
+	// WithdrawalKey is the secret key at the EIP-2334 withdrawal path of KeyIndex, whose public key the
+	// default 0x00 withdrawal credentials commit to. It is only stored for keys generated by the service.
+	WithdrawalKey string `json:"-"`
+	// DerivationPath is only stored for imported keys, generated keys use the EIP-2334 path of KeyIndex.
+	DerivationPath string `json:"derivation_path,omitempty"`
+	// Deleted keys were removed through the Keymanager API and are no longer served to validator clients.
+	Deleted bool `json:"deleted,omitempty"`
+	// Deposit is set once the service has signed a deposit transaction for the key.
+	Deposit *KeyDeposit `json:"deposit,omitempty"`
+	// Beacon is set once the beacon node knows the validator.
+	Beacon *BeaconState `json:"beacon,omitempty"`
+}
+
+// KeyDeposit tracks the deposit transaction the service sent for a validator key. The signed transaction
+// is stored before it is broadcast, so an interrupted deposit is resumed with the very same transaction.
+type KeyDeposit struct {
+	Status      string `json:"status"`
+	TxHash      string `json:"tx_hash"`
+	Nonce       uint64 `json:"-"`
+	RawTx       string `json:"-"`
+	BlockNumber uint64 `json:"block_number,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// BeaconState is a validator as last seen by the beacon node.
+type BeaconState struct {
+	Status string `json:"status"`
+	Index  uint64 `json:"index"`
+	// Balance is in gwei.
+	Balance   uint64    `json:"balance"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// Withdrawal credential types of ValidatorRequestInput, named after their prefix.
+const (
+	WithdrawalTypeBLS         = "0x00"
+	WithdrawalTypeExecution   = "0x01"
+	WithdrawalTypeCompounding = "0x02"
+)
+
 type ValidatorRequestInput struct {
 	NumValidators int    `json:"num_validators"`
 	FeeRecipient  string `json:"fee_recipient"`
+	// WithdrawalType selects the withdrawal credentials of the keys. It defaults to 0x01 when a
+	// WithdrawalAddress is given, and to BLS (0x00) credentials of each key's EIP-2334 withdrawal key otherwise.
+	WithdrawalType string `json:"withdrawal_type,omitempty"`
+	// WithdrawalAddress receives the withdrawals of 0x01 and 0x02 credentials.
+	WithdrawalAddress string `json:"withdrawal_address,omitempty"`
+	// WithdrawalCredentials sets raw credentials instead of WithdrawalType and WithdrawalAddress.
+	WithdrawalCredentials string `json:"withdrawal_credentials,omitempty"`
+	// Amount is the deposit amount in gwei, 32 ETH by default. Compounding validators take up to 2048 ETH.
+	Amount uint64 `json:"amount,omitempty"`
+	// Deposit asks the service to submit the deposits from its funding wallet once the keys are generated.
+	Deposit bool `json:"deposit,omitempty"`
 }
 
 type ValidatorRequestResponse struct {
@@ -40,7 +109,69 @@ type ValidatorRequestResponse struct {
 }
 
 type ValidatorStatusResponse struct {
-	Status  Status   `json:"status"`
-	Keys    []string `json:"keys,omitempty"`
-	Message string   `json:"message,omitempty"`
+	Status   Status              `json:"status"`
+	Keys     []string            `json:"keys,omitempty"`
+	Deposits []*ValidatorDeposit `json:"deposits,omitempty"`
+	Beacon   []*ValidatorBeacon  `json:"beacon,omitempty"`
+	Message  string              `json:"message,omitempty"`
+}
+
+type ValidatorDeposit struct {
+	Key string `json:"key"`
+	KeyDeposit
+}
+
+type ValidatorBeacon struct {
+	Key string `json:"key"`
+	BeaconState
+}
+
+// DepositData is a deposit_data.json entry as produced by the staking deposit CLI.
+type DepositData struct {
+	Pubkey                string `json:"pubkey"`
+	WithdrawalCredentials string `json:"withdrawal_credentials"`
+	Amount                uint64 `json:"amount"`
+	Signature             string `json:"signature"`
+	DepositMessageRoot    string `json:"deposit_message_root"`
+	DepositDataRoot       string `json:"deposit_data_root"`
+	ForkVersion           string `json:"fork_version"`
+	NetworkName           string `json:"network_name"`
+}
+
+type VoluntaryExitInput struct {
+	// Epoch is the earliest epoch the exit can be included in.
+	Epoch *uint64 `json:"epoch"`
+	// ValidatorIndex is looked up on the beacon node when not given and not yet tracked.
+	ValidatorIndex *uint64 `json:"validator_index,omitempty"`
+	// Broadcast submits the signed exit to the beacon node, which starts the exit right away.
+	Broadcast bool `json:"broadcast,omitempty"`
+}
+
+// SignedVoluntaryExit is in the Beacon Node API format, as accepted by /eth/v1/beacon/pool/voluntary_exits.
+type SignedVoluntaryExit struct {
+	Message   VoluntaryExit `json:"message"`
+	Signature string        `json:"signature"`
+}
+
+type VoluntaryExit struct {
+	Epoch          uint64 `json:"epoch,string"`
+	ValidatorIndex uint64 `json:"validator_index,string"`
+}
+
+type BLSToExecutionChangeInput struct {
+	// ToExecutionAddress receives the validators' withdrawals once the change is processed.
+	ToExecutionAddress string `json:"to_execution_address"`
+}
+
+// SignedBLSToExecutionChange is in the Beacon Node API format. A list of them is accepted by
+// /eth/v1/beacon/pool/bls_to_execution_changes.
+type SignedBLSToExecutionChange struct {
+	Message   BLSToExecutionChange `json:"message"`
+	Signature string               `json:"signature"`
+}
+
+type BLSToExecutionChange struct {
+	ValidatorIndex     uint64 `json:"validator_index,string"`
+	FromBLSPubkey      string `json:"from_bls_pubkey"`
+	ToExecutionAddress string `json:"to_execution_address"`
 }
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"stakeway_test_task/internal/models"
+	"time"
+)
+
+// ClaimJob marks the oldest pending job of stage running and returns it, or nil if no such job is pending.
+// The claim is a single statement, so concurrent workers never claim the same job.
+func (r *ValidatorRepository) ClaimJob(stage models.JobStage) (*models.Job, error) {
+	job := models.Job{Stage: stage, Status: models.JobStatusRunning}
+	err := r.db.QueryRow(`
+		UPDATE jobs SET status = ?, attempts = attempts + 1, updated_at = ?
+		WHERE request_id = (SELECT request_id FROM jobs WHERE stage = ? AND status = ? ORDER BY created_at LIMIT 1)
+		RETURNING request_id, attempts, error
+	`, models.JobStatusRunning, time.Now(), stage, models.JobStatusPending).Scan(&job.RequestID, &job.Attempts, &job.Error)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *ValidatorRepository) UpdateJobStatus(requestID string, status models.JobStatus, errorMessage string) error {
+	_, err := r.db.Exec(
+		"UPDATE jobs SET status = ?, error = ?, updated_at = ? WHERE request_id = ?",
+		status, errorMessage, time.Now(), requestID,
+	)
+	return err
+}
+
+// RequeueJob makes a job pending again for stage. Its attempts start over, since they count the
+// interruptions of a single stage.
+func (r *ValidatorRepository) RequeueJob(requestID string, stage models.JobStage) error {
+	_, err := r.db.Exec(
+		"UPDATE jobs SET stage = ?, status = ?, attempts = 0, error = '', updated_at = ? WHERE request_id = ?",
+		stage, models.JobStatusPending, time.Now(), requestID,
+	)
+	return err
+}
+
+func (r *ValidatorRepository) GetJobsByStatus(status models.JobStatus) ([]*models.Job, error) {
+	rows, err := r.db.Query("SELECT request_id, stage, status, attempts, error FROM jobs WHERE status = ? ORDER BY created_at", status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*models.Job
+	for rows.Next() {
+		var job models.Job
+		if err := rows.Scan(&job.RequestID, &job.Stage, &job.Status, &job.Attempts, &job.Error); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, &job)
+	}
+	return jobs, rows.Err()
+}
+
+func (r *ValidatorRepository) CountJobs(stage models.JobStage, status models.JobStatus) (int, error) {
+	var count int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM jobs WHERE stage = ? AND status = ?", stage, status).Scan(&count)
+	return count, err
+}
+
+// EnqueueUnfinishedRequests creates pending jobs for requests that are still being processed but have no
+// job, which is the case for requests created before jobs were stored. It returns the number of jobs created.
+func (r *ValidatorRepository) EnqueueUnfinishedRequests() (int64, error) {
+	result, err := r.db.Exec(`
+		INSERT OR IGNORE INTO jobs (request_id, stage, status, attempts, error, created_at, updated_at)
+		SELECT id, CASE WHEN status = ? THEN ? ELSE ? END, ?, 0, '', created_at, ?
+		FROM validator_requests WHERE status IN (?, ?)
+	`, models.StatusDepositing, models.JobStageDeposits, models.JobStageKeys, models.JobStatusPending, time.Now(),
+		models.StatusStarted, models.StatusDepositing)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"stakeway_test_task/internal/models"
+)
+
+// GetActiveValidatorKeys returns every key held by the service, generated or imported, that was not deleted.
+func (r *ValidatorRepository) GetActiveValidatorKeys() ([]*models.ValidatorKey, error) {
+	rows, err := r.db.Query("SELECT " + validatorKeyColumns + " FROM validator_keys WHERE COALESCE(deleted, 0) = 0 ORDER BY request_id, key_index")
+	if err != nil {
+		return nil, err
+	}
+	return scanValidatorKeys(rows)
+}
+
+// GetValidatorKeyByPubkey looks a key up by its 0x-prefixed lowercase pubkey, including deleted keys.
+func (r *ValidatorRepository) GetValidatorKeyByPubkey(pubkey string) (*models.ValidatorKey, error) {
+	key, err := scanValidatorKey(r.db.QueryRow("SELECT "+validatorKeyColumns+" FROM validator_keys WHERE key = ?", pubkey))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrKeyNotFound
+	}
+	return key, err
+}
+
+func (r *ValidatorRepository) SetKeyDeleted(keyID string, deleted bool) error {
+	_, err := r.db.Exec("UPDATE validator_keys SET deleted = ? WHERE id = ?", deleted, keyID)
+	return err
+}
+
+func (r *ValidatorRepository) UpdateKeyFeeRecipient(keyID, feeRecipient string) error {
+	_, err := r.db.Exec("UPDATE validator_keys SET fee_recipient = ? WHERE id = ?", feeRecipient, keyID)
+	return err
+}
+
+func (r *ValidatorRepository) GetRemoteKeys() ([]*models.RemoteKey, error) {
+	rows, err := r.db.Query("SELECT pubkey, url FROM remote_keys ORDER BY pubkey")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*models.RemoteKey
+	for rows.Next() {
+		var key models.RemoteKey
+		if err := rows.Scan(&key.Pubkey, &key.URL); err != nil {
+			return nil, err
+		}
+		keys = append(keys, &key)
+	}
+	return keys, rows.Err()
+}
+
+func (r *ValidatorRepository) SaveRemoteKey(key *models.RemoteKey) error {
+	_, err := r.db.Exec("INSERT INTO remote_keys (pubkey, url) VALUES (?, ?)", key.Pubkey, key.URL)
+	return err
+}
+
+// DeleteRemoteKey reports whether the key existed.
+func (r *ValidatorRepository) DeleteRemoteKey(pubkey string) (bool, error) {
+	result, err := r.db.Exec("DELETE FROM remote_keys WHERE pubkey = ?", pubkey)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := result.RowsAffected()
+	return deleted > 0, err
+}
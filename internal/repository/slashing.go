@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"stakeway_test_task/internal/models"
+)
+
+// GetBlockWatermark returns the block with the highest slot signed by pubkey, or nil if it signed none.
+func (r *ValidatorRepository) GetBlockWatermark(pubkey string) (*models.SignedBlock, error) {
+	var block models.SignedBlock
+	err := r.db.QueryRow("SELECT slot, signing_root FROM signed_blocks WHERE pubkey = ? ORDER BY slot DESC LIMIT 1", pubkey).
+		Scan(&block.Slot, &block.SigningRoot)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return &block, err
+}
+
+// GetAttestationWatermark returns the highest source epoch and the highest target epoch signed by pubkey,
+// with the signing root of the attestation for that target, or nil if it signed no attestation.
+func (r *ValidatorRepository) GetAttestationWatermark(pubkey string) (*models.SignedAttestation, error) {
+	var attestation models.SignedAttestation
+	err := r.db.QueryRow(`
+		SELECT (SELECT MAX(source_epoch) FROM signed_attestations WHERE pubkey = ?), target_epoch, signing_root
+		FROM signed_attestations WHERE pubkey = ? ORDER BY target_epoch DESC LIMIT 1
+	`, pubkey, pubkey).Scan(&attestation.SourceEpoch, &attestation.TargetEpoch, &attestation.SigningRoot)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return &attestation, err
+}
+
+func (r *ValidatorRepository) SaveSignedBlock(pubkey string, block *models.SignedBlock) error {
+	return saveSignedBlock(r.db, pubkey, block)
+}
+
+func (r *ValidatorRepository) SaveSignedAttestation(pubkey string, attestation *models.SignedAttestation) error {
+	return saveSignedAttestation(r.db, pubkey, attestation)
+}
+
+// GetSlashingProtection returns the signing history of pubkeys, or of every pubkey with history when
+// pubkeys is nil. Pubkeys without history are included with empty lists.
+func (r *ValidatorRepository) GetSlashingProtection(pubkeys []string) ([]models.InterchangeData, error) {
+	if pubkeys == nil {
+		var err error
+		pubkeys, err = r.slashingProtectionPubkeys()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	data := make([]models.InterchangeData, 0, len(pubkeys))
+	for _, pubkey := range pubkeys {
+		record := models.InterchangeData{
+			Pubkey:             pubkey,
+			SignedBlocks:       []models.SignedBlock{},
+			SignedAttestations: []models.SignedAttestation{},
+		}
+
+		rows, err := r.db.Query("SELECT slot, signing_root FROM signed_blocks WHERE pubkey = ? ORDER BY slot", pubkey)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var block models.SignedBlock
+			if err := rows.Scan(&block.Slot, &block.SigningRoot); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			record.SignedBlocks = append(record.SignedBlocks, block)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		rows, err = r.db.Query("SELECT source_epoch, target_epoch, signing_root FROM signed_attestations WHERE pubkey = ? ORDER BY target_epoch", pubkey)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var attestation models.SignedAttestation
+			if err := rows.Scan(&attestation.SourceEpoch, &attestation.TargetEpoch, &attestation.SigningRoot); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			record.SignedAttestations = append(record.SignedAttestations, attestation)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+
+		data = append(data, record)
+	}
+	return data, nil
+}
+
+func (r *ValidatorRepository) slashingProtectionPubkeys() ([]string, error) {
+	rows, err := r.db.Query("SELECT pubkey FROM signed_blocks UNION SELECT pubkey FROM signed_attestations ORDER BY pubkey")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pubkeys := []string{}
+	for rows.Next() {
+		var pubkey string
+		if err := rows.Scan(&pubkey); err != nil {
+			return nil, err
+		}
+		pubkeys = append(pubkeys, pubkey)
+	}
+	return pubkeys, rows.Err()
+}
+
+// ImportSlashingProtection merges interchange data into the signing history in a single transaction.
+// Records for a slot or target epoch that is already known are skipped.
+func (r *ValidatorRepository) ImportSlashingProtection(data []models.InterchangeData) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, record := range data {
+		for _, block := range record.SignedBlocks {
+			if err := saveSignedBlock(tx, record.Pubkey, &block); err != nil {
+				return err
+			}
+		}
+		for _, attestation := range record.SignedAttestations {
+			if err := saveSignedAttestation(tx, record.Pubkey, &attestation); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func saveSignedBlock(db execer, pubkey string, block *models.SignedBlock) error {
+	_, err := db.Exec("INSERT OR IGNORE INTO signed_blocks (pubkey, slot, signing_root) VALUES (?, ?, ?)",
+		pubkey, block.Slot, block.SigningRoot)
+	return err
+}
+
+func saveSignedAttestation(db execer, pubkey string, attestation *models.SignedAttestation) error {
+	_, err := db.Exec("INSERT OR IGNORE INTO signed_attestations (pubkey, source_epoch, target_epoch, signing_root) VALUES (?, ?, ?, ?)",
+		pubkey, attestation.SourceEpoch, attestation.TargetEpoch, attestation.SigningRoot)
+	return err
+}
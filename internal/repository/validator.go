@@ -3,6 +3,7 @@ package repository
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	_ "github.com/mattn/go-sqlite3"
 	"stakeway_test_task/internal/models"
 	"time"
@@ -32,6 +33,9 @@ func createTables(db *sql.DB) error {
 			id TEXT PRIMARY KEY,
 			num_validators INTEGER,
 			fee_recipient TEXT,
+			withdrawal_credentials TEXT,
+			amount INTEGER,
+			start_index INTEGER,
 			status TEXT,
 			created_at TIMESTAMP,
 			updated_at TIMESTAMP,
@@ -47,30 +51,168 @@ func createTables(db *sql.DB) error {
 			id TEXT PRIMARY KEY,
 			request_id TEXT,
 			key TEXT,
+			key_index INTEGER,
+			secret_key TEXT,
+			withdrawal_key TEXT,
 			fee_recipient TEXT,
 			FOREIGN KEY (request_id) REFERENCES validator_requests (id)
 		)
 	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS remote_keys (
+			pubkey TEXT PRIMARY KEY,
+			url TEXT
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Slashing protection history is kept by pubkey, so that history imported for keys the service does
+	// not hold yet applies once they are imported.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS signed_blocks (
+			pubkey TEXT,
+			slot INTEGER,
+			signing_root TEXT,
+			PRIMARY KEY (pubkey, slot)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS signed_attestations (
+			pubkey TEXT,
+			source_epoch INTEGER,
+			target_epoch INTEGER,
+			signing_root TEXT,
+			PRIMARY KEY (pubkey, target_epoch)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Every request is processed by a job, so that requests interrupted by a restart are picked up again.
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS jobs (
+			request_id TEXT PRIMARY KEY,
+			stage TEXT NOT NULL DEFAULT 'keys',
+			status TEXT,
+			attempts INTEGER,
+			error TEXT,
+			created_at TIMESTAMP,
+			updated_at TIMESTAMP,
+			FOREIGN KEY (request_id) REFERENCES validator_requests (id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	migrations := []struct{ table, column, definition string }{
+		{"validator_requests", "withdrawal_credentials", "TEXT"},
+		{"validator_requests", "amount", "INTEGER"},
+		{"validator_requests", "start_index", "INTEGER"},
+		{"validator_keys", "key_index", "INTEGER"},
+		{"validator_keys", "secret_key", "TEXT"},
+		{"validator_requests", "deposit", "INTEGER"},
+		{"validator_keys", "deposit_status", "TEXT"},
+		{"validator_keys", "deposit_tx_hash", "TEXT"},
+		{"validator_keys", "deposit_nonce", "INTEGER"},
+		{"validator_keys", "deposit_raw_tx", "TEXT"},
+		{"validator_keys", "deposit_block", "INTEGER"},
+		{"validator_keys", "deposit_error", "TEXT"},
+		{"validator_keys", "beacon_status", "TEXT"},
+		{"validator_keys", "validator_index", "INTEGER"},
+		{"validator_keys", "balance", "INTEGER"},
+		{"validator_keys", "beacon_updated_at", "TIMESTAMP"},
+		{"validator_keys", "derivation_path", "TEXT"},
+		{"validator_keys", "deleted", "INTEGER"},
+		{"validator_keys", "withdrawal_key", "TEXT"},
+		{"jobs", "stage", "TEXT NOT NULL DEFAULT 'keys'"},
+	}
+	for _, m := range migrations {
+		if err := addColumnIfNotExists(db, m.table, m.column, m.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumnIfNotExists upgrades databases created before the column was introduced.
+func addColumnIfNotExists(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
 	return err
 }
 
+// CreateRequest stores a request together with the pending job that processes it.
 func (r *ValidatorRepository) CreateRequest(request *models.ValidatorRequest) error {
-	_, err := r.db.Exec(
-		"INSERT INTO validator_requests (id, num_validators, fee_recipient, status, created_at, updated_at, error_message) VALUES (?, ?, ?, ?, ?, ?, ?)",
-		request.ID, request.NumValidators, request.FeeRecipient, request.Status, time.Now(), time.Now(), request.ErrorMessage,
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(
+		"INSERT INTO validator_requests (id, num_validators, fee_recipient, withdrawal_credentials, amount, deposit, start_index, status, created_at, updated_at, error_message) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		request.ID, request.NumValidators, request.FeeRecipient, request.WithdrawalCredentials, request.Amount, request.Deposit, request.StartIndex, request.Status, time.Now(), time.Now(), request.ErrorMessage,
 	)
-	return err
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		"INSERT INTO jobs (request_id, stage, status, attempts, error, created_at, updated_at) VALUES (?, ?, ?, 0, '', ?, ?)",
+		request.ID, models.JobStageKeys, models.JobStatusPending, time.Now(), time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *ValidatorRepository) GetRequestByID(id string) (*models.ValidatorRequest, error) {
-	row := r.db.QueryRow("SELECT id, num_validators, fee_recipient, status, created_at, updated_at, error_message FROM validator_requests WHERE id = ?", id)
+	row := r.db.QueryRow("SELECT id, num_validators, fee_recipient, COALESCE(withdrawal_credentials, ''), COALESCE(amount, 0), COALESCE(deposit, 0), COALESCE(start_index, 0), status, created_at, updated_at, error_message FROM validator_requests WHERE id = ?", id)
 
 	var req models.ValidatorRequest
 	var status string
-	err := row.Scan(&req.ID, &req.NumValidators, &req.FeeRecipient, &status, &req.CreatedAt, &req.UpdatedAt, &req.ErrorMessage)
+	err := row.Scan(&req.ID, &req.NumValidators, &req.FeeRecipient, &req.WithdrawalCredentials, &req.Amount, &req.Deposit, &req.StartIndex, &status, &req.CreatedAt, &req.UpdatedAt, &req.ErrorMessage)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("request not found")
+			return nil, models.ErrRequestNotFound
 		}
 		return nil, err
 	}
@@ -79,6 +221,13 @@ func (r *ValidatorRepository) GetRequestByID(id string) (*models.ValidatorReques
 	return &req, nil
 }
 
+// NextKeyIndex returns the first EIP-2334 index not yet reserved by any request.
+func (r *ValidatorRepository) NextKeyIndex() (int, error) {
+	var next int
+	err := r.db.QueryRow("SELECT COALESCE(MAX(start_index + num_validators), 0) FROM validator_requests").Scan(&next)
+	return next, err
+}
+
 func (r *ValidatorRepository) UpdateRequestStatus(id string, status models.Status, errorMessage string) error {
 	_, err := r.db.Exec(
 		"UPDATE validator_requests SET status = ?, updated_at = ?, error_message = ? WHERE id = ?",
@@ -89,29 +238,99 @@ func (r *ValidatorRepository) UpdateRequestStatus(id string, status models.Statu
 
 func (r *ValidatorRepository) SaveValidatorKey(key *models.ValidatorKey) error {
 	_, err := r.db.Exec(
-		"INSERT INTO validator_keys (id, request_id, key, fee_recipient) VALUES (?, ?, ?, ?)",
-		key.ID, key.RequestID, key.Key, key.FeeRecipient,
+		"INSERT INTO validator_keys (id, request_id, key, key_index, secret_key, withdrawal_key, fee_recipient, derivation_path, deleted) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		key.ID, key.RequestID, key.Key, key.KeyIndex, key.SecretKey, key.WithdrawalKey, key.FeeRecipient, key.DerivationPath, key.Deleted,
 	)
 	return err
 }
 
-func (r *ValidatorRepository) GetKeysByRequestID(requestID string) ([]string, error) {
-	rows, err := r.db.Query("SELECT key FROM validator_keys WHERE request_id = ?", requestID)
+const validatorKeyColumns = `id, COALESCE(request_id, ''), key, COALESCE(key_index, 0), COALESCE(secret_key, ''), COALESCE(withdrawal_key, ''),
+	COALESCE(fee_recipient, ''), COALESCE(derivation_path, ''), COALESCE(deleted, 0),
+	deposit_status, COALESCE(deposit_tx_hash, ''), COALESCE(deposit_nonce, 0), COALESCE(deposit_raw_tx, ''),
+	COALESCE(deposit_block, 0), COALESCE(deposit_error, ''),
+	beacon_status, COALESCE(validator_index, 0), COALESCE(balance, 0), beacon_updated_at`
+
+func (r *ValidatorRepository) GetValidatorKeysByRequestID(requestID string) ([]*models.ValidatorKey, error) {
+	rows, err := r.db.Query("SELECT "+validatorKeyColumns+" FROM validator_keys WHERE request_id = ? ORDER BY key_index", requestID)
+	if err != nil {
+		return nil, err
+	}
+	return scanValidatorKeys(rows)
+}
+
+// GetTrackedValidatorKeys returns the keys of all requests whose validator can still change on the beacon
+// chain, that is every key not seen with status withdrawal_done yet.
+func (r *ValidatorRepository) GetTrackedValidatorKeys() ([]*models.ValidatorKey, error) {
+	rows, err := r.db.Query(
+		"SELECT "+validatorKeyColumns+" FROM validator_keys WHERE beacon_status IS NULL OR beacon_status != ? ORDER BY key_index",
+		"withdrawal_done",
+	)
 	if err != nil {
 		return nil, err
 	}
+	return scanValidatorKeys(rows)
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanValidatorKeys(rows *sql.Rows) ([]*models.ValidatorKey, error) {
 	defer rows.Close()
 
-	var keys []string
+	var keys []*models.ValidatorKey
 	for rows.Next() {
-		var key string
-		if err := rows.Scan(&key); err != nil {
+		key, err := scanValidatorKey(rows)
+		if err != nil {
 			return nil, err
 		}
 		keys = append(keys, key)
 	}
 
-	return keys, nil
+	return keys, rows.Err()
+}
+
+// scanValidatorKey reads a row selected with validatorKeyColumns.
+func scanValidatorKey(row scanner) (*models.ValidatorKey, error) {
+	var key models.ValidatorKey
+	var depositStatus, beaconStatus sql.NullString
+	var deposit models.KeyDeposit
+	var beacon models.BeaconState
+	var beaconUpdatedAt sql.NullTime
+	if err := row.Scan(&key.ID, &key.RequestID, &key.Key, &key.KeyIndex, &key.SecretKey, &key.WithdrawalKey,
+		&key.FeeRecipient, &key.DerivationPath, &key.Deleted,
+		&depositStatus, &deposit.TxHash, &deposit.Nonce, &deposit.RawTx, &deposit.BlockNumber, &deposit.Error,
+		&beaconStatus, &beacon.Index, &beacon.Balance, &beaconUpdatedAt); err != nil {
+		return nil, err
+	}
+	if depositStatus.Valid {
+		deposit.Status = depositStatus.String
+		key.Deposit = &deposit
+	}
+	if beaconStatus.Valid {
+		beacon.Status = beaconStatus.String
+		beacon.UpdatedAt = beaconUpdatedAt.Time
+		key.Beacon = &beacon
+	}
+	return &key, nil
+}
+
+// UpdateKeyDeposit stores the deposit transaction of a validator key.
+func (r *ValidatorRepository) UpdateKeyDeposit(keyID string, deposit *models.KeyDeposit) error {
+	_, err := r.db.Exec(
+		"UPDATE validator_keys SET deposit_status = ?, deposit_tx_hash = ?, deposit_nonce = ?, deposit_raw_tx = ?, deposit_block = ?, deposit_error = ? WHERE id = ?",
+		deposit.Status, deposit.TxHash, deposit.Nonce, deposit.RawTx, deposit.BlockNumber, deposit.Error, keyID,
+	)
+	return err
+}
+
+// UpdateKeyBeaconState stores the validator of a key as last seen by the beacon node.
+func (r *ValidatorRepository) UpdateKeyBeaconState(keyID string, state *models.BeaconState) error {
+	_, err := r.db.Exec(
+		"UPDATE validator_keys SET beacon_status = ?, validator_index = ?, balance = ?, beacon_updated_at = ? WHERE id = ?",
+		state.Status, state.Index, state.Balance, state.UpdatedAt, keyID,
+	)
+	return err
 }
 
 func (r *ValidatorRepository) CheckHealth() error {
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"stakeway_test_task/internal/beacon"
+	"stakeway_test_task/internal/models"
+	"strings"
+	"time"
+)
+
+type BeaconRepo interface {
+	GetTrackedValidatorKeys() ([]*models.ValidatorKey, error)
+	UpdateKeyBeaconState(keyID string, state *models.BeaconState) error
+}
+
+// BeaconPoller keeps the consensus layer status, index and balance of every generated key up to date.
+type BeaconPoller struct {
+	repo   BeaconRepo
+	client *beacon.Client
+	logger *slog.Logger
+}
+
+func NewBeaconPoller(repo BeaconRepo, client *beacon.Client, logger *slog.Logger) *BeaconPoller {
+	return &BeaconPoller{repo: repo, client: client, logger: logger}
+}
+
+// Run polls the beacon node every interval until ctx is cancelled.
+func (p *BeaconPoller) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.Poll(ctx); err != nil && ctx.Err() == nil {
+			p.logger.Error("Failed to poll beacon node", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll fetches the head state of every tracked key in one pass. Keys the beacon node does not know yet,
+// typically because their deposit is still being processed, are left untouched.
+func (p *BeaconPoller) Poll(ctx context.Context) error {
+	keys, err := p.repo.GetTrackedValidatorKeys()
+	if err != nil {
+		return fmt.Errorf("error loading validator keys: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	pubkeys := make([]string, len(keys))
+	for i, key := range keys {
+		pubkeys[i] = key.Key
+	}
+
+	validators, err := p.client.Validators(ctx, "head", pubkeys)
+	if err != nil {
+		return err
+	}
+
+	byPubkey := make(map[string]beacon.Validator, len(validators))
+	for _, validator := range validators {
+		byPubkey[strings.ToLower(validator.Validator.Pubkey)] = validator
+	}
+
+	now := time.Now()
+	for _, key := range keys {
+		validator, ok := byPubkey[strings.ToLower(key.Key)]
+		if !ok {
+			continue
+		}
+
+		state := &models.BeaconState{
+			Status:    validator.Status,
+			Index:     validator.Index,
+			Balance:   validator.Balance,
+			UpdatedAt: now,
+		}
+		if err := p.repo.UpdateKeyBeaconState(key.ID, state); err != nil {
+			return fmt.Errorf("error saving beacon state of %s: %w", key.Key, err)
+		}
+		if key.Beacon == nil || key.Beacon.Status != state.Status {
+			p.logger.Info("Validator status changed",
+				"key", key.Key,
+				"request_id", key.RequestID,
+				"index", state.Index,
+				"status", state.Status)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"stakeway_test_task/internal/beacon"
+	"stakeway_test_task/internal/mocks"
+	"stakeway_test_task/internal/models"
+	"strings"
+	"testing"
+)
+
+const (
+	activeKey  = "0x80df3c8cb942676d7ade40b42a7306ea6275a9da4c3b952efb1a6dd9a9b4f2d21cca7463b7ca3cfe0dd09b6c335d5c26"
+	pendingKey = "0xa99a76ed7796f7be22d5b7e85deeb7c5677e88e511e0b337618f8c4eb61349b4bf2d153f649f7b53359fe8b94a38e44c"
+	unknownKey = "0xb4c2eb3d6f6d8f1a5e0f96a7b5d7c5b7a2c8e5a3f5e9c7b2d4e6f8a0c2e4d6b8f0a2c4e6d8b0f2a4c6e8d0b2f4a6c8e0"
+)
+
+// stubBeacon serves the validators endpoint of a beacon node that knows activeKey and pendingKey.
+func stubBeacon(t *testing.T) *beacon.Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/eth/v1/beacon/states/head/validators", r.URL.Path)
+
+		var data []string
+		for _, id := range strings.Split(r.URL.Query().Get("id"), ",") {
+			switch id {
+			case activeKey:
+				data = append(data, `{"index":"1234","balance":"32001000000","status":"active_ongoing","validator":{"pubkey":"`+activeKey+`"}}`)
+			case pendingKey:
+				data = append(data, `{"index":"1300","balance":"32000000000","status":"pending_queued","validator":{"pubkey":"`+pendingKey+`"}}`)
+			}
+		}
+		w.Write([]byte(`{"execution_optimistic":false,"finalized":false,"data":[` + strings.Join(data, ",") + `]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return beacon.NewClient(server.URL)
+}
+
+func setupBeaconPollerTest(t *testing.T) (*mocks.BeaconRepo, *BeaconPoller) {
+	mockRepo := mocks.NewBeaconRepo(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	return mockRepo, NewBeaconPoller(mockRepo, stubBeacon(t), logger)
+}
+
+func TestBeaconPollerPoll(t *testing.T) {
+	t.Run("states are saved for known validators", func(t *testing.T) {
+		mockRepo, poller := setupBeaconPollerTest(t)
+
+		mockRepo.On("GetTrackedValidatorKeys").Return([]*models.ValidatorKey{
+			{ID: "active", Key: activeKey},
+			{ID: "pending", Key: pendingKey, Beacon: &models.BeaconState{Status: beacon.StatusPendingInitialized, Index: 1300}},
+			{ID: "unknown", Key: unknownKey},
+		}, nil)
+
+		states := make(map[string]*models.BeaconState)
+		mockRepo.On("UpdateKeyBeaconState", mock.Anything, mock.AnythingOfType("*models.BeaconState")).
+			Run(func(args mock.Arguments) {
+				states[args.String(0)] = args.Get(1).(*models.BeaconState)
+			}).
+			Return(nil)
+
+		require.NoError(t, poller.Poll(context.Background()))
+
+		require.Len(t, states, 2)
+		assert.Equal(t, beacon.StatusActiveOngoing, states["active"].Status)
+		assert.Equal(t, uint64(1234), states["active"].Index)
+		assert.Equal(t, uint64(32001000000), states["active"].Balance)
+		assert.False(t, states["active"].UpdatedAt.IsZero())
+		assert.Equal(t, beacon.StatusPendingQueued, states["pending"].Status)
+		assert.NotContains(t, states, "unknown")
+	})
+
+	t.Run("no tracked keys", func(t *testing.T) {
+		mockRepo, poller := setupBeaconPollerTest(t)
+
+		mockRepo.On("GetTrackedValidatorKeys").Return(nil, nil)
+
+		assert.NoError(t, poller.Poll(context.Background()))
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepo, poller := setupBeaconPollerTest(t)
+
+		mockRepo.On("GetTrackedValidatorKeys").Return([]*models.ValidatorKey{{ID: "active", Key: activeKey}}, nil)
+		mockRepo.On("UpdateKeyBeaconState", "active", mock.Anything).Return(errors.New("database error"))
+
+		assert.ErrorContains(t, poller.Poll(context.Background()), "database error")
+	})
+}
+
+func TestGetRequestStatusBeacon(t *testing.T) {
+	mockRepo, service := setupValidatorServiceTest(t)
+
+	request := &models.ValidatorRequest{ID: "request", NumValidators: 2, Status: models.StatusSuccessful}
+	beaconState := models.BeaconState{Status: beacon.StatusActiveOngoing, Index: 1234, Balance: 32001000000}
+	mockRepo.On("GetRequestByID", request.ID).Return(request, nil)
+	mockRepo.On("GetValidatorKeysByRequestID", request.ID).Return([]*models.ValidatorKey{
+		{Key: activeKey, Beacon: &beaconState},
+		{Key: unknownKey},
+	}, nil)
+
+	response, err := service.GetRequestStatus(request.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{activeKey, unknownKey}, response.Keys)
+	assert.Equal(t, []*models.ValidatorBeacon{{Key: activeKey, BeaconState: beaconState}}, response.Beacon)
+	assert.Empty(t, response.Deposits)
+}
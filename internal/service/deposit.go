@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stakeway_test_task/internal/deposit"
+	"stakeway_test_task/internal/models"
+	"sync"
+	"time"
+)
+
+// depositConcurrency is the number of deposits of a request that are broadcast and awaited in parallel.
+const depositConcurrency = 4
+
+// depositTimeout bounds a single attempt at the deposits of a request, so that a transaction that is not
+// mined does not keep the deposit worker waiting forever.
+const depositTimeout = 10 * time.Minute
+
+// DepositSubmitter sends deposit transactions from the service's funding wallet. It is implemented by
+// *deposit.Submitter.
+type DepositSubmitter interface {
+	SubmitAll(ctx context.Context, depositDataList []deposit.Data, store deposit.Store, concurrency int) ([]deposit.Result, error)
+}
+
+// processDeposits submits a deposit for every key of a request whose keys have been generated and waits
+// for their inclusion. Deposits already recorded on a key are resumed rather than sent again. It reports
+// whether the deposits were settled, that is confirmed or failed; deposits cut short by depositTimeout or
+// by ctx being cancelled leave the request depositing.
+func (s *ValidatorService) processDeposits(ctx context.Context, request *models.ValidatorRequest) bool {
+	requestID := request.ID
+
+	// A request resumed after a restart may find the funding wallet no longer configured.
+	if s.deposits == nil {
+		s.failDeposits(requestID, "Error submitting deposits", fmt.Errorf("deposit submission is not enabled on this server"))
+		return true
+	}
+
+	keys, err := s.repo.GetValidatorKeysByRequestID(requestID)
+	if err != nil {
+		s.failDeposits(requestID, "Error loading validator keys", err)
+		return true
+	}
+
+	depositData, err := s.depositData(request, keys)
+	if err != nil {
+		s.failDeposits(requestID, "Error signing deposit data", err)
+		return true
+	}
+
+	depositDataList := make([]deposit.Data, len(depositData))
+	store := &keyDepositStore{repo: s.repo, keys: make(map[string]*models.ValidatorKey, len(keys))}
+	for i, data := range depositData {
+		depositDataList[i] = deposit.Data(*data)
+		store.keys[data.DepositDataRoot] = keys[i]
+	}
+
+	s.logger.Info("Submitting deposits", "request_id", requestID, "num_deposits", len(depositDataList))
+
+	// Batches of different requests share the funding wallet's nonces. They do not overlap because a single
+	// job worker runs the deposit stage.
+	depositCtx, cancel := context.WithTimeout(ctx, depositTimeout)
+	results, err := s.deposits.SubmitAll(depositCtx, depositDataList, store, depositConcurrency)
+	cancel()
+
+	for _, result := range results {
+		s.logger.Info("Deposit processed",
+			"request_id", requestID,
+			"key", result.Pubkey,
+			"status", result.Status,
+			"tx_hash", result.TxHash,
+			"block_number", result.BlockNumber)
+	}
+	// Sent transactions are recorded on the keys, so deposits cut short are resumed by the next attempt.
+	if ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded) {
+		s.logger.Warn("Deposits not confirmed yet", "error", err, "request_id", requestID)
+		return false
+	}
+	if err != nil {
+		s.failDeposits(requestID, "Error submitting deposits", err)
+		return true
+	}
+
+	if err := s.repo.UpdateRequestStatus(requestID, models.StatusDeposited, ""); err != nil {
+		s.logger.Error("Failed to update request status", "error", err, "request_id", requestID)
+		return true
+	}
+	s.logger.Info("Deposits confirmed", "request_id", requestID)
+	return true
+}
+
+func (s *ValidatorService) failDeposits(requestID, message string, err error) {
+	s.logger.Error(message, "error", err, "request_id", requestID)
+
+	err = s.repo.UpdateRequestStatus(requestID, models.StatusDepositFailed, fmt.Sprintf("%s: %v", message, err))
+	if err != nil {
+		s.logger.Error("Failed to update request status", "error", err, "request_id", requestID)
+	}
+}
+
+// keyDepositStore keeps the deposit records of a request on its validator keys, so that the database
+// plays the role of the deposit tool's state file.
+type keyDepositStore struct {
+	repo RequestRepo
+
+	mu sync.Mutex
+	// keys maps deposit data roots to the key being deposited.
+	keys map[string]*models.ValidatorKey
+}
+
+func (s *keyDepositStore) Get(depositDataRoot string) (deposit.Record, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[depositDataRoot]
+	if !ok || key.Deposit == nil {
+		return deposit.Record{}, false
+	}
+	return deposit.Record{
+		Pubkey:      key.Key,
+		Nonce:       key.Deposit.Nonce,
+		TxHash:      key.Deposit.TxHash,
+		RawTx:       key.Deposit.RawTx,
+		Status:      key.Deposit.Status,
+		BlockNumber: key.Deposit.BlockNumber,
+		Error:       key.Deposit.Error,
+	}, true
+}
+
+func (s *keyDepositStore) Put(depositDataRoot string, record deposit.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.keys[depositDataRoot]
+	if !ok {
+		return fmt.Errorf("no validator key for deposit data root %s", depositDataRoot)
+	}
+
+	keyDeposit := &models.KeyDeposit{
+		Status:      record.Status,
+		TxHash:      record.TxHash,
+		Nonce:       record.Nonce,
+		RawTx:       record.RawTx,
+		BlockNumber: record.BlockNumber,
+		Error:       record.Error,
+	}
+	if err := s.repo.UpdateKeyDeposit(key.ID, keyDeposit); err != nil {
+		return err
+	}
+	key.Deposit = keyDeposit
+	return nil
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"stakeway_test_task/internal/bls"
+	"stakeway_test_task/internal/deposit"
+	"stakeway_test_task/internal/eth2"
+	"stakeway_test_task/internal/mocks"
+	"stakeway_test_task/internal/models"
+	"testing"
+)
+
+// fakeSubmitter confirms every deposit in block 100 and records it in the store the way deposit.Submitter does.
+type fakeSubmitter struct {
+	t       *testing.T
+	network *eth2.Network
+	err     error
+
+	submitted []deposit.Data
+	resumed   []deposit.Record
+}
+
+func (f *fakeSubmitter) SubmitAll(ctx context.Context, depositDataList []deposit.Data, store deposit.Store, _ int) ([]deposit.Result, error) {
+	f.submitted = depositDataList
+	_, bounded := ctx.Deadline()
+	assert.True(f.t, bounded, "deposits are submitted without a timeout")
+
+	results := make([]deposit.Result, len(depositDataList))
+	for i, depositData := range depositDataList {
+		assert.NoError(f.t, deposit.Verify(depositData, f.network))
+
+		results[i] = deposit.Result{Index: i, Pubkey: depositData.Pubkey}
+		if record, ok := store.Get(depositData.DepositDataRoot); ok {
+			f.resumed = append(f.resumed, record)
+			if record.Status == deposit.StatusConfirmed {
+				results[i].Status = deposit.StatusConfirmed
+				results[i].Skipped = true
+				continue
+			}
+		}
+		if f.err != nil {
+			results[i].Status = deposit.StatusFailed
+			return results, f.err
+		}
+
+		record := deposit.Record{Pubkey: depositData.Pubkey, Nonce: uint64(i), TxHash: fmt.Sprintf("0x%064x", i+1), RawTx: "0x02", Status: deposit.StatusSent}
+		if err := store.Put(depositData.DepositDataRoot, record); err != nil {
+			return results, err
+		}
+		record.Status = deposit.StatusConfirmed
+		record.BlockNumber = 100
+		if err := store.Put(depositData.DepositDataRoot, record); err != nil {
+			return results, err
+		}
+		results[i].Status = deposit.StatusConfirmed
+		results[i].TxHash = record.TxHash
+	}
+	return results, nil
+}
+
+func setupDepositTest(t *testing.T) (*mocks.RequestRepo, *ValidatorService, *fakeSubmitter) {
+	mockRepo, service := setupValidatorServiceTest(t)
+	submitter := &fakeSubmitter{t: t, network: service.network}
+	service.deposits = submitter
+	return mockRepo, service, submitter
+}
+
+func depositRequest(numValidators int) *models.ValidatorRequest {
+	return &models.ValidatorRequest{
+		ID:            uuid.New().String(),
+		NumValidators: numValidators,
+		FeeRecipient:  "0x1234567890abcdef1234567890abcdef12345678",
+		Amount:        eth2.MaxEffectiveBalance,
+		Deposit:       true,
+		StartIndex:    7,
+		Status:        models.StatusDepositing,
+	}
+}
+
+func generatedKeys(t *testing.T, request *models.ValidatorRequest) []*models.ValidatorKey {
+	keys := make([]*models.ValidatorKey, request.NumValidators)
+	for i := range keys {
+		secretKey, err := bls.DeriveKey(testSeed, bls.SigningKeyPath(request.StartIndex+i))
+		require.NoError(t, err)
+		keys[i] = &models.ValidatorKey{
+			ID:        uuid.New().String(),
+			RequestID: request.ID,
+			Key:       hexutil.Encode(secretKey.PublicKey()),
+			KeyIndex:  request.StartIndex + i,
+			SecretKey: hexutil.Encode(secretKey.Bytes()),
+		}
+	}
+	return keys
+}
+
+func TestCreateValidatorRequestDeposit(t *testing.T) {
+	t.Run("deposit stage not configured", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		response, err := service.CreateValidatorRequest(&models.ValidatorRequestInput{
+			NumValidators: 1,
+			FeeRecipient:  "0x1234567890abcdef1234567890abcdef12345678",
+			Deposit:       true,
+		})
+
+		assert.Nil(t, response)
+		assert.EqualError(t, err, "deposit submission is not enabled on this server")
+		mockRepo.AssertNotCalled(t, "CreateRequest", mock.Anything)
+	})
+}
+
+func TestProcessValidatorCreationDeposit(t *testing.T) {
+	t.Run("keys are deposited", func(t *testing.T) {
+		mockRepo, service, submitter := setupDepositTest(t)
+		request := depositRequest(2)
+		request.Status = models.StatusStarted
+
+		var savedKeys []*models.ValidatorKey
+		mockRepo.On("SaveValidatorKey", mock.AnythingOfType("*models.ValidatorKey")).
+			Run(func(args mock.Arguments) {
+				savedKeys = append(savedKeys, args.Get(0).(*models.ValidatorKey))
+			}).
+			Return(nil)
+		mockRepo.On("UpdateRequestStatus", request.ID, models.StatusDepositing, "").Return(nil)
+		mockRepo.On("GetValidatorKeysByRequestID", request.ID).
+			Return(func(string) []*models.ValidatorKey { return savedKeys }, nil)
+		deposits := make(map[string][]models.KeyDeposit)
+		mockRepo.On("UpdateKeyDeposit", mock.Anything, mock.AnythingOfType("*models.KeyDeposit")).
+			Run(func(args mock.Arguments) {
+				keyID := args.String(0)
+				deposits[keyID] = append(deposits[keyID], *args.Get(1).(*models.KeyDeposit))
+			}).
+			Return(nil)
+		mockRepo.On("UpdateRequestStatus", request.ID, models.StatusDeposited, "").Return(nil)
+
+		require.True(t, service.processValidatorCreation(request))
+		// Deposits are left to the deposit worker.
+		assert.Empty(t, submitter.submitted)
+		assert.True(t, service.processDeposits(context.Background(), request))
+
+		mockRepo.AssertCalled(t, "UpdateRequestStatus", request.ID, models.StatusDepositing, "")
+		mockRepo.AssertCalled(t, "UpdateRequestStatus", request.ID, models.StatusDeposited, "")
+		mockRepo.AssertNotCalled(t, "UpdateRequestStatus", request.ID, models.StatusSuccessful, "")
+
+		require.Len(t, submitter.submitted, 2)
+		for i, key := range savedKeys {
+			assert.Equal(t, key.Key, "0x"+submitter.submitted[i].Pubkey)
+			assert.Equal(t, eth2.MaxEffectiveBalance, submitter.submitted[i].Amount)
+
+			// The transaction is stored as sent before it is broadcast, then marked confirmed.
+			require.Len(t, deposits[key.ID], 2)
+			assert.Equal(t, deposit.StatusSent, deposits[key.ID][0].Status)
+			assert.Equal(t, "0x02", deposits[key.ID][0].RawTx)
+			assert.Equal(t, deposit.StatusConfirmed, deposits[key.ID][1].Status)
+			assert.Equal(t, uint64(100), deposits[key.ID][1].BlockNumber)
+			assert.Equal(t, fmt.Sprintf("0x%064x", i+1), deposits[key.ID][1].TxHash)
+		}
+	})
+
+	t.Run("recorded deposits are resumed", func(t *testing.T) {
+		mockRepo, service, submitter := setupDepositTest(t)
+		request := depositRequest(2)
+
+		keys := generatedKeys(t, request)
+		keys[0].Deposit = &models.KeyDeposit{Status: deposit.StatusConfirmed, TxHash: "0xabc", Nonce: 4, RawTx: "0x01", BlockNumber: 90}
+		mockRepo.On("GetValidatorKeysByRequestID", request.ID).Return(keys, nil)
+		mockRepo.On("UpdateKeyDeposit", keys[1].ID, mock.AnythingOfType("*models.KeyDeposit")).Return(nil)
+		mockRepo.On("UpdateRequestStatus", request.ID, models.StatusDeposited, "").Return(nil)
+
+		assert.True(t, service.processDeposits(context.Background(), request))
+
+		require.Len(t, submitter.resumed, 1)
+		assert.Equal(t, deposit.Record{Pubkey: keys[0].Key, Nonce: 4, TxHash: "0xabc", RawTx: "0x01", Status: deposit.StatusConfirmed, BlockNumber: 90}, submitter.resumed[0])
+		mockRepo.AssertNotCalled(t, "UpdateKeyDeposit", keys[0].ID, mock.Anything)
+		mockRepo.AssertCalled(t, "UpdateRequestStatus", request.ID, models.StatusDeposited, "")
+	})
+
+	t.Run("submission error", func(t *testing.T) {
+		mockRepo, service, submitter := setupDepositTest(t)
+		submitter.err = errors.New("deposit 0 (abc): transaction reverted in block 5: DepositContract: deposit value too low")
+		request := depositRequest(1)
+
+		mockRepo.On("GetValidatorKeysByRequestID", request.ID).Return(generatedKeys(t, request), nil)
+		mockRepo.On("UpdateRequestStatus", request.ID, models.StatusDepositFailed,
+			"Error submitting deposits: deposit 0 (abc): transaction reverted in block 5: DepositContract: deposit value too low").
+			Return(nil)
+
+		assert.True(t, service.processDeposits(context.Background(), request))
+
+		mockRepo.AssertNumberOfCalls(t, "UpdateRequestStatus", 1)
+	})
+
+	t.Run("deposits not confirmed in time", func(t *testing.T) {
+		mockRepo, service, submitter := setupDepositTest(t)
+		submitter.err = fmt.Errorf("deposit 0 (abc): error waiting for confirmation: %w", context.DeadlineExceeded)
+		request := depositRequest(1)
+
+		mockRepo.On("GetValidatorKeysByRequestID", request.ID).Return(generatedKeys(t, request), nil)
+
+		assert.False(t, service.processDeposits(context.Background(), request))
+
+		mockRepo.AssertNotCalled(t, "UpdateRequestStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("shutdown", func(t *testing.T) {
+		mockRepo, service, submitter := setupDepositTest(t)
+		submitter.err = fmt.Errorf("deposit 0 (abc): error waiting for confirmation: %w", context.Canceled)
+		request := depositRequest(1)
+
+		mockRepo.On("GetValidatorKeysByRequestID", request.ID).Return(generatedKeys(t, request), nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.False(t, service.processDeposits(ctx, request))
+
+		mockRepo.AssertNotCalled(t, "UpdateRequestStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestGetRequestStatusDeposits(t *testing.T) {
+	mockRepo, service, _ := setupDepositTest(t)
+	request := depositRequest(2)
+	request.Status = models.StatusDepositing
+
+	keys := generatedKeys(t, request)
+	keys[0].Deposit = &models.KeyDeposit{Status: deposit.StatusConfirmed, TxHash: "0xabc", BlockNumber: 90}
+	mockRepo.On("GetRequestByID", request.ID).Return(request, nil)
+	mockRepo.On("GetValidatorKeysByRequestID", request.ID).Return(keys, nil)
+
+	response, err := service.GetRequestStatus(request.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusDepositing, response.Status)
+	assert.Len(t, response.Keys, 2)
+	assert.Equal(t, []*models.ValidatorDeposit{{Key: keys[0].Key, KeyDeposit: *keys[0].Deposit}}, response.Deposits)
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"stakeway_test_task/internal/beacon"
+	"stakeway_test_task/internal/eth2"
+	"stakeway_test_task/internal/models"
+)
+
+// BeaconNode is the beacon node validators are looked up on and exits are broadcast to. It is implemented
+// by *beacon.Client.
+type BeaconNode interface {
+	Validator(ctx context.Context, stateID, id string) (*beacon.Validator, error)
+	SubmitVoluntaryExit(ctx context.Context, exit *models.SignedVoluntaryExit) error
+}
+
+// SignVoluntaryExit signs an exit of one of a request's keys, broadcasting it if asked to. Exits are
+// not slashable, so unlike other messages they need no slashing protection.
+func (s *ValidatorService) SignVoluntaryExit(ctx context.Context, requestID, pubkey string, input *models.VoluntaryExitInput) (*models.SignedVoluntaryExit, error) {
+	if input.Epoch == nil {
+		return nil, fmt.Errorf("%w: epoch is required", models.ErrInvalidInput)
+	}
+	if input.Broadcast && s.beacon == nil {
+		return nil, fmt.Errorf("%w: no beacon node is configured to broadcast to", models.ErrInvalidInput)
+	}
+
+	normalized, err := normalizePubkey(pubkey)
+	if err != nil {
+		return nil, err
+	}
+
+	_, keys, err := s.getReadyKeys(requestID)
+	if err != nil {
+		return nil, err
+	}
+	var key *models.ValidatorKey
+	for _, candidate := range keys {
+		if candidate.Key == normalized {
+			key = candidate
+			break
+		}
+	}
+	if key == nil {
+		return nil, models.ErrKeyNotFound
+	}
+
+	validatorIndex, err := s.validatorIndex(ctx, key, input.ValidatorIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	secretKey, err := decodeSecretKey(key)
+	if err != nil {
+		return nil, err
+	}
+	exit := &eth2.VoluntaryExit{Epoch: *input.Epoch, ValidatorIndex: validatorIndex}
+	signature := eth2.SignVoluntaryExit(secretKey, exit, s.network)
+
+	signed := &models.SignedVoluntaryExit{
+		Message:   models.VoluntaryExit{Epoch: exit.Epoch, ValidatorIndex: exit.ValidatorIndex},
+		Signature: hexutil.Encode(signature[:]),
+	}
+
+	if input.Broadcast {
+		if err := s.beacon.SubmitVoluntaryExit(ctx, signed); err != nil {
+			return nil, fmt.Errorf("%w: %w", models.ErrBeaconNode, err)
+		}
+		s.logger.Info("Broadcast voluntary exit",
+			"request_id", requestID,
+			"key", key.Key,
+			"validator_index", validatorIndex,
+			"epoch", exit.Epoch)
+	}
+
+	return signed, nil
+}
+
+// validatorIndex returns the index the beacon poller recorded, else the given index, else the one the
+// beacon node reports. A given index must match the recorded one, an exit for another index is worthless.
+func (s *ValidatorService) validatorIndex(ctx context.Context, key *models.ValidatorKey, index *uint64) (uint64, error) {
+	if key.Beacon != nil {
+		if index != nil && *index != key.Beacon.Index {
+			return 0, fmt.Errorf("%w: validator %s has index %d", models.ErrInvalidInput, key.Key, key.Beacon.Index)
+		}
+		return key.Beacon.Index, nil
+	}
+	if index != nil {
+		return *index, nil
+	}
+	if s.beacon == nil {
+		return 0, fmt.Errorf("%w: validator_index is required when no beacon node is configured", models.ErrInvalidInput)
+	}
+
+	validator, err := s.beacon.Validator(ctx, "head", key.Key)
+	if errors.Is(err, beacon.ErrValidatorNotFound) {
+		return 0, fmt.Errorf("%w: validator %s is not known to the beacon node yet", models.ErrInvalidInput, key.Key)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", models.ErrBeaconNode, err)
+	}
+	return validator.Index, nil
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"stakeway_test_task/internal/beacon"
+	"stakeway_test_task/internal/bls"
+	"stakeway_test_task/internal/eth2"
+	"stakeway_test_task/internal/models"
+	"strings"
+	"testing"
+)
+
+// fakeBeaconNode knows validators by pubkey and records the exits submitted to it.
+type fakeBeaconNode struct {
+	indices map[string]uint64
+	err     error
+
+	submitted []*models.SignedVoluntaryExit
+}
+
+func (f *fakeBeaconNode) Validator(_ context.Context, _, id string) (*beacon.Validator, error) {
+	index, ok := f.indices[id]
+	if !ok {
+		return nil, beacon.ErrValidatorNotFound
+	}
+	return &beacon.Validator{Index: index, Status: beacon.StatusActiveOngoing}, nil
+}
+
+func (f *fakeBeaconNode) SubmitVoluntaryExit(_ context.Context, exit *models.SignedVoluntaryExit) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.submitted = append(f.submitted, exit)
+	return nil
+}
+
+func TestSignVoluntaryExit(t *testing.T) {
+	secretKey, err := bls.DeriveKey(testSeed, bls.SigningKeyPath(0))
+	require.NoError(t, err)
+	pubkey := hexutil.Encode(secretKey.PublicKey())
+	epoch := uint64(256)
+
+	setup := func(t *testing.T, key *models.ValidatorKey, beaconNode *fakeBeaconNode) (*ValidatorService, string) {
+		mockRepo, service := setupValidatorServiceTest(t)
+		if beaconNode != nil {
+			service.beacon = beaconNode
+		}
+
+		requestID := uuid.New().String()
+		mockRepo.On("GetRequestByID", requestID).Return(&models.ValidatorRequest{ID: requestID, Status: models.StatusSuccessful}, nil).Maybe()
+		mockRepo.On("GetValidatorKeysByRequestID", requestID).Return([]*models.ValidatorKey{key}, nil).Maybe()
+		return service, requestID
+	}
+	newKey := func() *models.ValidatorKey {
+		return &models.ValidatorKey{Key: pubkey, SecretKey: hexutil.Encode(secretKey.Bytes())}
+	}
+	assertValidExit := func(t *testing.T, network *eth2.Network, exit *models.SignedVoluntaryExit) {
+		signature := [96]byte(hexutil.MustDecode(exit.Signature))
+		message := &eth2.VoluntaryExit{Epoch: exit.Message.Epoch, ValidatorIndex: exit.Message.ValidatorIndex}
+		assert.True(t, eth2.VerifyVoluntaryExit(secretKey.PublicKey(), message, signature, network))
+	}
+
+	t.Run("index tracked by the beacon poller", func(t *testing.T) {
+		key := newKey()
+		key.Beacon = &models.BeaconState{Status: beacon.StatusActiveOngoing, Index: 42}
+		service, requestID := setup(t, key, nil)
+
+		exit, err := service.SignVoluntaryExit(context.Background(), requestID, "0x"+strings.ToUpper(pubkey[2:]), &models.VoluntaryExitInput{Epoch: &epoch})
+
+		require.NoError(t, err)
+		assert.Equal(t, models.VoluntaryExit{Epoch: 256, ValidatorIndex: 42}, exit.Message)
+		assertValidExit(t, service.network, exit)
+	})
+
+	t.Run("index looked up and exit broadcast", func(t *testing.T) {
+		beaconNode := &fakeBeaconNode{indices: map[string]uint64{pubkey: 7}}
+		service, requestID := setup(t, newKey(), beaconNode)
+
+		exit, err := service.SignVoluntaryExit(context.Background(), requestID, pubkey, &models.VoluntaryExitInput{Epoch: &epoch, Broadcast: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(7), exit.Message.ValidatorIndex)
+		assertValidExit(t, service.network, exit)
+		assert.Equal(t, []*models.SignedVoluntaryExit{exit}, beaconNode.submitted)
+	})
+
+	t.Run("given index", func(t *testing.T) {
+		index := uint64(9)
+		service, requestID := setup(t, newKey(), nil)
+
+		exit, err := service.SignVoluntaryExit(context.Background(), requestID, pubkey, &models.VoluntaryExitInput{Epoch: &epoch, ValidatorIndex: &index})
+
+		require.NoError(t, err)
+		assert.Equal(t, uint64(9), exit.Message.ValidatorIndex)
+	})
+
+	t.Run("broadcast rejected", func(t *testing.T) {
+		beaconNode := &fakeBeaconNode{indices: map[string]uint64{pubkey: 7}, err: errors.New("beacon node returned 400: invalid exit")}
+		service, requestID := setup(t, newKey(), beaconNode)
+
+		_, err := service.SignVoluntaryExit(context.Background(), requestID, pubkey, &models.VoluntaryExitInput{Epoch: &epoch, Broadcast: true})
+
+		assert.ErrorIs(t, err, models.ErrBeaconNode)
+		assert.ErrorContains(t, err, "invalid exit")
+	})
+
+	t.Run("invalid input", func(t *testing.T) {
+		otherIndex := uint64(43)
+		tracked := newKey()
+		tracked.Beacon = &models.BeaconState{Index: 42}
+
+		for name, tt := range map[string]struct {
+			key        *models.ValidatorKey
+			beaconNode *fakeBeaconNode
+			input      *models.VoluntaryExitInput
+		}{
+			"missing epoch":              {newKey(), nil, &models.VoluntaryExitInput{}},
+			"broadcast without beacon":   {newKey(), nil, &models.VoluntaryExitInput{Epoch: &epoch, Broadcast: true}},
+			"unknown index":              {newKey(), nil, &models.VoluntaryExitInput{Epoch: &epoch}},
+			"validator not on chain yet": {newKey(), &fakeBeaconNode{}, &models.VoluntaryExitInput{Epoch: &epoch}},
+			"index of another validator": {tracked, nil, &models.VoluntaryExitInput{Epoch: &epoch, ValidatorIndex: &otherIndex}},
+		} {
+			service, requestID := setup(t, tt.key, tt.beaconNode)
+
+			_, err := service.SignVoluntaryExit(context.Background(), requestID, pubkey, tt.input)
+
+			assert.ErrorIs(t, err, models.ErrInvalidInput, name)
+		}
+	})
+
+	t.Run("key of another request", func(t *testing.T) {
+		service, requestID := setup(t, newKey(), nil)
+
+		_, err := service.SignVoluntaryExit(context.Background(), requestID, "0x"+repeatHex("aa"), &models.VoluntaryExitInput{Epoch: &epoch})
+
+		assert.ErrorIs(t, err, models.ErrKeyNotFound)
+	})
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stakeway_test_task/internal/models"
+	"stakeway_test_task/internal/utils"
+	"sync"
+	"time"
+)
+
+// JobLimits bounds the work the service takes on: the keys of Workers requests are generated at the same
+// time, and new requests are refused once QueueSize requests wait for a worker. Deposits are submitted by a
+// worker of their own, one request at a time, so that waiting for transactions never holds up key generation.
+type JobLimits struct {
+	Workers   int
+	QueueSize int
+}
+
+// DefaultJobLimits keep a burst of requests from exhausting CPU and database connections, while queueing
+// enough requests that clients rarely have to retry.
+var DefaultJobLimits = JobLimits{Workers: 4, QueueSize: 100}
+
+const (
+	// jobPollInterval is how often idle workers look for pending jobs nobody woke them up for.
+	jobPollInterval = 5 * time.Second
+	// maxJobAttempts bounds how often a job interrupted by a restart is resumed, so that a request that
+	// brings the service down does not do so forever.
+	maxJobAttempts = 3
+)
+
+// RecoverJobs prepares the stored jobs for RunJobs after a restart. Jobs that were running when the service
+// stopped are resumed, unless they were interrupted too often, in which case their request fails. Requests
+// still in progress without a job get one.
+func (s *ValidatorService) RecoverJobs() error {
+	jobs, err := s.repo.GetJobsByStatus(models.JobStatusRunning)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.Attempts < maxJobAttempts {
+			s.logger.Info("Resuming interrupted job", "request_id", job.RequestID, "attempts", job.Attempts)
+			if err := s.repo.UpdateJobStatus(job.RequestID, models.JobStatusPending, ""); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s.logger.Error("Giving up on repeatedly interrupted job", "request_id", job.RequestID, "attempts", job.Attempts)
+		if err := s.failInterruptedRequest(job.RequestID); err != nil {
+			return err
+		}
+		message := fmt.Sprintf("interrupted %d times", job.Attempts)
+		if err := s.repo.UpdateJobStatus(job.RequestID, models.JobStatusFailed, message); err != nil {
+			return err
+		}
+	}
+
+	enqueued, err := s.repo.EnqueueUnfinishedRequests()
+	if err != nil {
+		return err
+	}
+	if enqueued > 0 {
+		s.logger.Info("Created jobs for unfinished requests", "count", enqueued)
+	}
+
+	// Recovered jobs are queued even beyond QueueSize, they were accepted before the restart.
+	pending, err := s.repo.CountJobs(models.JobStageKeys, models.JobStatusPending)
+	if err != nil {
+		return err
+	}
+	utils.JobQueueDepth.Set(float64(pending))
+	return nil
+}
+
+func (s *ValidatorService) failInterruptedRequest(requestID string) error {
+	request, err := s.repo.GetRequestByID(requestID)
+	if err != nil {
+		return err
+	}
+
+	switch request.Status {
+	case models.StatusStarted:
+		return s.repo.UpdateRequestStatus(requestID, models.StatusFailed, "Validator creation was interrupted too many times")
+	case models.StatusDepositing:
+		return s.repo.UpdateRequestStatus(requestID, models.StatusDepositFailed, "Deposit submission was interrupted too many times")
+	}
+	return nil
+}
+
+// RunJobs processes pending jobs with the configured number of key workers and a deposit worker until ctx
+// is cancelled. A job cut short by the shutdown stays running and is resumed by RecoverJobs on the next start.
+func (s *ValidatorService) RunJobs(ctx context.Context) {
+	utils.JobWorkers.Set(float64(s.jobLimits.Workers))
+	defer utils.JobWorkers.Set(0)
+
+	var wg sync.WaitGroup
+	for range s.jobLimits.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runJobWorker(ctx, models.JobStageKeys)
+		}()
+	}
+	// A single deposit worker keeps batches of different requests, which share the funding wallet's nonces,
+	// from overlapping.
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.runJobWorker(ctx, models.JobStageDeposits)
+	}()
+	wg.Wait()
+}
+
+func (s *ValidatorService) runJobWorker(ctx context.Context, stage models.JobStage) {
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	// The metrics describe the key worker pool, which new requests wait for.
+	keys := stage == models.JobStageKeys
+
+	for {
+		for ctx.Err() == nil {
+			job, err := s.repo.ClaimJob(stage)
+			if err != nil {
+				s.logger.Error("Failed to claim job", "error", err, "stage", stage)
+				break
+			}
+			if job == nil {
+				if keys {
+					utils.JobQueueDepth.Set(0)
+				}
+				break
+			}
+
+			if keys {
+				s.updateQueueDepth()
+				utils.JobWorkersBusy.Inc()
+			}
+			s.processJob(ctx, job)
+			if keys {
+				utils.JobWorkersBusy.Dec()
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake[stage]:
+		case <-ticker.C:
+		}
+	}
+}
+
+// processJob runs a claimed job from where its request stands. Failures are recorded on the request, so
+// the job is done once processing returns, whatever its outcome.
+func (s *ValidatorService) processJob(ctx context.Context, job *models.Job) {
+	request, err := s.repo.GetRequestByID(job.RequestID)
+	if err != nil {
+		s.logger.Error("Failed to load request of job", "error", err, "request_id", job.RequestID)
+		if err := s.repo.UpdateJobStatus(job.RequestID, models.JobStatusFailed, err.Error()); err != nil {
+			s.logger.Error("Failed to update job status", "error", err, "request_id", job.RequestID)
+		}
+		return
+	}
+
+	switch {
+	case job.Stage == models.JobStageKeys && request.Status == models.StatusStarted:
+		if s.processValidatorCreation(request) && request.Deposit {
+			s.queueDeposits(job.RequestID)
+			return
+		}
+	case job.Stage == models.JobStageKeys && request.Status == models.StatusDepositing:
+		// Jobs stored before stages existed have the keys stage whatever their request's status.
+		s.queueDeposits(job.RequestID)
+		return
+	case job.Stage == models.JobStageDeposits && request.Status == models.StatusDepositing:
+		if !s.processDeposits(ctx, request) {
+			s.retryDeposits(ctx, job)
+			return
+		}
+	}
+
+	if err := s.repo.UpdateJobStatus(job.RequestID, models.JobStatusDone, ""); err != nil {
+		s.logger.Error("Failed to update job status", "error", err, "request_id", job.RequestID)
+	}
+}
+
+// retryDeposits puts back a job whose deposits were not confirmed within depositTimeout, until the job ran
+// out of attempts. A job cut short by the shutdown stays running and is resumed by RecoverJobs instead.
+func (s *ValidatorService) retryDeposits(ctx context.Context, job *models.Job) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	if job.Attempts < maxJobAttempts {
+		message := fmt.Sprintf("deposits not confirmed within %s", depositTimeout)
+		s.logger.Info("Retrying deposits", "request_id", job.RequestID, "attempts", job.Attempts)
+		if err := s.repo.UpdateJobStatus(job.RequestID, models.JobStatusPending, message); err != nil {
+			s.logger.Error("Failed to update job status", "error", err, "request_id", job.RequestID)
+		}
+		return
+	}
+
+	message := fmt.Sprintf("deposits not confirmed within %s %d times", depositTimeout, job.Attempts)
+	s.failDeposits(job.RequestID, "Error submitting deposits", errors.New(message))
+	if err := s.repo.UpdateJobStatus(job.RequestID, models.JobStatusFailed, message); err != nil {
+		s.logger.Error("Failed to update job status", "error", err, "request_id", job.RequestID)
+	}
+}
+
+// queueDeposits hands a job over to the deposit worker. If that fails, the job stays running and is
+// resumed after the next restart.
+func (s *ValidatorService) queueDeposits(requestID string) {
+	if err := s.repo.RequeueJob(requestID, models.JobStageDeposits); err != nil {
+		s.logger.Error("Failed to queue deposits", "error", err, "request_id", requestID)
+		return
+	}
+	s.wakeJobWorkers(models.JobStageDeposits)
+}
+
+// updateQueueDepth reports the number of requests waiting for a key worker after one was claimed.
+func (s *ValidatorService) updateQueueDepth() {
+	pending, err := s.repo.CountJobs(models.JobStageKeys, models.JobStatusPending)
+	if err != nil {
+		s.logger.Error("Failed to count pending jobs", "error", err)
+		return
+	}
+	utils.JobQueueDepth.Set(float64(pending))
+}
+
+// wakeJobWorkers lets an idle worker of stage pick up a new job right away instead of at its next poll.
+func (s *ValidatorService) wakeJobWorkers(stage models.JobStage) {
+	select {
+	case s.wake[stage] <- struct{}{}:
+	default:
+	}
+}
+
+func newWakeChannels() map[models.JobStage]chan struct{} {
+	return map[models.JobStage]chan struct{}{
+		models.JobStageKeys:     make(chan struct{}, 1),
+		models.JobStageDeposits: make(chan struct{}, 1),
+	}
+}
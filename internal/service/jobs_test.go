@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"stakeway_test_task/internal/models"
+	"stakeway_test_task/internal/utils"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRecoverJobs(t *testing.T) {
+	t.Run("interrupted jobs resumed or failed", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		resumed := &models.Job{RequestID: uuid.New().String(), Status: models.JobStatusRunning, Attempts: 1}
+		creation := &models.Job{RequestID: uuid.New().String(), Status: models.JobStatusRunning, Attempts: maxJobAttempts}
+		deposits := &models.Job{RequestID: uuid.New().String(), Status: models.JobStatusRunning, Attempts: maxJobAttempts}
+
+		mockRepo.On("GetJobsByStatus", models.JobStatusRunning).Return([]*models.Job{resumed, creation, deposits}, nil)
+		mockRepo.On("UpdateJobStatus", resumed.RequestID, models.JobStatusPending, "").Return(nil)
+		mockRepo.On("GetRequestByID", creation.RequestID).
+			Return(&models.ValidatorRequest{ID: creation.RequestID, Status: models.StatusStarted}, nil)
+		mockRepo.On("UpdateRequestStatus", creation.RequestID, models.StatusFailed, mock.Anything).Return(nil)
+		mockRepo.On("UpdateJobStatus", creation.RequestID, models.JobStatusFailed, "interrupted 3 times").Return(nil)
+		mockRepo.On("GetRequestByID", deposits.RequestID).
+			Return(&models.ValidatorRequest{ID: deposits.RequestID, Status: models.StatusDepositing}, nil)
+		mockRepo.On("UpdateRequestStatus", deposits.RequestID, models.StatusDepositFailed, mock.Anything).Return(nil)
+		mockRepo.On("UpdateJobStatus", deposits.RequestID, models.JobStatusFailed, "interrupted 3 times").Return(nil)
+		mockRepo.On("EnqueueUnfinishedRequests").Return(int64(2), nil)
+		mockRepo.On("CountJobs", models.JobStageKeys, models.JobStatusPending).Return(3, nil)
+
+		require.NoError(t, service.RecoverJobs())
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		mockRepo.On("GetJobsByStatus", models.JobStatusRunning).Return(nil, errors.New("database error"))
+
+		assert.Error(t, service.RecoverJobs())
+		mockRepo.AssertNotCalled(t, "EnqueueUnfinishedRequests")
+	})
+}
+
+func TestProcessJob(t *testing.T) {
+	t.Run("validator creation", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		request := &models.ValidatorRequest{
+			ID:            uuid.New().String(),
+			NumValidators: 1,
+			FeeRecipient:  "0x1234567890abcdef1234567890abcdef12345678",
+			Status:        models.StatusStarted,
+		}
+		mockRepo.On("GetRequestByID", request.ID).Return(request, nil)
+		mockRepo.On("GetValidatorKeysByRequestID", request.ID).Return(nil, nil)
+		mockRepo.On("SaveValidatorKey", mock.AnythingOfType("*models.ValidatorKey")).Return(nil)
+		mockRepo.On("UpdateRequestStatus", request.ID, models.StatusSuccessful, "").Return(nil)
+		mockRepo.On("UpdateJobStatus", request.ID, models.JobStatusDone, "").Return(nil)
+
+		service.processJob(context.Background(), &models.Job{RequestID: request.ID, Stage: models.JobStageKeys, Status: models.JobStatusRunning, Attempts: 1})
+	})
+
+	t.Run("deposits handed to the deposit worker", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+		service.wake = newWakeChannels()
+
+		request := &models.ValidatorRequest{
+			ID:            uuid.New().String(),
+			NumValidators: 1,
+			FeeRecipient:  "0x1234567890abcdef1234567890abcdef12345678",
+			Status:        models.StatusStarted,
+			Deposit:       true,
+		}
+		mockRepo.On("GetRequestByID", request.ID).Return(request, nil)
+		mockRepo.On("GetValidatorKeysByRequestID", request.ID).Return(nil, nil)
+		mockRepo.On("SaveValidatorKey", mock.AnythingOfType("*models.ValidatorKey")).Return(nil)
+		mockRepo.On("UpdateRequestStatus", request.ID, models.StatusDepositing, "").Return(nil)
+		mockRepo.On("RequeueJob", request.ID, models.JobStageDeposits).Return(nil)
+
+		service.processJob(context.Background(), &models.Job{RequestID: request.ID, Stage: models.JobStageKeys, Status: models.JobStatusRunning, Attempts: 1})
+
+		mockRepo.AssertNotCalled(t, "UpdateJobStatus", mock.Anything, mock.Anything, mock.Anything)
+		assert.Len(t, service.wake[models.JobStageDeposits], 1)
+		assert.Empty(t, service.wake[models.JobStageKeys])
+	})
+
+	t.Run("depositing request on the keys stage", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		request := &models.ValidatorRequest{ID: uuid.New().String(), Status: models.StatusDepositing, Deposit: true}
+		mockRepo.On("GetRequestByID", request.ID).Return(request, nil)
+		mockRepo.On("RequeueJob", request.ID, models.JobStageDeposits).Return(nil)
+
+		service.processJob(context.Background(), &models.Job{RequestID: request.ID, Stage: models.JobStageKeys, Status: models.JobStatusRunning, Attempts: 1})
+
+		mockRepo.AssertNotCalled(t, "UpdateRequestStatus", mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "UpdateJobStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("deposits without a depositor", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		request := &models.ValidatorRequest{ID: uuid.New().String(), Status: models.StatusDepositing, Deposit: true}
+		mockRepo.On("GetRequestByID", request.ID).Return(request, nil)
+		mockRepo.On("UpdateRequestStatus", request.ID, models.StatusDepositFailed, mock.Anything).Return(nil)
+		mockRepo.On("UpdateJobStatus", request.ID, models.JobStatusDone, "").Return(nil)
+
+		service.processJob(context.Background(), &models.Job{RequestID: request.ID, Stage: models.JobStageDeposits, Status: models.JobStatusRunning, Attempts: 1})
+	})
+
+	t.Run("deposits not confirmed in time", func(t *testing.T) {
+		mockRepo, service, submitter := setupDepositTest(t)
+		submitter.err = fmt.Errorf("deposit 0 (abc): error waiting for confirmation: %w", context.DeadlineExceeded)
+
+		request := depositRequest(1)
+		mockRepo.On("GetRequestByID", request.ID).Return(request, nil)
+		mockRepo.On("GetValidatorKeysByRequestID", request.ID).Return(generatedKeys(t, request), nil)
+		mockRepo.On("UpdateJobStatus", request.ID, models.JobStatusPending, "deposits not confirmed within 10m0s").Return(nil)
+
+		service.processJob(context.Background(), &models.Job{RequestID: request.ID, Stage: models.JobStageDeposits, Status: models.JobStatusRunning, Attempts: 1})
+
+		mockRepo.AssertNotCalled(t, "UpdateRequestStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("deposits not confirmed in time too often", func(t *testing.T) {
+		mockRepo, service, submitter := setupDepositTest(t)
+		submitter.err = fmt.Errorf("deposit 0 (abc): error waiting for confirmation: %w", context.DeadlineExceeded)
+
+		request := depositRequest(1)
+		mockRepo.On("GetRequestByID", request.ID).Return(request, nil)
+		mockRepo.On("GetValidatorKeysByRequestID", request.ID).Return(generatedKeys(t, request), nil)
+		mockRepo.On("UpdateRequestStatus", request.ID, models.StatusDepositFailed,
+			"Error submitting deposits: deposits not confirmed within 10m0s 3 times").Return(nil)
+		mockRepo.On("UpdateJobStatus", request.ID, models.JobStatusFailed, "deposits not confirmed within 10m0s 3 times").Return(nil)
+
+		service.processJob(context.Background(), &models.Job{RequestID: request.ID, Stage: models.JobStageDeposits, Status: models.JobStatusRunning, Attempts: maxJobAttempts})
+	})
+
+	t.Run("deposits cut short by the shutdown", func(t *testing.T) {
+		mockRepo, service, submitter := setupDepositTest(t)
+		submitter.err = fmt.Errorf("deposit 0 (abc): error waiting for confirmation: %w", context.Canceled)
+
+		request := depositRequest(1)
+		mockRepo.On("GetRequestByID", request.ID).Return(request, nil)
+		mockRepo.On("GetValidatorKeysByRequestID", request.ID).Return(generatedKeys(t, request), nil)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		service.processJob(ctx, &models.Job{RequestID: request.ID, Stage: models.JobStageDeposits, Status: models.JobStatusRunning, Attempts: 1})
+
+		// The job stays running for RecoverJobs.
+		mockRepo.AssertNotCalled(t, "UpdateJobStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("request already finished", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		request := &models.ValidatorRequest{ID: uuid.New().String(), Status: models.StatusSuccessful}
+		mockRepo.On("GetRequestByID", request.ID).Return(request, nil)
+		mockRepo.On("UpdateJobStatus", request.ID, models.JobStatusDone, "").Return(nil)
+
+		service.processJob(context.Background(), &models.Job{RequestID: request.ID, Stage: models.JobStageKeys, Status: models.JobStatusRunning, Attempts: 2})
+
+		mockRepo.AssertNotCalled(t, "UpdateRequestStatus", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("request not loaded", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		requestID := uuid.New().String()
+		mockRepo.On("GetRequestByID", requestID).Return(nil, errors.New("database error"))
+		mockRepo.On("UpdateJobStatus", requestID, models.JobStatusFailed, "database error").Return(nil)
+
+		service.processJob(context.Background(), &models.Job{RequestID: requestID, Stage: models.JobStageKeys, Status: models.JobStatusRunning, Attempts: 1})
+	})
+}
+
+func TestRunJobs(t *testing.T) {
+	mockRepo, service := setupValidatorServiceTest(t)
+	service.wake = newWakeChannels()
+	service.jobLimits.Workers = 2
+
+	// A single job is pending on each stage, whichever worker of the stage claims first gets it.
+	requests := map[models.JobStage]*models.ValidatorRequest{
+		models.JobStageKeys:     {ID: uuid.New().String(), Status: models.StatusSuccessful},
+		models.JobStageDeposits: {ID: uuid.New().String(), Status: models.StatusDeposited},
+	}
+	var keysClaimed, depositsClaimed atomic.Bool
+	claimed := map[models.JobStage]*atomic.Bool{models.JobStageKeys: &keysClaimed, models.JobStageDeposits: &depositsClaimed}
+	var processed sync.WaitGroup
+	processed.Add(len(requests))
+	mockRepo.On("ClaimJob", mock.Anything).Return(func(stage models.JobStage) (*models.Job, error) {
+		if claimed[stage].Swap(true) {
+			return nil, nil
+		}
+		return &models.Job{RequestID: requests[stage].ID, Stage: stage, Status: models.JobStatusRunning, Attempts: 1}, nil
+	})
+	mockRepo.On("CountJobs", models.JobStageKeys, models.JobStatusPending).Return(0, nil)
+	for _, request := range requests {
+		mockRepo.On("GetRequestByID", request.ID).Return(request, nil)
+		mockRepo.On("UpdateJobStatus", request.ID, models.JobStatusDone, "").
+			Run(func(args mock.Arguments) { processed.Done() }).
+			Return(nil)
+	}
+	done := make(chan struct{})
+	go func() {
+		processed.Wait()
+		close(done)
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		service.RunJobs(ctx)
+		close(stopped)
+	}()
+	service.wakeJobWorkers(models.JobStageKeys)
+	service.wakeJobWorkers(models.JobStageDeposits)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("jobs were not processed")
+	}
+	assert.Equal(t, float64(2), testutil.ToFloat64(utils.JobWorkers))
+	assert.Equal(t, float64(0), testutil.ToFloat64(utils.JobQueueDepth))
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("workers did not stop")
+	}
+	assert.Equal(t, float64(0), testutil.ToFloat64(utils.JobWorkersBusy))
+	assert.Equal(t, float64(0), testutil.ToFloat64(utils.JobWorkers))
+}
@@ -0,0 +1,391 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+	"log/slog"
+	"net/url"
+	"stakeway_test_task/internal/bls"
+	"stakeway_test_task/internal/eth2"
+	"stakeway_test_task/internal/keystore"
+	"stakeway_test_task/internal/models"
+	"strings"
+)
+
+type KeyRepo interface {
+	GetActiveValidatorKeys() ([]*models.ValidatorKey, error)
+	GetValidatorKeyByPubkey(pubkey string) (*models.ValidatorKey, error)
+	GetRequestByID(id string) (*models.ValidatorRequest, error)
+	SaveValidatorKey(key *models.ValidatorKey) error
+	SetKeyDeleted(keyID string, deleted bool) error
+	UpdateKeyFeeRecipient(keyID, feeRecipient string) error
+	GetRemoteKeys() ([]*models.RemoteKey, error)
+	SaveRemoteKey(key *models.RemoteKey) error
+	DeleteRemoteKey(pubkey string) (bool, error)
+}
+
+// KeymanagerService implements the standard Keymanager API on top of the keys held by the service.
+type KeymanagerService struct {
+	repo       KeyRepo
+	logger     *slog.Logger
+	network    *eth2.Network
+	protection *SlashingProtection
+}
+
+func NewKeymanagerService(repo KeyRepo, logger *slog.Logger, network *eth2.Network, protection *SlashingProtection) *KeymanagerService {
+	return &KeymanagerService{repo: repo, logger: logger, network: network, protection: protection}
+}
+
+func (s *KeymanagerService) ListKeystores() ([]models.KeystoreInfo, error) {
+	keys, err := s.repo.GetActiveValidatorKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	keystores := make([]models.KeystoreInfo, 0, len(keys))
+	for _, key := range keys {
+		keystores = append(keystores, models.KeystoreInfo{
+			ValidatingPubkey: key.Key,
+			DerivationPath:   derivationPath(key),
+		})
+	}
+	return keystores, nil
+}
+
+// maxImportKeystores bounds the keystores of an import. Any of them may use scrypt, so an import is held to
+// the limit of a scrypt export.
+const maxImportKeystores = maxScryptKeystores
+
+// ImportKeystores stores the secret keys of EIP-2335 keystores. The status of each keystore is reported
+// separately, so that one bad keystore does not prevent importing the others. Slashing protection data
+// is imported first, and no key is imported if it is invalid.
+func (s *KeymanagerService) ImportKeystores(input *models.ImportKeystoresInput) ([]models.KeyStatus, error) {
+	if len(input.Keystores) != len(input.Passwords) {
+		return nil, fmt.Errorf("%w: got %d keystores and %d passwords", models.ErrInvalidInput, len(input.Keystores), len(input.Passwords))
+	}
+	if len(input.Keystores) > maxImportKeystores {
+		return nil, fmt.Errorf("%w: at most %d keystores can be imported at once, got %d", models.ErrInvalidInput, maxImportKeystores, len(input.Keystores))
+	}
+	if input.SlashingProtection != "" {
+		var interchange models.Interchange
+		if err := json.Unmarshal([]byte(input.SlashingProtection), &interchange); err != nil {
+			return nil, fmt.Errorf("%w: invalid slashing protection data: %w", models.ErrInvalidInput, err)
+		}
+		if err := s.protection.Import(&interchange); err != nil {
+			return nil, err
+		}
+	}
+
+	type decrypted struct {
+		secretKey *bls.SecretKey
+		path      string
+		err       error
+	}
+	keys := make([]decrypted, len(input.Keystores))
+
+	// A password is only checked after running the keystore's KDF, so keystores are decrypted in parallel.
+	var g errgroup.Group
+	g.SetLimit(keystoreParallelism)
+	for i := range input.Keystores {
+		g.Go(func() error {
+			keys[i].secretKey, keys[i].path, keys[i].err = decryptKeystore(input.Keystores[i], input.Passwords[i])
+			return nil
+		})
+	}
+	g.Wait()
+
+	statuses := make([]models.KeyStatus, len(keys))
+	for i, key := range keys {
+		if key.err != nil {
+			statuses[i] = models.KeyStatus{Status: models.KeyStatusError, Message: key.err.Error()}
+			continue
+		}
+		statuses[i] = s.importKey(key.secretKey, key.path)
+	}
+	return statuses, nil
+}
+
+func (s *KeymanagerService) importKey(secretKey *bls.SecretKey, path string) models.KeyStatus {
+	pubkey := hexutil.Encode(secretKey.PublicKey())
+
+	existing, err := s.repo.GetValidatorKeyByPubkey(pubkey)
+	switch {
+	case err == nil && !existing.Deleted:
+		return models.KeyStatus{Status: models.KeyStatusDuplicate}
+	case err == nil:
+		// A deleted key is served again, with the metadata it had before.
+		if err := s.repo.SetKeyDeleted(existing.ID, false); err != nil {
+			return models.KeyStatus{Status: models.KeyStatusError, Message: err.Error()}
+		}
+	case errors.Is(err, models.ErrKeyNotFound):
+		err := s.repo.SaveValidatorKey(&models.ValidatorKey{
+			ID:             uuid.New().String(),
+			Key:            pubkey,
+			SecretKey:      hexutil.Encode(secretKey.Bytes()),
+			DerivationPath: path,
+		})
+		if err != nil {
+			return models.KeyStatus{Status: models.KeyStatusError, Message: err.Error()}
+		}
+	default:
+		return models.KeyStatus{Status: models.KeyStatusError, Message: err.Error()}
+	}
+
+	s.logger.Info("Imported validator key", "key", pubkey)
+	return models.KeyStatus{Status: models.KeyStatusImported}
+}
+
+func decryptKeystore(keystoreJSON, password string) (*bls.SecretKey, string, error) {
+	var ks keystore.Keystore
+	if err := json.Unmarshal([]byte(keystoreJSON), &ks); err != nil {
+		return nil, "", fmt.Errorf("invalid keystore: %w", err)
+	}
+
+	secret, err := keystore.Decrypt(&ks, password)
+	if err != nil {
+		return nil, "", err
+	}
+	secretKey, err := bls.SecretKeyFromBytes(secret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if ks.Pubkey != "" && !strings.EqualFold(strings.TrimPrefix(ks.Pubkey, "0x"), hexutil.Encode(secretKey.PublicKey())[2:]) {
+		return nil, "", errors.New("keystore pubkey does not match its secret key")
+	}
+	return secretKey, ks.Path, nil
+}
+
+// DeleteKeystores stops serving the given keys and returns the slashing protection data of every key
+// that was held by the service, as the validator client importing them elsewhere needs it.
+func (s *KeymanagerService) DeleteKeystores(input *models.DeleteKeysInput) (*models.DeleteKeystoresResponse, error) {
+	response := &models.DeleteKeystoresResponse{Data: make([]models.KeyStatus, len(input.Pubkeys))}
+
+	var known []string
+	for i, pubkey := range input.Pubkeys {
+		normalized, err := normalizePubkey(pubkey)
+		if err != nil {
+			response.Data[i] = models.KeyStatus{Status: models.KeyStatusError, Message: err.Error()}
+			continue
+		}
+
+		key, err := s.repo.GetValidatorKeyByPubkey(normalized)
+		switch {
+		case errors.Is(err, models.ErrKeyNotFound):
+			response.Data[i] = models.KeyStatus{Status: models.KeyStatusNotFound}
+			continue
+		case err != nil:
+			response.Data[i] = models.KeyStatus{Status: models.KeyStatusError, Message: err.Error()}
+			continue
+		case key.Deleted:
+			response.Data[i] = models.KeyStatus{Status: models.KeyStatusNotActive}
+		default:
+			if err := s.repo.SetKeyDeleted(key.ID, true); err != nil {
+				response.Data[i] = models.KeyStatus{Status: models.KeyStatusError, Message: err.Error()}
+				continue
+			}
+			s.logger.Info("Deleted validator key", "key", normalized)
+			response.Data[i] = models.KeyStatus{Status: models.KeyStatusDeleted}
+		}
+		known = append(known, normalized)
+	}
+
+	slashingProtection, err := s.slashingProtection(known)
+	if err != nil {
+		return nil, err
+	}
+	response.SlashingProtection = slashingProtection
+	return response, nil
+}
+
+// slashingProtection returns EIP-3076 interchange JSON for pubkeys, and not for every key when there
+// are none.
+func (s *KeymanagerService) slashingProtection(pubkeys []string) (string, error) {
+	if pubkeys == nil {
+		pubkeys = []string{}
+	}
+	interchange, err := s.protection.Export(pubkeys)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(interchange)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// ExportSlashingProtection returns the signing history of pubkeys, or of every key with history when
+// none are given.
+func (s *KeymanagerService) ExportSlashingProtection(pubkeys []string) (*models.Interchange, error) {
+	var normalized []string
+	for _, pubkey := range pubkeys {
+		key, err := normalizePubkey(pubkey)
+		if err != nil {
+			return nil, err
+		}
+		normalized = append(normalized, key)
+	}
+	return s.protection.Export(normalized)
+}
+
+func (s *KeymanagerService) ImportSlashingProtection(interchange *models.Interchange) error {
+	return s.protection.Import(interchange)
+}
+
+func (s *KeymanagerService) ListRemoteKeys() ([]models.RemoteKey, error) {
+	keys, err := s.repo.GetRemoteKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	remoteKeys := make([]models.RemoteKey, 0, len(keys))
+	for _, key := range keys {
+		remoteKeys = append(remoteKeys, *key)
+	}
+	return remoteKeys, nil
+}
+
+func (s *KeymanagerService) ImportRemoteKeys(input *models.ImportRemoteKeysInput) ([]models.KeyStatus, error) {
+	remoteKeys, err := s.repo.GetRemoteKeys()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(remoteKeys))
+	for _, key := range remoteKeys {
+		known[key.Pubkey] = true
+	}
+
+	statuses := make([]models.KeyStatus, len(input.RemoteKeys))
+	for i, remoteKey := range input.RemoteKeys {
+		pubkey, err := normalizePubkey(remoteKey.Pubkey)
+		if err != nil {
+			statuses[i] = models.KeyStatus{Status: models.KeyStatusError, Message: err.Error()}
+			continue
+		}
+		if signerURL, err := url.Parse(remoteKey.URL); err != nil || (signerURL.Scheme != "http" && signerURL.Scheme != "https") || signerURL.Host == "" {
+			statuses[i] = models.KeyStatus{Status: models.KeyStatusError, Message: fmt.Sprintf("invalid remote signer URL %q", remoteKey.URL)}
+			continue
+		}
+
+		// A key cannot be both local and remote.
+		localKey, err := s.repo.GetValidatorKeyByPubkey(pubkey)
+		if err != nil && !errors.Is(err, models.ErrKeyNotFound) {
+			statuses[i] = models.KeyStatus{Status: models.KeyStatusError, Message: err.Error()}
+			continue
+		}
+		if known[pubkey] || (err == nil && !localKey.Deleted) {
+			statuses[i] = models.KeyStatus{Status: models.KeyStatusDuplicate}
+			continue
+		}
+
+		if err := s.repo.SaveRemoteKey(&models.RemoteKey{Pubkey: pubkey, URL: remoteKey.URL}); err != nil {
+			statuses[i] = models.KeyStatus{Status: models.KeyStatusError, Message: err.Error()}
+			continue
+		}
+		known[pubkey] = true
+		statuses[i] = models.KeyStatus{Status: models.KeyStatusImported}
+	}
+	return statuses, nil
+}
+
+func (s *KeymanagerService) DeleteRemoteKeys(input *models.DeleteKeysInput) ([]models.KeyStatus, error) {
+	statuses := make([]models.KeyStatus, len(input.Pubkeys))
+	for i, pubkey := range input.Pubkeys {
+		normalized, err := normalizePubkey(pubkey)
+		if err != nil {
+			statuses[i] = models.KeyStatus{Status: models.KeyStatusError, Message: err.Error()}
+			continue
+		}
+
+		deleted, err := s.repo.DeleteRemoteKey(normalized)
+		switch {
+		case err != nil:
+			statuses[i] = models.KeyStatus{Status: models.KeyStatusError, Message: err.Error()}
+		case deleted:
+			statuses[i] = models.KeyStatus{Status: models.KeyStatusDeleted}
+		default:
+			statuses[i] = models.KeyStatus{Status: models.KeyStatusNotFound}
+		}
+	}
+	return statuses, nil
+}
+
+func (s *KeymanagerService) GetFeeRecipient(pubkey string) (*models.FeeRecipient, error) {
+	key, err := s.activeKey(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	if key.FeeRecipient == "" {
+		return nil, models.ErrNoFeeRecipient
+	}
+	return &models.FeeRecipient{Pubkey: key.Key, EthAddress: key.FeeRecipient}, nil
+}
+
+func (s *KeymanagerService) SetFeeRecipient(pubkey, ethAddress string) error {
+	if !isValidEthereumAddress(ethAddress) {
+		return fmt.Errorf("%w: invalid Ethereum address format", models.ErrInvalidInput)
+	}
+
+	key, err := s.activeKey(pubkey)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdateKeyFeeRecipient(key.ID, ethAddress)
+}
+
+// DeleteFeeRecipient reverts a key to the fee recipient of the request that generated it. Imported keys
+// have no default and are left without one.
+func (s *KeymanagerService) DeleteFeeRecipient(pubkey string) error {
+	key, err := s.activeKey(pubkey)
+	if err != nil {
+		return err
+	}
+
+	var feeRecipient string
+	if key.RequestID != "" {
+		request, err := s.repo.GetRequestByID(key.RequestID)
+		if err != nil {
+			return err
+		}
+		feeRecipient = request.FeeRecipient
+	}
+	return s.repo.UpdateKeyFeeRecipient(key.ID, feeRecipient)
+}
+
+func (s *KeymanagerService) activeKey(pubkey string) (*models.ValidatorKey, error) {
+	normalized, err := normalizePubkey(pubkey)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.repo.GetValidatorKeyByPubkey(normalized)
+	if err != nil {
+		return nil, err
+	}
+	if key.Deleted {
+		return nil, models.ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func derivationPath(key *models.ValidatorKey) string {
+	if key.DerivationPath != "" || key.RequestID == "" {
+		return key.DerivationPath
+	}
+	return bls.SigningKeyPath(key.KeyIndex)
+}
+
+// normalizePubkey returns pubkey in the form keys are stored in: 0x-prefixed lowercase hex.
+func normalizePubkey(pubkey string) (string, error) {
+	decoded, err := hexutil.Decode(pubkey)
+	if err != nil || len(decoded) != bls.PublicKeyLength {
+		return "", fmt.Errorf("%w: invalid validator pubkey %q", models.ErrInvalidInput, pubkey)
+	}
+	return hexutil.Encode(decoded), nil
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"os"
+	"stakeway_test_task/internal/bls"
+	"stakeway_test_task/internal/eth2"
+	"stakeway_test_task/internal/keystore"
+	"stakeway_test_task/internal/mocks"
+	"stakeway_test_task/internal/models"
+	"strings"
+	"testing"
+)
+
+const testFeeRecipient = "0x1234567890abcdef1234567890abcdef12345678"
+
+func setupKeymanagerTest(t *testing.T) (*mocks.KeyRepo, *mocks.SlashingRepo, *KeymanagerService) {
+	mockRepo := mocks.NewKeyRepo(t)
+	mockSlashingRepo := mocks.NewSlashingRepo(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	network, err := eth2.NetworkByName("holesky")
+	require.NoError(t, err)
+
+	return mockRepo, mockSlashingRepo, NewKeymanagerService(mockRepo, logger, network, NewSlashingProtection(mockSlashingRepo, network))
+}
+
+func testKeystore(t *testing.T, index int, password string) (string, *bls.SecretKey) {
+	secretKey, err := bls.DeriveKey(testSeed, bls.SigningKeyPath(index))
+	require.NoError(t, err)
+
+	ks, err := keystore.Encrypt(secretKey.Bytes(), secretKey.PublicKey(), bls.SigningKeyPath(index), password, keystore.KDFPBKDF2)
+	require.NoError(t, err)
+	data, err := json.Marshal(ks)
+	require.NoError(t, err)
+	return string(data), secretKey
+}
+
+func TestListKeystores(t *testing.T) {
+	mockRepo, _, service := setupKeymanagerTest(t)
+
+	mockRepo.On("GetActiveValidatorKeys").Return([]*models.ValidatorKey{
+		{Key: "0xaa", RequestID: "request", KeyIndex: 4},
+		{Key: "0xbb", DerivationPath: "m/12381/3600/9/0/0"},
+		{Key: "0xcc"},
+	}, nil)
+
+	keystores, err := service.ListKeystores()
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.KeystoreInfo{
+		{ValidatingPubkey: "0xaa", DerivationPath: "m/12381/3600/4/0/0"},
+		{ValidatingPubkey: "0xbb", DerivationPath: "m/12381/3600/9/0/0"},
+		{ValidatingPubkey: "0xcc"},
+	}, keystores)
+}
+
+func TestImportKeystores(t *testing.T) {
+	t.Run("statuses per keystore", func(t *testing.T) {
+		mockRepo, _, service := setupKeymanagerTest(t)
+
+		newKeystore, newKey := testKeystore(t, 0, "password")
+		duplicateKeystore, duplicateKey := testKeystore(t, 1, "password")
+		deletedKeystore, deletedKey := testKeystore(t, 2, "password")
+		wrongPasswordKeystore, _ := testKeystore(t, 3, "password")
+
+		newPubkey := hexutil.Encode(newKey.PublicKey())
+		mockRepo.On("GetValidatorKeyByPubkey", newPubkey).Return(nil, models.ErrKeyNotFound)
+		mockRepo.On("GetValidatorKeyByPubkey", hexutil.Encode(duplicateKey.PublicKey())).
+			Return(&models.ValidatorKey{ID: "duplicate"}, nil)
+		mockRepo.On("GetValidatorKeyByPubkey", hexutil.Encode(deletedKey.PublicKey())).
+			Return(&models.ValidatorKey{ID: "deleted", Deleted: true}, nil)
+		mockRepo.On("SetKeyDeleted", "deleted", false).Return(nil)
+		mockRepo.On("SaveValidatorKey", mock.MatchedBy(func(key *models.ValidatorKey) bool {
+			return key.Key == newPubkey && key.SecretKey == hexutil.Encode(newKey.Bytes()) &&
+				key.DerivationPath == bls.SigningKeyPath(0) && key.RequestID == ""
+		})).Return(nil)
+
+		statuses, err := service.ImportKeystores(&models.ImportKeystoresInput{
+			Keystores: []string{newKeystore, duplicateKeystore, deletedKeystore, wrongPasswordKeystore, "{"},
+			Passwords: []string{"password", "password", "password", "wrong", "password"},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, statuses, 5)
+		assert.Equal(t, models.KeyStatus{Status: models.KeyStatusImported}, statuses[0])
+		assert.Equal(t, models.KeyStatus{Status: models.KeyStatusDuplicate}, statuses[1])
+		assert.Equal(t, models.KeyStatus{Status: models.KeyStatusImported}, statuses[2])
+		assert.Equal(t, models.KeyStatus{Status: models.KeyStatusError, Message: keystore.ErrInvalidPassword.Error()}, statuses[3])
+		assert.Equal(t, models.KeyStatusError, statuses[4].Status)
+		mockRepo.AssertNumberOfCalls(t, "SaveValidatorKey", 1)
+	})
+
+	t.Run("passwords do not match keystores", func(t *testing.T) {
+		_, _, service := setupKeymanagerTest(t)
+
+		_, err := service.ImportKeystores(&models.ImportKeystoresInput{Keystores: []string{"{}"}})
+
+		assert.ErrorIs(t, err, models.ErrInvalidInput)
+	})
+
+	t.Run("too many keystores", func(t *testing.T) {
+		_, _, service := setupKeymanagerTest(t)
+
+		keystores := make([]string, maxImportKeystores+1)
+		passwords := make([]string, len(keystores))
+		for i := range keystores {
+			keystores[i], passwords[i] = "{}", "password"
+		}
+		statuses, err := service.ImportKeystores(&models.ImportKeystoresInput{Keystores: keystores, Passwords: passwords})
+
+		assert.ErrorIs(t, err, models.ErrInvalidInput)
+		assert.Nil(t, statuses)
+	})
+
+	t.Run("slashing protection is imported first", func(t *testing.T) {
+		mockRepo, mockSlashingRepo, service := setupKeymanagerTest(t)
+
+		ks, secretKey := testKeystore(t, 0, "password")
+		pubkey := hexutil.Encode(secretKey.PublicKey())
+		mockSlashingRepo.On("ImportSlashingProtection", []models.InterchangeData{
+			{Pubkey: pubkey, SignedBlocks: []models.SignedBlock{{Slot: 81952}}},
+		}).Return(nil)
+		mockRepo.On("GetValidatorKeyByPubkey", pubkey).Return(nil, models.ErrKeyNotFound)
+		mockRepo.On("SaveValidatorKey", mock.Anything).Return(nil)
+
+		statuses, err := service.ImportKeystores(&models.ImportKeystoresInput{
+			Keystores: []string{ks},
+			Passwords: []string{"password"},
+			SlashingProtection: `{"metadata":{"interchange_format_version":"5",` +
+				`"genesis_validators_root":"0x9143aa7c615a7f7115e2b6aac319c03529df8242ae705fba9df39b79c59fa8b1"},` +
+				`"data":[{"pubkey":"` + pubkey + `","signed_blocks":[{"slot":"81952"}],"signed_attestations":[]}]}`,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.KeyStatus{{Status: models.KeyStatusImported}}, statuses)
+	})
+
+	t.Run("invalid slashing protection", func(t *testing.T) {
+		_, _, service := setupKeymanagerTest(t)
+
+		_, err := service.ImportKeystores(&models.ImportKeystoresInput{
+			Keystores:          []string{"{}"},
+			Passwords:          []string{"password"},
+			SlashingProtection: `{"metadata":{"interchange_format_version":"5","genesis_validators_root":"0x00"},"data":[]}`,
+		})
+
+		assert.ErrorIs(t, err, models.ErrInvalidInput)
+	})
+}
+
+func TestDeleteKeystores(t *testing.T) {
+	mockRepo, mockSlashingRepo, service := setupKeymanagerTest(t)
+
+	active := "0x" + repeatHex("aa")
+	deleted := "0x" + repeatHex("bb")
+	unknown := "0x" + repeatHex("cc")
+	mockRepo.On("GetValidatorKeyByPubkey", active).Return(&models.ValidatorKey{ID: "active", Key: active}, nil)
+	mockRepo.On("GetValidatorKeyByPubkey", deleted).Return(&models.ValidatorKey{ID: "deleted", Key: deleted, Deleted: true}, nil)
+	mockRepo.On("GetValidatorKeyByPubkey", unknown).Return(nil, models.ErrKeyNotFound)
+	mockRepo.On("SetKeyDeleted", "active", true).Return(nil)
+	mockSlashingRepo.On("GetSlashingProtection", []string{active, deleted}).Return([]models.InterchangeData{
+		{Pubkey: active, SignedBlocks: []models.SignedBlock{{Slot: 81952, SigningRoot: "0x" + strings.Repeat("01", 32)}}, SignedAttestations: []models.SignedAttestation{}},
+		{Pubkey: deleted, SignedBlocks: []models.SignedBlock{}, SignedAttestations: []models.SignedAttestation{{SourceEpoch: 2290, TargetEpoch: 3007}}},
+	}, nil)
+
+	// Pubkeys are matched case-insensitively.
+	response, err := service.DeleteKeystores(&models.DeleteKeysInput{Pubkeys: []string{"0x" + repeatHex("AA"), deleted, unknown, "0x1234"}})
+
+	require.NoError(t, err)
+	require.Len(t, response.Data, 4)
+	assert.Equal(t, models.KeyStatusDeleted, response.Data[0].Status)
+	assert.Equal(t, models.KeyStatusNotActive, response.Data[1].Status)
+	assert.Equal(t, models.KeyStatusNotFound, response.Data[2].Status)
+	assert.Equal(t, models.KeyStatusError, response.Data[3].Status)
+
+	assert.JSONEq(t, `{
+		"metadata": {
+			"interchange_format_version": "5",
+			"genesis_validators_root": "0x9143aa7c615a7f7115e2b6aac319c03529df8242ae705fba9df39b79c59fa8b1"
+		},
+		"data": [
+			{"pubkey": "`+active+`", "signed_blocks": [{"slot": "81952", "signing_root": "0x`+strings.Repeat("01", 32)+`"}], "signed_attestations": []},
+			{"pubkey": "`+deleted+`", "signed_blocks": [], "signed_attestations": [{"source_epoch": "2290", "target_epoch": "3007"}]}
+		]
+	}`, response.SlashingProtection)
+}
+
+func TestImportRemoteKeys(t *testing.T) {
+	mockRepo, _, service := setupKeymanagerTest(t)
+
+	local := "0x" + repeatHex("aa")
+	remote := "0x" + repeatHex("bb")
+	fresh := "0x" + repeatHex("cc")
+	mockRepo.On("GetRemoteKeys").Return([]*models.RemoteKey{{Pubkey: remote, URL: "https://signer.example"}}, nil)
+	mockRepo.On("GetValidatorKeyByPubkey", local).Return(&models.ValidatorKey{ID: "local", Key: local}, nil)
+	mockRepo.On("GetValidatorKeyByPubkey", remote).Return(nil, models.ErrKeyNotFound)
+	mockRepo.On("GetValidatorKeyByPubkey", fresh).Return(nil, models.ErrKeyNotFound)
+	mockRepo.On("SaveRemoteKey", &models.RemoteKey{Pubkey: fresh, URL: "https://signer.example"}).Return(nil)
+
+	statuses, err := service.ImportRemoteKeys(&models.ImportRemoteKeysInput{RemoteKeys: []models.RemoteKey{
+		{Pubkey: local, URL: "https://signer.example"},
+		{Pubkey: remote, URL: "https://signer.example"},
+		{Pubkey: fresh, URL: "https://signer.example"},
+		{Pubkey: fresh, URL: "https://signer.example"},
+		{Pubkey: fresh, URL: "signer.example"},
+	}})
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.KeyStatus{
+		{Status: models.KeyStatusDuplicate},
+		{Status: models.KeyStatusDuplicate},
+		{Status: models.KeyStatusImported},
+		{Status: models.KeyStatusDuplicate},
+		{Status: models.KeyStatusError, Message: `invalid remote signer URL "signer.example"`},
+	}, statuses)
+}
+
+func TestDeleteRemoteKeys(t *testing.T) {
+	mockRepo, _, service := setupKeymanagerTest(t)
+
+	remote := "0x" + repeatHex("bb")
+	unknown := "0x" + repeatHex("cc")
+	mockRepo.On("DeleteRemoteKey", remote).Return(true, nil)
+	mockRepo.On("DeleteRemoteKey", unknown).Return(false, nil)
+
+	statuses, err := service.DeleteRemoteKeys(&models.DeleteKeysInput{Pubkeys: []string{remote, unknown}})
+
+	require.NoError(t, err)
+	assert.Equal(t, []models.KeyStatus{{Status: models.KeyStatusDeleted}, {Status: models.KeyStatusNotFound}}, statuses)
+}
+
+func TestFeeRecipient(t *testing.T) {
+	pubkey := "0x" + repeatHex("aa")
+
+	t.Run("get", func(t *testing.T) {
+		mockRepo, _, service := setupKeymanagerTest(t)
+		mockRepo.On("GetValidatorKeyByPubkey", pubkey).Return(&models.ValidatorKey{Key: pubkey, FeeRecipient: testFeeRecipient}, nil)
+
+		feeRecipient, err := service.GetFeeRecipient(pubkey)
+
+		require.NoError(t, err)
+		assert.Equal(t, &models.FeeRecipient{Pubkey: pubkey, EthAddress: testFeeRecipient}, feeRecipient)
+	})
+
+	t.Run("get for imported key without fee recipient", func(t *testing.T) {
+		mockRepo, _, service := setupKeymanagerTest(t)
+		mockRepo.On("GetValidatorKeyByPubkey", pubkey).Return(&models.ValidatorKey{Key: pubkey}, nil)
+
+		_, err := service.GetFeeRecipient(pubkey)
+
+		assert.ErrorIs(t, err, models.ErrNoFeeRecipient)
+	})
+
+	t.Run("get for deleted key", func(t *testing.T) {
+		mockRepo, _, service := setupKeymanagerTest(t)
+		mockRepo.On("GetValidatorKeyByPubkey", pubkey).Return(&models.ValidatorKey{Key: pubkey, Deleted: true}, nil)
+
+		_, err := service.GetFeeRecipient(pubkey)
+
+		assert.ErrorIs(t, err, models.ErrKeyNotFound)
+	})
+
+	t.Run("set", func(t *testing.T) {
+		mockRepo, _, service := setupKeymanagerTest(t)
+		mockRepo.On("GetValidatorKeyByPubkey", pubkey).Return(&models.ValidatorKey{ID: "key", Key: pubkey}, nil)
+		mockRepo.On("UpdateKeyFeeRecipient", "key", testFeeRecipient).Return(nil)
+
+		assert.NoError(t, service.SetFeeRecipient(pubkey, testFeeRecipient))
+	})
+
+	t.Run("set invalid address", func(t *testing.T) {
+		_, _, service := setupKeymanagerTest(t)
+
+		assert.ErrorIs(t, service.SetFeeRecipient(pubkey, "0x1234"), models.ErrInvalidInput)
+	})
+
+	t.Run("delete restores the request's fee recipient", func(t *testing.T) {
+		mockRepo, _, service := setupKeymanagerTest(t)
+		mockRepo.On("GetValidatorKeyByPubkey", pubkey).
+			Return(&models.ValidatorKey{ID: "key", RequestID: "request", Key: pubkey, FeeRecipient: "0xabcdefabcdefabcdefabcdefabcdefabcdefabcd"}, nil)
+		mockRepo.On("GetRequestByID", "request").Return(&models.ValidatorRequest{ID: "request", FeeRecipient: testFeeRecipient}, nil)
+		mockRepo.On("UpdateKeyFeeRecipient", "key", testFeeRecipient).Return(nil)
+
+		assert.NoError(t, service.DeleteFeeRecipient(pubkey))
+	})
+}
+
+// repeatHex fills a pubkey with the given byte.
+func repeatHex(b string) string {
+	return strings.Repeat(b, bls.PublicKeyLength)
+}
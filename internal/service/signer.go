@@ -0,0 +1,313 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"stakeway_test_task/internal/bls"
+	"stakeway_test_task/internal/eth2"
+	"stakeway_test_task/internal/models"
+	"strings"
+)
+
+type SignerRepo interface {
+	GetActiveValidatorKeys() ([]*models.ValidatorKey, error)
+	GetValidatorKeyByPubkey(pubkey string) (*models.ValidatorKey, error)
+}
+
+// SignerService signs consensus messages for validator clients through the Web3Signer API, so that
+// keys held by the service never have to be exported.
+type SignerService struct {
+	repo       SignerRepo
+	logger     *slog.Logger
+	network    *eth2.Network
+	protection *SlashingProtection
+}
+
+func NewSignerService(repo SignerRepo, logger *slog.Logger, network *eth2.Network, protection *SlashingProtection) *SignerService {
+	return &SignerService{repo: repo, logger: logger, network: network, protection: protection}
+}
+
+func (s *SignerService) PublicKeys() ([]string, error) {
+	keys, err := s.repo.GetActiveValidatorKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	pubkeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pubkeys = append(pubkeys, key.Key)
+	}
+	return pubkeys, nil
+}
+
+// Sign returns the signature of the key identified by pubkey over the message in request. Deleted keys
+// are refused like unknown ones, and block proposals and attestations are refused by slashing protection
+// unless they are safe to sign.
+func (s *SignerService) Sign(pubkey string, request *models.SignRequest) ([]byte, error) {
+	normalized, err := normalizePubkey(pubkey)
+	if err != nil {
+		return nil, err
+	}
+
+	signingRoot, err := s.signingRoot(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unknown keys are refused before anything is recorded for them.
+	if _, err := s.signingKey(normalized); err != nil {
+		return nil, err
+	}
+
+	switch request.Type {
+	case models.SignTypeBlockV2:
+		err = s.protection.CheckBlock(normalized, request.BeaconBlock.BlockHeader.Slot, signingRoot)
+	case models.SignTypeAttestation:
+		err = s.protection.CheckAttestation(normalized, request.Attestation.Source.Epoch, request.Attestation.Target.Epoch, signingRoot)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The key is loaded again after the message was recorded: if it was deleted in between, its exported
+	// history may not contain the message.
+	secretKey, err := s.signingKey(normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Debug("Signing message", "type", request.Type, "pubkey", normalized)
+	return secretKey.Sign(signingRoot[:]), nil
+}
+
+func (s *SignerService) signingKey(pubkey string) (*bls.SecretKey, error) {
+	key, err := s.repo.GetValidatorKeyByPubkey(pubkey)
+	if err != nil {
+		return nil, err
+	}
+	if key.Deleted || key.SecretKey == "" {
+		return nil, models.ErrKeyNotFound
+	}
+
+	secretKey, err := decodeSecretKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret key: %w", err)
+	}
+	return secretKey, nil
+}
+
+// signingRoot computes the root to sign from the message itself rather than trusting the signing root
+// the client sent, which only has to agree with it.
+func (s *SignerService) signingRoot(request *models.SignRequest) (eth2.Root, error) {
+	if request.ForkInfo == nil {
+		return eth2.Root{}, fmt.Errorf("%w: fork_info is required", models.ErrInvalidInput)
+	}
+	if eth2.Root(request.ForkInfo.GenesisValidatorsRoot) != s.network.GenesisValidatorsRoot {
+		return eth2.Root{}, fmt.Errorf("%w: fork_info is not for %s", models.ErrInvalidInput, s.network.Name)
+	}
+	fork, err := forkFromInfo(request.ForkInfo)
+	if err != nil {
+		return eth2.Root{}, err
+	}
+
+	objectRoot, domainType, epoch, err := messageRoot(request)
+	if err != nil {
+		return eth2.Root{}, err
+	}
+
+	domain := eth2.ComputeDomain(domainType, fork.Version(epoch), s.network.GenesisValidatorsRoot)
+	signingRoot := eth2.ComputeSigningRoot(objectRoot, domain)
+	if request.SigningRoot != nil && eth2.Root(*request.SigningRoot) != signingRoot {
+		return eth2.Root{}, fmt.Errorf("%w: signingRoot does not match the message", models.ErrInvalidInput)
+	}
+	return signingRoot, nil
+}
+
+// messageRoot returns the hash tree root of the signed object, its signature domain and the epoch
+// that selects the fork version of the domain.
+func messageRoot(request *models.SignRequest) (eth2.Root, eth2.DomainType, uint64, error) {
+	missing := func(field string) error {
+		return fmt.Errorf("%w: %s requires %s", models.ErrInvalidInput, request.Type, field)
+	}
+
+	switch request.Type {
+	case models.SignTypeBlockV2:
+		if request.BeaconBlock == nil || request.BeaconBlock.BlockHeader == nil {
+			return eth2.Root{}, eth2.DomainType{}, 0, missing("beacon_block.block_header")
+		}
+		header := blockHeader(request.BeaconBlock.BlockHeader)
+		return header.HashTreeRoot(), eth2.DomainBeaconProposer, eth2.EpochAtSlot(header.Slot), nil
+
+	case models.SignTypeAttestation:
+		if request.Attestation == nil {
+			return eth2.Root{}, eth2.DomainType{}, 0, missing("attestation")
+		}
+		data := attestationData(request.Attestation)
+		return data.HashTreeRoot(), eth2.DomainBeaconAttester, data.Target.Epoch, nil
+
+	case models.SignTypeAggregationSlot:
+		if request.AggregationSlot == nil {
+			return eth2.Root{}, eth2.DomainType{}, 0, missing("aggregation_slot")
+		}
+		slot := request.AggregationSlot.Slot
+		return eth2.Uint64Root(slot), eth2.DomainSelectionProof, eth2.EpochAtSlot(slot), nil
+
+	case models.SignTypeAggregateAndProof, models.SignTypeAggregateAndProofV2:
+		if len(request.AggregateAndProof) == 0 {
+			return eth2.Root{}, eth2.DomainType{}, 0, missing("aggregate_and_proof")
+		}
+		aggregateAndProof, err := decodeAggregateAndProof(request.Type, request.AggregateAndProof)
+		if err != nil {
+			return eth2.Root{}, eth2.DomainType{}, 0, err
+		}
+		root, err := aggregateAndProof.HashTreeRoot()
+		if err != nil {
+			return eth2.Root{}, eth2.DomainType{}, 0, fmt.Errorf("%w: %w", models.ErrInvalidInput, err)
+		}
+		return root, eth2.DomainAggregateAndProof, eth2.EpochAtSlot(aggregateAndProof.Aggregate.Data.Slot), nil
+
+	case models.SignTypeRandaoReveal:
+		if request.RandaoReveal == nil {
+			return eth2.Root{}, eth2.DomainType{}, 0, missing("randao_reveal")
+		}
+		epoch := request.RandaoReveal.Epoch
+		return eth2.Uint64Root(epoch), eth2.DomainRandao, epoch, nil
+
+	case models.SignTypeSyncCommitteeMessage:
+		if request.SyncCommitteeMessage == nil {
+			return eth2.Root{}, eth2.DomainType{}, 0, missing("sync_committee_message")
+		}
+		message := request.SyncCommitteeMessage
+		return eth2.Root(message.BeaconBlockRoot), eth2.DomainSyncCommittee, eth2.EpochAtSlot(message.Slot), nil
+
+	case models.SignTypeSyncCommitteeSelectionProof:
+		if request.SyncAggregatorSelectionData == nil {
+			return eth2.Root{}, eth2.DomainType{}, 0, missing("sync_aggregator_selection_data")
+		}
+		data := eth2.SyncAggregatorSelectionData{
+			Slot:              request.SyncAggregatorSelectionData.Slot,
+			SubcommitteeIndex: request.SyncAggregatorSelectionData.SubcommitteeIndex,
+		}
+		return data.HashTreeRoot(), eth2.DomainSyncCommitteeSelectionProof, eth2.EpochAtSlot(data.Slot), nil
+
+	case models.SignTypeSyncCommitteeContributionAndProof:
+		if request.ContributionAndProof == nil {
+			return eth2.Root{}, eth2.DomainType{}, 0, missing("contribution_and_proof")
+		}
+		contributionAndProof, err := contributionAndProof(request.ContributionAndProof)
+		if err != nil {
+			return eth2.Root{}, eth2.DomainType{}, 0, err
+		}
+		return contributionAndProof.HashTreeRoot(), eth2.DomainContributionAndProof, eth2.EpochAtSlot(contributionAndProof.Contribution.Slot), nil
+
+	default:
+		return eth2.Root{}, eth2.DomainType{}, 0, fmt.Errorf("%w: unsupported signing type %q", models.ErrInvalidInput, request.Type)
+	}
+}
+
+func forkFromInfo(info *models.ForkInfo) (*eth2.Fork, error) {
+	if len(info.Fork.PreviousVersion) != 4 || len(info.Fork.CurrentVersion) != 4 {
+		return nil, fmt.Errorf("%w: fork versions must be 4 bytes", models.ErrInvalidInput)
+	}
+	return &eth2.Fork{
+		PreviousVersion: [4]byte(info.Fork.PreviousVersion),
+		CurrentVersion:  [4]byte(info.Fork.CurrentVersion),
+		Epoch:           info.Fork.Epoch,
+	}, nil
+}
+
+func blockHeader(header *models.BeaconBlockHeader) *eth2.BeaconBlockHeader {
+	return &eth2.BeaconBlockHeader{
+		Slot:          header.Slot,
+		ProposerIndex: header.ProposerIndex,
+		ParentRoot:    header.ParentRoot,
+		StateRoot:     header.StateRoot,
+		BodyRoot:      header.BodyRoot,
+	}
+}
+
+func attestationData(data *models.AttestationData) *eth2.AttestationData {
+	return &eth2.AttestationData{
+		Slot:            data.Slot,
+		Index:           data.Index,
+		BeaconBlockRoot: data.BeaconBlockRoot,
+		Source:          eth2.Checkpoint{Epoch: data.Source.Epoch, Root: data.Source.Root},
+		Target:          eth2.Checkpoint{Epoch: data.Target.Epoch, Root: data.Target.Root},
+	}
+}
+
+// decodeAggregateAndProof decodes the message of an AGGREGATE_AND_PROOF request, or of the versioned
+// AGGREGATE_AND_PROOF_V2 envelope, whose Electra and later attestations carry committee bits.
+func decodeAggregateAndProof(signType models.SignType, raw json.RawMessage) (*eth2.AggregateAndProof, error) {
+	var message models.AggregateAndProof
+	electra := false
+	if signType == models.SignTypeAggregateAndProofV2 {
+		var versioned models.VersionedAggregateAndProof
+		if err := json.Unmarshal(raw, &versioned); err != nil {
+			return nil, fmt.Errorf("%w: %w", models.ErrInvalidInput, err)
+		}
+		switch strings.ToUpper(versioned.Version) {
+		case "PHASE0", "ALTAIR", "BELLATRIX", "CAPELLA", "DENEB":
+		case "ELECTRA", "FULU":
+			electra = true
+		default:
+			return nil, fmt.Errorf("%w: unsupported version %q", models.ErrInvalidInput, versioned.Version)
+		}
+		message = versioned.Data
+	} else if err := json.Unmarshal(raw, &message); err != nil {
+		return nil, fmt.Errorf("%w: %w", models.ErrInvalidInput, err)
+	}
+
+	aggregate := eth2.Attestation{
+		AggregationBits: message.Aggregate.AggregationBits,
+		Data:            *attestationData(&message.Aggregate.Data),
+	}
+	if err := copySignature(aggregate.Signature[:], message.Aggregate.Signature); err != nil {
+		return nil, err
+	}
+	if electra {
+		if len(message.Aggregate.CommitteeBits) != eth2.MaxCommitteesPerSlot/8 {
+			return nil, fmt.Errorf("%w: committee_bits must be %d bytes", models.ErrInvalidInput, eth2.MaxCommitteesPerSlot/8)
+		}
+		aggregate.CommitteeBits = (*[eth2.MaxCommitteesPerSlot / 8]byte)(message.Aggregate.CommitteeBits)
+	}
+
+	aggregateAndProof := &eth2.AggregateAndProof{AggregatorIndex: message.AggregatorIndex, Aggregate: aggregate}
+	if err := copySignature(aggregateAndProof.SelectionProof[:], message.SelectionProof); err != nil {
+		return nil, err
+	}
+	return aggregateAndProof, nil
+}
+
+func contributionAndProof(message *models.ContributionAndProof) (*eth2.ContributionAndProof, error) {
+	contribution := message.Contribution
+	if len(contribution.AggregationBits) != eth2.SyncSubcommitteeSize/8 {
+		return nil, fmt.Errorf("%w: aggregation_bits must be %d bytes", models.ErrInvalidInput, eth2.SyncSubcommitteeSize/8)
+	}
+
+	contributionAndProof := &eth2.ContributionAndProof{
+		AggregatorIndex: message.AggregatorIndex,
+		Contribution: eth2.SyncCommitteeContribution{
+			Slot:              contribution.Slot,
+			BeaconBlockRoot:   contribution.BeaconBlockRoot,
+			SubcommitteeIndex: contribution.SubcommitteeIndex,
+			AggregationBits:   [eth2.SyncSubcommitteeSize / 8]byte(contribution.AggregationBits),
+		},
+	}
+	if err := copySignature(contributionAndProof.Contribution.Signature[:], contribution.Signature); err != nil {
+		return nil, err
+	}
+	if err := copySignature(contributionAndProof.SelectionProof[:], message.SelectionProof); err != nil {
+		return nil, err
+	}
+	return contributionAndProof, nil
+}
+
+func copySignature(dst, signature []byte) error {
+	if len(signature) != bls.SignatureLength {
+		return fmt.Errorf("%w: signatures must be %d bytes", models.ErrInvalidInput, bls.SignatureLength)
+	}
+	copy(dst, signature)
+	return nil
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"encoding/json"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"os"
+	"stakeway_test_task/internal/bls"
+	"stakeway_test_task/internal/eth2"
+	"stakeway_test_task/internal/mocks"
+	"stakeway_test_task/internal/models"
+	"strings"
+	"testing"
+)
+
+// holeskyForkInfo moved from Deneb (0x05017000) to Electra (0x06017000) at epoch 115968.
+const holeskyForkInfo = `{"fork":{"previous_version":"0x05017000","current_version":"0x06017000","epoch":"115968"},` +
+	`"genesis_validators_root":"0x9143aa7c615a7f7115e2b6aac319c03529df8242ae705fba9df39b79c59fa8b1"}`
+
+func setupSignerTest(t *testing.T) (*mocks.SignerRepo, *mocks.SlashingRepo, *SignerService, *eth2.Network) {
+	mockRepo := mocks.NewSignerRepo(t)
+	mockSlashingRepo := mocks.NewSlashingRepo(t)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	network, err := eth2.NetworkByName("holesky")
+	require.NoError(t, err)
+
+	return mockRepo, mockSlashingRepo, NewSignerService(mockRepo, logger, network, NewSlashingProtection(mockSlashingRepo, network)), network
+}
+
+func signerKey(t *testing.T) (*bls.SecretKey, *models.ValidatorKey) {
+	secretKey, err := bls.DeriveKey(testSeed, bls.SigningKeyPath(0))
+	require.NoError(t, err)
+
+	return secretKey, &models.ValidatorKey{
+		ID:        "key",
+		Key:       hexutil.Encode(secretKey.PublicKey()),
+		SecretKey: hexutil.Encode(secretKey.Bytes()),
+	}
+}
+
+func signRequest(t *testing.T, body string) *models.SignRequest {
+	var request models.SignRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &request))
+	return &request
+}
+
+func attestationDataJSON(targetEpoch string) string {
+	return `{"slot":"3711000","index":"0","beacon_block_root":"0x0101010101010101010101010101010101010101010101010101010101010101",` +
+		`"source":{"epoch":"115967","root":"0x0202020202020202020202020202020202020202020202020202020202020202"},` +
+		`"target":{"epoch":"` + targetEpoch + `","root":"0x0303030303030303030303030303030303030303030303030303030303030303"}}`
+}
+
+func attestationRequest(targetEpoch string) string {
+	return `{"type":"ATTESTATION","fork_info":` + holeskyForkInfo + `,"attestation":` + attestationDataJSON(targetEpoch) + `}`
+}
+
+func TestSignerPublicKeys(t *testing.T) {
+	mockRepo, _, service, _ := setupSignerTest(t)
+
+	mockRepo.On("GetActiveValidatorKeys").Return([]*models.ValidatorKey{{Key: "0xaa"}, {Key: "0xbb"}}, nil)
+
+	pubkeys, err := service.PublicKeys()
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0xaa", "0xbb"}, pubkeys)
+}
+
+func TestSign(t *testing.T) {
+	secretKey, key := signerKey(t)
+
+	t.Run("attestation uses the fork version of its target epoch", func(t *testing.T) {
+		for targetEpoch, forkVersion := range map[string][4]byte{
+			"115967": {0x05, 0x01, 0x70, 0x00},
+			"115968": {0x06, 0x01, 0x70, 0x00},
+		} {
+			mockRepo, mockSlashingRepo, service, network := setupSignerTest(t)
+			mockRepo.On("GetValidatorKeyByPubkey", key.Key).Return(key, nil)
+			mockSlashingRepo.On("GetAttestationWatermark", key.Key).Return(nil, nil)
+			mockSlashingRepo.On("SaveSignedAttestation", key.Key, mock.Anything).Return(nil)
+			request := signRequest(t, attestationRequest(targetEpoch))
+
+			signature, err := service.Sign(key.Key, request)
+			require.NoError(t, err)
+
+			data := attestationData(request.Attestation)
+			domain := eth2.ComputeDomain(eth2.DomainBeaconAttester, forkVersion, network.GenesisValidatorsRoot)
+			signingRoot := eth2.ComputeSigningRoot(data.HashTreeRoot(), domain)
+			assert.True(t, bls.Verify(secretKey.PublicKey(), signingRoot[:], signature), "target epoch %s", targetEpoch)
+		}
+	})
+
+	t.Run("block proposal", func(t *testing.T) {
+		mockRepo, mockSlashingRepo, service, network := setupSignerTest(t)
+		mockRepo.On("GetValidatorKeyByPubkey", key.Key).Return(key, nil)
+		mockSlashingRepo.On("GetBlockWatermark", key.Key).Return(&models.SignedBlock{Slot: 3710999}, nil)
+		mockSlashingRepo.On("SaveSignedBlock", key.Key, mock.Anything).Return(nil)
+
+		request := signRequest(t, `{"type":"BLOCK_V2","fork_info":`+holeskyForkInfo+`,"beacon_block":{"version":"ELECTRA","block_header":`+
+			`{"slot":"3711000","proposer_index":"42","parent_root":"0x0101010101010101010101010101010101010101010101010101010101010101",`+
+			`"state_root":"0x0202020202020202020202020202020202020202020202020202020202020202",`+
+			`"body_root":"0x0303030303030303030303030303030303030303030303030303030303030303"}}}`)
+
+		signature, err := service.Sign(key.Key, request)
+		require.NoError(t, err)
+
+		header := blockHeader(request.BeaconBlock.BlockHeader)
+		domain := eth2.ComputeDomain(eth2.DomainBeaconProposer, [4]byte{0x06, 0x01, 0x70, 0x00}, network.GenesisValidatorsRoot)
+		signingRoot := eth2.ComputeSigningRoot(header.HashTreeRoot(), domain)
+		assert.True(t, bls.Verify(secretKey.PublicKey(), signingRoot[:], signature))
+
+		// The signing root sent by the client is accepted when it matches the message.
+		request.SigningRoot = (*common.Hash)(&signingRoot)
+		_, err = service.Sign(key.Key, request)
+		assert.NoError(t, err)
+	})
+
+	t.Run("selection proofs and sync committee messages", func(t *testing.T) {
+		mockRepo, _, service, _ := setupSignerTest(t)
+		mockRepo.On("GetValidatorKeyByPubkey", key.Key).Return(key, nil)
+
+		signature := hexutil.Encode(make([]byte, bls.SignatureLength))
+		for _, body := range []string{
+			`{"type":"AGGREGATION_SLOT","aggregation_slot":{"slot":"3711000"}}`,
+			`{"type":"RANDAO_REVEAL","randao_reveal":{"epoch":"115968"}}`,
+			`{"type":"SYNC_COMMITTEE_MESSAGE","sync_committee_message":{"slot":"3711000",` +
+				`"beacon_block_root":"0x0101010101010101010101010101010101010101010101010101010101010101"}}`,
+			`{"type":"SYNC_COMMITTEE_SELECTION_PROOF","sync_aggregator_selection_data":{"slot":"3711000","subcommittee_index":"1"}}`,
+			`{"type":"SYNC_COMMITTEE_CONTRIBUTION_AND_PROOF","contribution_and_proof":{"aggregator_index":"7","selection_proof":"` + signature + `",` +
+				`"contribution":{"slot":"3711000","beacon_block_root":"0x0101010101010101010101010101010101010101010101010101010101010101",` +
+				`"subcommittee_index":"1","aggregation_bits":"0xffffffffffffffffffffffffffffffff","signature":"` + signature + `"}}}`,
+			`{"type":"AGGREGATE_AND_PROOF","aggregate_and_proof":{"aggregator_index":"7","selection_proof":"` + signature + `",` +
+				`"aggregate":{"aggregation_bits":"0x0101","signature":"` + signature + `","data":` + attestationDataJSON("115968") + `}}}`,
+		} {
+			request := signRequest(t, strings.Replace(body, `{"type":`, `{"fork_info":`+holeskyForkInfo+`,"type":`, 1))
+
+			_, err := service.Sign(key.Key, request)
+
+			assert.NoError(t, err, request.Type)
+		}
+	})
+
+	t.Run("electra aggregates need committee bits", func(t *testing.T) {
+		_, _, service, _ := setupSignerTest(t)
+		signature := hexutil.Encode(make([]byte, bls.SignatureLength))
+
+		request := signRequest(t, `{"type":"AGGREGATE_AND_PROOF_V2","fork_info":`+holeskyForkInfo+`,"aggregate_and_proof":{"version":"ELECTRA",`+
+			`"data":{"aggregator_index":"7","selection_proof":"`+signature+`","aggregate":{"aggregation_bits":"0x0101","signature":"`+signature+`"}}}}`)
+
+		_, err := service.Sign(key.Key, request)
+
+		assert.ErrorIs(t, err, models.ErrInvalidInput)
+		assert.ErrorContains(t, err, "committee_bits")
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, _, service, _ := setupSignerTest(t)
+
+		for name, body := range map[string]string{
+			"missing fork info": `{"type":"RANDAO_REVEAL","randao_reveal":{"epoch":"1"}}`,
+			"other network":     strings.Replace(attestationRequest("115968"), "0x9143aa7c", "0x4b363db9", 1),
+			"missing message":   `{"type":"BLOCK_V2","fork_info":` + holeskyForkInfo + `}`,
+			"unsupported type":  `{"type":"VOLUNTARY_EXIT","fork_info":` + holeskyForkInfo + `}`,
+			"wrong signing root": strings.Replace(attestationRequest("115968"), `{"type":"ATTESTATION"`,
+				`{"type":"ATTESTATION","signingRoot":"0x0404040404040404040404040404040404040404040404040404040404040404"`, 1),
+		} {
+			_, err := service.Sign(key.Key, signRequest(t, body))
+
+			assert.ErrorIs(t, err, models.ErrInvalidInput, name)
+		}
+	})
+
+	t.Run("deleted keys are not used", func(t *testing.T) {
+		mockRepo, _, service, _ := setupSignerTest(t)
+		deleted := *key
+		deleted.Deleted = true
+		mockRepo.On("GetValidatorKeyByPubkey", key.Key).Return(&deleted, nil)
+
+		_, err := service.Sign(key.Key, signRequest(t, attestationRequest("115968")))
+
+		assert.ErrorIs(t, err, models.ErrKeyNotFound)
+	})
+}
+
+func TestSignSlashingProtection(t *testing.T) {
+	_, key := signerKey(t)
+
+	t.Run("attestation below the watermark", func(t *testing.T) {
+		mockRepo, mockSlashingRepo, service, _ := setupSignerTest(t)
+		mockRepo.On("GetValidatorKeyByPubkey", key.Key).Return(key, nil)
+		mockSlashingRepo.On("GetAttestationWatermark", key.Key).Return(&models.SignedAttestation{SourceEpoch: 115967, TargetEpoch: 115968}, nil)
+
+		signature, err := service.Sign(key.Key, signRequest(t, attestationRequest("115968")))
+
+		assert.ErrorIs(t, err, models.ErrSlashable)
+		assert.Nil(t, signature)
+	})
+
+	t.Run("unknown keys record nothing", func(t *testing.T) {
+		mockRepo, _, service, _ := setupSignerTest(t)
+		mockRepo.On("GetValidatorKeyByPubkey", key.Key).Return(nil, models.ErrKeyNotFound)
+
+		_, err := service.Sign(key.Key, signRequest(t, attestationRequest("115968")))
+
+		assert.ErrorIs(t, err, models.ErrKeyNotFound)
+	})
+
+	t.Run("key deleted while the message was recorded", func(t *testing.T) {
+		mockRepo, mockSlashingRepo, service, _ := setupSignerTest(t)
+		deleted := *key
+		deleted.Deleted = true
+		mockRepo.On("GetValidatorKeyByPubkey", key.Key).Return(key, nil).Once()
+		mockRepo.On("GetValidatorKeyByPubkey", key.Key).Return(&deleted, nil).Once()
+		mockSlashingRepo.On("GetAttestationWatermark", key.Key).Return(nil, nil)
+		mockSlashingRepo.On("SaveSignedAttestation", key.Key, mock.Anything).Return(nil)
+
+		_, err := service.Sign(key.Key, signRequest(t, attestationRequest("115968")))
+
+		assert.ErrorIs(t, err, models.ErrKeyNotFound)
+	})
+}
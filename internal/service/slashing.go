@@ -0,0 +1,162 @@
+package services
+
+import (
+	"fmt"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"stakeway_test_task/internal/eth2"
+	"stakeway_test_task/internal/models"
+	"sync"
+)
+
+// interchangeFormatVersion is the EIP-3076 interchange format version written by the service.
+const interchangeFormatVersion = "5"
+
+type SlashingRepo interface {
+	GetBlockWatermark(pubkey string) (*models.SignedBlock, error)
+	GetAttestationWatermark(pubkey string) (*models.SignedAttestation, error)
+	SaveSignedBlock(pubkey string, block *models.SignedBlock) error
+	SaveSignedAttestation(pubkey string, attestation *models.SignedAttestation) error
+	GetSlashingProtection(pubkeys []string) ([]models.InterchangeData, error)
+	ImportSlashingProtection(data []models.InterchangeData) error
+}
+
+// SlashingProtection keeps the signing history of every key and refuses messages that could get a
+// validator slashed. Instead of searching the history for conflicting messages it only lets slots and
+// epochs move forward, which rules out double proposals, double votes and surround votes, and also
+// works with interchange data that only holds the latest messages of each key.
+type SlashingProtection struct {
+	repo    SlashingRepo
+	network *eth2.Network
+	// mu makes checking a message and recording it atomic.
+	mu sync.Mutex
+}
+
+func NewSlashingProtection(repo SlashingRepo, network *eth2.Network) *SlashingProtection {
+	return &SlashingProtection{repo: repo, network: network}
+}
+
+// CheckBlock records a block proposal, refusing it unless its slot is above every slot signed before.
+// The last block may be signed again.
+func (p *SlashingProtection) CheckBlock(pubkey string, slot uint64, signingRoot eth2.Root) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	root := hexutil.Encode(signingRoot[:])
+	latest, err := p.repo.GetBlockWatermark(pubkey)
+	if err != nil {
+		return err
+	}
+	if latest != nil {
+		if slot == latest.Slot && root == latest.SigningRoot {
+			return nil
+		}
+		if slot <= latest.Slot {
+			return fmt.Errorf("%w: block at slot %d, a block at slot %d was already signed", models.ErrSlashable, slot, latest.Slot)
+		}
+	}
+	return p.repo.SaveSignedBlock(pubkey, &models.SignedBlock{Slot: slot, SigningRoot: root})
+}
+
+// CheckAttestation records an attestation, refusing it unless its target epoch is above and its source
+// epoch is not below those of every attestation signed before. The last attestation may be signed again.
+func (p *SlashingProtection) CheckAttestation(pubkey string, sourceEpoch, targetEpoch uint64, signingRoot eth2.Root) error {
+	if sourceEpoch > targetEpoch {
+		return fmt.Errorf("%w: source epoch %d is after target epoch %d", models.ErrInvalidInput, sourceEpoch, targetEpoch)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	root := hexutil.Encode(signingRoot[:])
+	latest, err := p.repo.GetAttestationWatermark(pubkey)
+	if err != nil {
+		return err
+	}
+	if latest != nil {
+		if targetEpoch == latest.TargetEpoch && root == latest.SigningRoot {
+			return nil
+		}
+		if targetEpoch <= latest.TargetEpoch {
+			return fmt.Errorf("%w: attestation for target epoch %d, target epoch %d was already signed", models.ErrSlashable, targetEpoch, latest.TargetEpoch)
+		}
+		if sourceEpoch < latest.SourceEpoch {
+			return fmt.Errorf("%w: attestation with source epoch %d, source epoch %d was already signed", models.ErrSlashable, sourceEpoch, latest.SourceEpoch)
+		}
+	}
+	return p.repo.SaveSignedAttestation(pubkey, &models.SignedAttestation{SourceEpoch: sourceEpoch, TargetEpoch: targetEpoch, SigningRoot: root})
+}
+
+// Export returns the EIP-3076 interchange data of pubkeys, or of every key with history if pubkeys is nil.
+func (p *SlashingProtection) Export(pubkeys []string) (*models.Interchange, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	data, err := p.repo.GetSlashingProtection(pubkeys)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Interchange{
+		Metadata: models.InterchangeMetadata{
+			InterchangeFormatVersion: interchangeFormatVersion,
+			GenesisValidatorsRoot:    hexutil.Encode(p.network.GenesisValidatorsRoot[:]),
+		},
+		Data: data,
+	}, nil
+}
+
+// Import merges EIP-3076 interchange data into the signing history. Nothing is imported if any of it
+// is invalid.
+func (p *SlashingProtection) Import(interchange *models.Interchange) error {
+	if interchange.Metadata.InterchangeFormatVersion != interchangeFormatVersion {
+		return fmt.Errorf("%w: unsupported interchange format version %q", models.ErrInvalidInput, interchange.Metadata.InterchangeFormatVersion)
+	}
+	genesisValidatorsRoot, err := hexutil.Decode(interchange.Metadata.GenesisValidatorsRoot)
+	if err != nil || len(genesisValidatorsRoot) != len(eth2.Root{}) || eth2.Root(genesisValidatorsRoot) != p.network.GenesisValidatorsRoot {
+		return fmt.Errorf("%w: slashing protection data is not for %s", models.ErrInvalidInput, p.network.Name)
+	}
+
+	data := make([]models.InterchangeData, len(interchange.Data))
+	for i, record := range interchange.Data {
+		pubkey, err := normalizePubkey(record.Pubkey)
+		if err != nil {
+			return err
+		}
+		data[i] = models.InterchangeData{Pubkey: pubkey}
+
+		for _, block := range record.SignedBlocks {
+			if block.SigningRoot, err = normalizeSigningRoot(block.SigningRoot); err != nil {
+				return err
+			}
+			data[i].SignedBlocks = append(data[i].SignedBlocks, block)
+		}
+		for _, attestation := range record.SignedAttestations {
+			if attestation.SourceEpoch > attestation.TargetEpoch {
+				return fmt.Errorf("%w: attestation source epoch %d is after its target epoch %d", models.ErrInvalidInput, attestation.SourceEpoch, attestation.TargetEpoch)
+			}
+			if attestation.SigningRoot, err = normalizeSigningRoot(attestation.SigningRoot); err != nil {
+				return err
+			}
+			data[i].SignedAttestations = append(data[i].SignedAttestations, attestation)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.repo.ImportSlashingProtection(data)
+}
+
+// normalizeSigningRoot returns root as lowercase hex. A zero root is how some clients export an unknown
+// signing root, so it is dropped like a missing one.
+func normalizeSigningRoot(root string) (string, error) {
+	if root == "" {
+		return "", nil
+	}
+	decoded, err := hexutil.Decode(root)
+	if err != nil || len(decoded) != len(eth2.Root{}) {
+		return "", fmt.Errorf("%w: invalid signing root %q", models.ErrInvalidInput, root)
+	}
+	if eth2.Root(decoded) == (eth2.Root{}) {
+		return "", nil
+	}
+	return hexutil.Encode(decoded), nil
+}
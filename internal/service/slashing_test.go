@@ -0,0 +1,173 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"stakeway_test_task/internal/eth2"
+	"stakeway_test_task/internal/mocks"
+	"stakeway_test_task/internal/models"
+	"strings"
+	"testing"
+)
+
+func setupSlashingTest(t *testing.T) (*mocks.SlashingRepo, *SlashingProtection) {
+	mockRepo := mocks.NewSlashingRepo(t)
+
+	network, err := eth2.NetworkByName("holesky")
+	require.NoError(t, err)
+
+	return mockRepo, NewSlashingProtection(mockRepo, network)
+}
+
+func TestCheckBlock(t *testing.T) {
+	pubkey := "0x" + repeatHex("aa")
+	signed := eth2.Root{0x01}
+	signedRoot := "0x01" + strings.Repeat("00", 31)
+
+	tests := []struct {
+		name        string
+		slot        uint64
+		signingRoot eth2.Root
+		expected    error
+		saved       bool
+	}{
+		{"later slot", 101, eth2.Root{0x02}, nil, true},
+		{"same block again", 100, signed, nil, false},
+		{"double proposal", 100, eth2.Root{0x02}, models.ErrSlashable, false},
+		{"earlier slot", 99, eth2.Root{0x02}, models.ErrSlashable, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo, protection := setupSlashingTest(t)
+			mockRepo.On("GetBlockWatermark", pubkey).Return(&models.SignedBlock{Slot: 100, SigningRoot: signedRoot}, nil)
+			if tt.saved {
+				mockRepo.On("SaveSignedBlock", pubkey, &models.SignedBlock{Slot: tt.slot, SigningRoot: "0x02" + strings.Repeat("00", 31)}).Return(nil)
+			}
+
+			err := protection.CheckBlock(pubkey, tt.slot, tt.signingRoot)
+
+			if tt.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expected)
+			}
+		})
+	}
+
+	t.Run("first block", func(t *testing.T) {
+		mockRepo, protection := setupSlashingTest(t)
+		mockRepo.On("GetBlockWatermark", pubkey).Return(nil, nil)
+		mockRepo.On("SaveSignedBlock", pubkey, mock.Anything).Return(nil)
+
+		assert.NoError(t, protection.CheckBlock(pubkey, 0, signed))
+	})
+}
+
+func TestCheckAttestation(t *testing.T) {
+	pubkey := "0x" + repeatHex("aa")
+	signedRoot := "0x01" + strings.Repeat("00", 31)
+
+	tests := []struct {
+		name        string
+		source      uint64
+		target      uint64
+		signingRoot eth2.Root
+		expected    error
+		saved       bool
+	}{
+		{"next epoch", 11, 12, eth2.Root{0x02}, nil, true},
+		{"same attestation again", 10, 11, eth2.Root{0x01}, nil, false},
+		{"double vote", 10, 11, eth2.Root{0x02}, models.ErrSlashable, false},
+		{"earlier target", 9, 10, eth2.Root{0x02}, models.ErrSlashable, false},
+		{"surround vote", 9, 12, eth2.Root{0x02}, models.ErrSlashable, false},
+		{"source after target", 13, 12, eth2.Root{0x02}, models.ErrInvalidInput, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo, protection := setupSlashingTest(t)
+			mockRepo.On("GetAttestationWatermark", pubkey).Return(&models.SignedAttestation{SourceEpoch: 10, TargetEpoch: 11, SigningRoot: signedRoot}, nil).Maybe()
+			if tt.saved {
+				mockRepo.On("SaveSignedAttestation", pubkey, &models.SignedAttestation{SourceEpoch: tt.source, TargetEpoch: tt.target, SigningRoot: "0x02" + strings.Repeat("00", 31)}).Return(nil)
+			}
+
+			err := protection.CheckAttestation(pubkey, tt.source, tt.target, tt.signingRoot)
+
+			if tt.expected == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.expected)
+			}
+		})
+	}
+
+	t.Run("repository error", func(t *testing.T) {
+		mockRepo, protection := setupSlashingTest(t)
+		mockRepo.On("GetAttestationWatermark", pubkey).Return(nil, errors.New("database error"))
+
+		assert.ErrorContains(t, protection.CheckAttestation(pubkey, 10, 11, eth2.Root{}), "database error")
+	})
+}
+
+func TestImportSlashingProtection(t *testing.T) {
+	pubkey := "0x" + repeatHex("aa")
+
+	interchange := func(t *testing.T, genesisValidatorsRoot, record string) *models.Interchange {
+		var interchange models.Interchange
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"metadata": {"interchange_format_version": "5", "genesis_validators_root": "`+genesisValidatorsRoot+`"},
+			"data": [`+record+`]
+		}`), &interchange))
+		return &interchange
+	}
+	holesky := "0x9143aa7c615a7f7115e2b6aac319c03529df8242ae705fba9df39b79c59fa8b1"
+
+	t.Run("records are normalized", func(t *testing.T) {
+		mockRepo, protection := setupSlashingTest(t)
+		mockRepo.On("ImportSlashingProtection", []models.InterchangeData{{
+			Pubkey:             pubkey,
+			SignedBlocks:       []models.SignedBlock{{Slot: 81952, SigningRoot: "0x" + strings.Repeat("ab", 32)}, {Slot: 81953}},
+			SignedAttestations: []models.SignedAttestation{{SourceEpoch: 2290, TargetEpoch: 3007}},
+		}}).Return(nil)
+
+		err := protection.Import(interchange(t, holesky, `{
+			"pubkey": "0x`+repeatHex("AA")+`",
+			"signed_blocks": [
+				{"slot": "81952", "signing_root": "0x`+strings.Repeat("AB", 32)+`"},
+				{"slot": "81953", "signing_root": "0x`+strings.Repeat("00", 32)+`"}
+			],
+			"signed_attestations": [{"source_epoch": "2290", "target_epoch": "3007"}]
+		}`))
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		_, protection := setupSlashingTest(t)
+
+		for name, data := range map[string]*models.Interchange{
+			"other network":       interchange(t, "0x4b363db94e286120d76eb905340fdd4e54bfe9f06bf33ff6cf5ad27f511bfe95", `{"pubkey": "`+pubkey+`"}`),
+			"invalid pubkey":      interchange(t, holesky, `{"pubkey": "0x1234"}`),
+			"invalid root":        interchange(t, holesky, `{"pubkey": "`+pubkey+`", "signed_blocks": [{"slot": "1", "signing_root": "0x1234"}]}`),
+			"source after target": interchange(t, holesky, `{"pubkey": "`+pubkey+`", "signed_attestations": [{"source_epoch": "2", "target_epoch": "1"}]}`),
+			"old format":          {Metadata: models.InterchangeMetadata{InterchangeFormatVersion: "4", GenesisValidatorsRoot: holesky}},
+		} {
+			assert.ErrorIs(t, protection.Import(data), models.ErrInvalidInput, name)
+		}
+	})
+}
+
+func TestExportSlashingProtection(t *testing.T) {
+	mockRepo, protection := setupSlashingTest(t)
+	data := []models.InterchangeData{{Pubkey: "0x" + repeatHex("aa"), SignedBlocks: []models.SignedBlock{{Slot: 1}}}}
+	mockRepo.On("GetSlashingProtection", []string(nil)).Return(data, nil)
+
+	interchange, err := protection.Export(nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "5", interchange.Metadata.InterchangeFormatVersion)
+	assert.Equal(t, "0x9143aa7c615a7f7115e2b6aac319c03529df8242ae705fba9df39b79c59fa8b1", interchange.Metadata.GenesisValidatorsRoot)
+	assert.Equal(t, data, interchange.Data)
+}
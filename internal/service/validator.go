@@ -1,60 +1,124 @@
 package services
 
 import (
-	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
 	"log/slog"
 	"regexp"
+	"stakeway_test_task/internal/bls"
+	"stakeway_test_task/internal/eth2"
+	"stakeway_test_task/internal/keystore"
 	"stakeway_test_task/internal/models"
 	"stakeway_test_task/internal/repository"
 	"stakeway_test_task/internal/utils"
+	"sync"
 	"time"
 )
 
 type RequestRepo interface {
 	CreateRequest(request *models.ValidatorRequest) error
 	GetRequestByID(id string) (*models.ValidatorRequest, error)
-	GetKeysByRequestID(requestID string) ([]string, error)
+	GetValidatorKeysByRequestID(requestID string) ([]*models.ValidatorKey, error)
+	NextKeyIndex() (int, error)
 	UpdateRequestStatus(id string, status models.Status, errorMessage string) error
 	SaveValidatorKey(key *models.ValidatorKey) error
+	UpdateKeyDeposit(keyID string, deposit *models.KeyDeposit) error
+	ClaimJob(stage models.JobStage) (*models.Job, error)
+	UpdateJobStatus(requestID string, status models.JobStatus, errorMessage string) error
+	RequeueJob(requestID string, stage models.JobStage) error
+	GetJobsByStatus(status models.JobStatus) ([]*models.Job, error)
+	CountJobs(stage models.JobStage, status models.JobStatus) (int, error)
+	EnqueueUnfinishedRequests() (int64, error)
 }
 
 type ValidatorService struct {
-	repo   RequestRepo
-	logger *slog.Logger
+	repo    RequestRepo
+	logger  *slog.Logger
+	seed    []byte
+	network *eth2.Network
+	// deposits is nil unless the server is configured with a funding wallet.
+	deposits DepositSubmitter
+	// beacon is nil unless the server is configured with a beacon node.
+	beacon BeaconNode
+
+	jobLimits JobLimits
+	// wake tells the idle job workers of a stage that a job is pending.
+	wake map[models.JobStage]chan struct{}
+
+	// indexMu serializes key index reservation so concurrent requests never share a derivation path.
+	indexMu sync.Mutex
 }
 
-func NewValidatorService(repo *repository.ValidatorRepository, slog *slog.Logger) *ValidatorService {
-	return &ValidatorService{repo: repo, logger: slog}
+// NewValidatorService returns the service; deposits may be nil to disable the deposit stage, and beaconNode
+// to disable validator index lookups and exit broadcasts.
+func NewValidatorService(repo *repository.ValidatorRepository, slog *slog.Logger, seed []byte, network *eth2.Network, deposits DepositSubmitter, beaconNode BeaconNode, jobLimits JobLimits) *ValidatorService {
+	return &ValidatorService{repo: repo, logger: slog, seed: seed, network: network, deposits: deposits, beacon: beaconNode, jobLimits: jobLimits, wake: newWakeChannels()}
 }
 
+// maxValidatorsPerRequest bounds the keys of a request. The queue limit counts requests, so without it a
+// single request could keep a key worker busy for as long as it likes.
+const maxValidatorsPerRequest = 100
+
 func (s *ValidatorService) CreateValidatorRequest(input *models.ValidatorRequestInput) (*models.ValidatorRequestResponse, error) {
 	if input.NumValidators <= 0 {
 		return nil, fmt.Errorf("number of validators must be positive")
 	}
+	if input.NumValidators > maxValidatorsPerRequest {
+		return nil, fmt.Errorf("%w: number of validators must be at most %d", models.ErrInvalidInput, maxValidatorsPerRequest)
+	}
 
 	if !isValidEthereumAddress(input.FeeRecipient) {
 		return nil, fmt.Errorf("invalid Ethereum address format")
 	}
 
+	credentials, err := requestWithdrawalCredentials(input)
+	if err != nil {
+		return nil, err
+	}
+	var withdrawalCredentials string
+	maxAmount := eth2.MaxEffectiveBalance
+	if credentials != nil {
+		withdrawalCredentials = hexutil.Encode(credentials[:])
+		if credentials[0] == eth2.CompoundingWithdrawalPrefix {
+			maxAmount = eth2.MaxEffectiveBalanceElectra
+		}
+	}
+
+	amount := input.Amount
+	if amount == 0 {
+		amount = eth2.MaxEffectiveBalance
+	}
+	if amount < eth2.MinDepositAmount || amount > maxAmount {
+		return nil, fmt.Errorf("deposit amount must be between %d and %d gwei", eth2.MinDepositAmount, maxAmount)
+	}
+
+	if input.Deposit && s.deposits == nil {
+		return nil, fmt.Errorf("deposit submission is not enabled on this server")
+	}
+
 	requestID := uuid.New().String()
 	request := &models.ValidatorRequest{
-		ID:            requestID,
-		NumValidators: input.NumValidators,
-		FeeRecipient:  input.FeeRecipient,
-		Status:        models.StatusStarted,
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		ID:                    requestID,
+		NumValidators:         input.NumValidators,
+		FeeRecipient:          input.FeeRecipient,
+		WithdrawalCredentials: withdrawalCredentials,
+		Amount:                amount,
+		Deposit:               input.Deposit,
+		Status:                models.StatusStarted,
+		CreatedAt:             time.Now(),
+		UpdatedAt:             time.Now(),
 	}
 
-	err := s.repo.CreateRequest(request)
+	err = s.reserveAndCreateRequest(request)
 	if err != nil {
 		return nil, err
 	}
 
-	go s.processValidatorCreation(requestID, input.NumValidators, input.FeeRecipient)
+	s.wakeJobWorkers(models.JobStageKeys)
 
 	return &models.ValidatorRequestResponse{
 		RequestID: requestID,
@@ -62,6 +126,32 @@ func (s *ValidatorService) CreateValidatorRequest(input *models.ValidatorRequest
 	}, nil
 }
 
+// reserveAndCreateRequest also checks the queue under indexMu, so concurrent requests cannot overfill it.
+func (s *ValidatorService) reserveAndCreateRequest(request *models.ValidatorRequest) error {
+	s.indexMu.Lock()
+	defer s.indexMu.Unlock()
+
+	pending, err := s.repo.CountJobs(models.JobStageKeys, models.JobStatusPending)
+	if err != nil {
+		return err
+	}
+	if pending >= s.jobLimits.QueueSize {
+		return fmt.Errorf("%w: %d requests are waiting to be processed", models.ErrQueueFull, pending)
+	}
+
+	startIndex, err := s.repo.NextKeyIndex()
+	if err != nil {
+		return err
+	}
+	request.StartIndex = startIndex
+
+	if err := s.repo.CreateRequest(request); err != nil {
+		return err
+	}
+	utils.JobQueueDepth.Set(float64(pending + 1))
+	return nil
+}
+
 func (s *ValidatorService) GetRequestStatus(requestID string) (*models.ValidatorStatusResponse, error) {
 	request, err := s.repo.GetRequestByID(requestID)
 	if err != nil {
@@ -72,52 +162,245 @@ func (s *ValidatorService) GetRequestStatus(requestID string) (*models.Validator
 		Status: request.Status,
 	}
 
-	if request.Status == models.StatusSuccessful {
-		keys, err := s.repo.GetKeysByRequestID(requestID)
+	if request.Status.KeysReady() {
+		keys, err := s.repo.GetValidatorKeysByRequestID(requestID)
 		if err != nil {
 			return nil, err
 		}
-		response.Keys = keys
-	} else if request.Status == models.StatusFailed {
+		for _, key := range keys {
+			response.Keys = append(response.Keys, key.Key)
+			if key.Deposit != nil {
+				response.Deposits = append(response.Deposits, &models.ValidatorDeposit{Key: key.Key, KeyDeposit: *key.Deposit})
+			}
+			if key.Beacon != nil {
+				response.Beacon = append(response.Beacon, &models.ValidatorBeacon{Key: key.Key, BeaconState: *key.Beacon})
+			}
+		}
+	}
+	if request.Status == models.StatusFailed || request.Status == models.StatusDepositFailed {
 		response.Message = request.ErrorMessage
 	}
 
 	return response, nil
 }
 
-func (s *ValidatorService) processValidatorCreation(requestID string, numValidators int, feeRecipient string) {
+// keystoreParallelism bounds the keystores encrypted or decrypted at the same time by one request. It is
+// not derived from runtime.NumCPU, which reports the node's CPUs rather than the container's CPU quota.
+const keystoreParallelism = 2
+
+// maxScryptKeystores bounds the keys of a scrypt export. A scrypt keystore takes about a second to derive and
+// only keystore.ScryptConcurrency are derived at a time, so larger exports would outlast the server's 15s
+// WriteTimeout. PBKDF2 keystores are an order of magnitude faster.
+const maxScryptKeystores = 8 * keystore.ScryptConcurrency
+
+func (s *ValidatorService) GetKeystores(requestID, password, kdf string) ([]*keystore.Keystore, error) {
+	_, keys, err := s.getReadyKeys(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	parallelism := keystoreParallelism
+	if kdf == keystore.KDFScrypt {
+		if len(keys) > maxScryptKeystores {
+			return nil, fmt.Errorf("%w: scrypt keystores can be exported for at most %d keys, use kdf=pbkdf2 for %d keys",
+				models.ErrInvalidInput, maxScryptKeystores, len(keys))
+		}
+		parallelism = min(parallelism, keystore.ScryptConcurrency)
+	}
+
+	keystores := make([]*keystore.Keystore, len(keys))
+
+	// Key stretching is deliberately slow, so keys are encrypted in parallel.
+	var g errgroup.Group
+	g.SetLimit(parallelism)
+	for i, key := range keys {
+		g.Go(func() error {
+			secretKey, err := decodeSecretKey(key)
+			if err != nil {
+				return err
+			}
+
+			keystores[i], err = keystore.Encrypt(secretKey.Bytes(), secretKey.PublicKey(), bls.SigningKeyPath(key.KeyIndex), password, kdf)
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return keystores, nil
+}
+
+func (s *ValidatorService) GetDepositData(requestID string) ([]*models.DepositData, error) {
+	request, keys, err := s.getReadyKeys(requestID)
+	if err != nil {
+		return nil, err
+	}
+	return s.depositData(request, keys)
+}
+
+func (s *ValidatorService) depositData(request *models.ValidatorRequest, keys []*models.ValidatorKey) ([]*models.DepositData, error) {
+	amount := request.Amount
+	if amount == 0 {
+		amount = eth2.MaxEffectiveBalance
+	}
+
+	depositData := make([]*models.DepositData, 0, len(keys))
+	for _, key := range keys {
+		secretKey, err := decodeSecretKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		withdrawalCredentials, err := s.withdrawalCredentials(request, key)
+		if err != nil {
+			return nil, err
+		}
+
+		data := eth2.SignDeposit(secretKey, withdrawalCredentials, amount, s.network)
+		messageRoot := data.Message().HashTreeRoot()
+		dataRoot := data.HashTreeRoot()
+
+		depositData = append(depositData, &models.DepositData{
+			Pubkey:                hex.EncodeToString(data.Pubkey[:]),
+			WithdrawalCredentials: hex.EncodeToString(data.WithdrawalCredentials[:]),
+			Amount:                data.Amount,
+			Signature:             hex.EncodeToString(data.Signature[:]),
+			DepositMessageRoot:    hex.EncodeToString(messageRoot[:]),
+			DepositDataRoot:       hex.EncodeToString(dataRoot[:]),
+			ForkVersion:           hex.EncodeToString(s.network.GenesisForkVersion[:]),
+			NetworkName:           s.network.Name,
+		})
+	}
+
+	return depositData, nil
+}
+
+func (s *ValidatorService) withdrawalCredentials(request *models.ValidatorRequest, key *models.ValidatorKey) ([32]byte, error) {
+	if request.WithdrawalCredentials != "" {
+		return parseWithdrawalCredentials(request.WithdrawalCredentials)
+	}
+
+	withdrawalKey, err := s.withdrawalKey(key)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return eth2.BLSWithdrawalCredentials(withdrawalKey.PublicKey()), nil
+}
+
+// withdrawalKey returns the withdrawal key of a generated key. Keys generated before withdrawal keys were
+// stored have theirs derived from the seed again.
+func (s *ValidatorService) withdrawalKey(key *models.ValidatorKey) (*bls.SecretKey, error) {
+	if key.WithdrawalKey == "" {
+		return bls.DeriveKey(s.seed, bls.WithdrawalKeyPath(key.KeyIndex))
+	}
+	secret, err := hexutil.Decode(key.WithdrawalKey)
+	if err != nil {
+		return nil, fmt.Errorf("validator key %s has no usable withdrawal key: %w", key.Key, err)
+	}
+	return bls.SecretKeyFromBytes(secret)
+}
+
+func (s *ValidatorService) getReadyKeys(requestID string) (*models.ValidatorRequest, []*models.ValidatorKey, error) {
+	request, err := s.repo.GetRequestByID(requestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !request.Status.KeysReady() {
+		return nil, nil, models.ErrKeysNotReady
+	}
+
+	keys, err := s.repo.GetValidatorKeysByRequestID(requestID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Keys deleted through the Keymanager API are managed elsewhere now, so they are neither exported nor
+	// signed with.
+	active := make([]*models.ValidatorKey, 0, len(keys))
+	for _, key := range keys {
+		if !key.Deleted {
+			active = append(active, key)
+		}
+	}
+	return request, active, nil
+}
+
+func decodeSecretKey(key *models.ValidatorKey) (*bls.SecretKey, error) {
+	secret, err := hexutil.Decode(key.SecretKey)
+	if err != nil {
+		return nil, fmt.Errorf("validator key %s has no usable secret key: %w", key.Key, err)
+	}
+	return bls.SecretKeyFromBytes(secret)
+}
+
+// processValidatorCreation generates the keys of a request and reports whether all of them were saved.
+// Every saved key is a checkpoint: a request interrupted by a restart only generates the keys it has not
+// saved yet.
+func (s *ValidatorService) processValidatorCreation(request *models.ValidatorRequest) bool {
+	requestID := request.ID
+
+	existing, err := s.repo.GetValidatorKeysByRequestID(requestID)
+	if err != nil {
+		s.logger.Error("Failed to load validator keys",
+			"error", err,
+			"request_id", requestID)
+
+		err = s.repo.UpdateRequestStatus(requestID, models.StatusFailed, "Error loading validator keys")
+		if err != nil {
+			utils.TasksTotal.WithLabelValues("failed").Inc()
+			s.logger.Error("Failed to update request status", "error", err)
+		}
+		return false
+	}
+	saved := make(map[int]bool, len(existing))
+	for _, key := range existing {
+		saved[key.KeyIndex] = true
+	}
+
 	s.logger.Info("Starting validator creation process",
 		"request_id", requestID,
-		"num_validators", numValidators)
+		"num_validators", request.NumValidators,
+		"start_index", request.StartIndex,
+		"saved_keys", len(saved))
 
 	startTime := time.Now()
 
 	utils.TasksTotal.WithLabelValues("started").Inc()
 
-	var err error
-
-	for i := 0; i < numValidators; i++ {
-		time.Sleep(20 * time.Millisecond)
+	for i := 0; i < request.NumValidators; i++ {
+		index := request.StartIndex + i
+		if saved[index] {
+			continue
+		}
 
-		key, err := generateRandomKey()
+		secretKey, err := bls.DeriveKey(s.seed, bls.SigningKeyPath(index))
+		var withdrawalKey *bls.SecretKey
+		if err == nil {
+			withdrawalKey, err = bls.DeriveKey(s.seed, bls.WithdrawalKeyPath(index))
+		}
 		if err != nil {
-			s.logger.Error("Failed to generate key",
+			s.logger.Error("Failed to derive key",
 				"error", err,
-				"request_id", requestID)
+				"request_id", requestID,
+				"key_index", index)
 
 			err = s.repo.UpdateRequestStatus(requestID, models.StatusFailed, "Error generating validator keys")
 			if err != nil {
 				utils.TasksTotal.WithLabelValues("failed").Inc()
 				s.logger.Error("Failed to update request status", "error", err)
 			}
-			return
+			return false
 		}
 
 		validatorKey := &models.ValidatorKey{
-			ID:           uuid.New().String(),
-			RequestID:    requestID,
-			Key:          key,
-			FeeRecipient: feeRecipient,
+			ID:            uuid.New().String(),
+			RequestID:     requestID,
+			Key:           hexutil.Encode(secretKey.PublicKey()),
+			KeyIndex:      index,
+			SecretKey:     hexutil.Encode(secretKey.Bytes()),
+			WithdrawalKey: hexutil.Encode(withdrawalKey.Bytes()),
+			FeeRecipient:  request.FeeRecipient,
 		}
 
 		err = s.repo.SaveValidatorKey(validatorKey)
@@ -131,16 +414,22 @@ func (s *ValidatorService) processValidatorCreation(requestID string, numValidat
 				utils.TasksTotal.WithLabelValues("failed").Inc()
 				s.logger.Error("Failed to update request status", "error", err)
 			}
-			return
+			return false
 		}
 
 		s.logger.Info("Generated validator key",
-			"key", key,
+			"key", validatorKey.Key,
+			"path", bls.SigningKeyPath(index),
 			"index", i+1,
 			"request_id", requestID)
 	}
 
-	err = s.repo.UpdateRequestStatus(requestID, models.StatusSuccessful, "")
+	status := models.StatusSuccessful
+	if request.Deposit {
+		status = models.StatusDepositing
+	}
+
+	err = s.repo.UpdateRequestStatus(requestID, status, "")
 	if err != nil {
 		s.logger.Error("Failed to update request status",
 			"error", err,
@@ -154,15 +443,61 @@ func (s *ValidatorService) processValidatorCreation(requestID string, numValidat
 	}
 
 	utils.TaskDuration.Observe(time.Since(startTime).Seconds())
+
+	return err == nil
 }
 
-func generateRandomKey() (string, error) {
-	bytes := make([]byte, 32)
-	_, err := rand.Read(bytes)
-	if err != nil {
-		return "", err
+// requestWithdrawalCredentials returns the withdrawal credentials input asks for, or nil for the default
+// BLS credentials of each key's withdrawal key.
+func requestWithdrawalCredentials(input *models.ValidatorRequestInput) (*[32]byte, error) {
+	if input.WithdrawalCredentials != "" {
+		if input.WithdrawalType != "" || input.WithdrawalAddress != "" {
+			return nil, fmt.Errorf("withdrawal credentials cannot be combined with a withdrawal type or address")
+		}
+		credentials, err := parseWithdrawalCredentials(input.WithdrawalCredentials)
+		if err != nil {
+			return nil, err
+		}
+		return &credentials, nil
+	}
+
+	withdrawalType := input.WithdrawalType
+	if withdrawalType == "" && input.WithdrawalAddress != "" {
+		withdrawalType = models.WithdrawalTypeExecution
+	}
+
+	var prefix byte
+	switch withdrawalType {
+	case "", models.WithdrawalTypeBLS:
+		if input.WithdrawalAddress != "" {
+			return nil, fmt.Errorf("BLS withdrawal credentials take no withdrawal address")
+		}
+		return nil, nil
+	case models.WithdrawalTypeExecution:
+		prefix = eth2.ExecutionWithdrawalPrefix
+	case models.WithdrawalTypeCompounding:
+		prefix = eth2.CompoundingWithdrawalPrefix
+	default:
+		return nil, fmt.Errorf("unsupported withdrawal type %q, expected 0x00, 0x01 or 0x02", withdrawalType)
+	}
+
+	if !isValidEthereumAddress(input.WithdrawalAddress) {
+		return nil, fmt.Errorf("invalid withdrawal address format")
+	}
+	credentials := eth2.ExecutionWithdrawalCredentials(prefix, common.HexToAddress(input.WithdrawalAddress))
+	return &credentials, nil
+}
+
+func parseWithdrawalCredentials(value string) ([32]byte, error) {
+	var credentials [32]byte
+
+	decoded, err := hexutil.Decode(value)
+	if err != nil || len(decoded) != len(credentials) {
+		return credentials, fmt.Errorf("withdrawal credentials must be 32 bytes of 0x-prefixed hex")
 	}
-	return hex.EncodeToString(bytes), nil
+	copy(credentials[:], decoded)
+
+	return credentials, eth2.ValidateWithdrawalCredentials(credentials)
 }
 
 func isValidEthereumAddress(address string) bool {
@@ -1,26 +1,42 @@
 package services
 
 import (
+	"encoding/hex"
 	"errors"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/tyler-smith/go-bip39"
 	"log/slog"
 	"os"
+	"stakeway_test_task/internal/bls"
+	"stakeway_test_task/internal/eth2"
+	"stakeway_test_task/internal/keystore"
 	"stakeway_test_task/internal/mocks"
 	"stakeway_test_task/internal/models"
 	"testing"
 	"time"
 )
 
+var testSeed = bip39.NewSeed("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "")
+
 func setupValidatorServiceTest(t *testing.T) (*mocks.RequestRepo, *ValidatorService) {
 	mockRepo := mocks.NewRequestRepo(t)
 
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
 
+	network, err := eth2.NetworkByName("holesky")
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	service := &ValidatorService{
-		repo:   mockRepo,
-		logger: logger,
+		repo:      mockRepo,
+		logger:    logger,
+		seed:      testSeed,
+		network:   network,
+		jobLimits: DefaultJobLimits,
 	}
 
 	return mockRepo, service
@@ -30,7 +46,11 @@ func TestCreateValidatorRequest(t *testing.T) {
 	t.Run("successful request creation", func(t *testing.T) {
 		mockRepo, service := setupValidatorServiceTest(t)
 
-		mockRepo.On("CreateRequest", mock.AnythingOfType("*models.ValidatorRequest")).
+		mockRepo.On("CountJobs", models.JobStageKeys, models.JobStatusPending).Return(0, nil)
+		mockRepo.On("NextKeyIndex").Return(5, nil)
+		mockRepo.On("CreateRequest", mock.MatchedBy(func(request *models.ValidatorRequest) bool {
+			return request.StartIndex == 5
+		})).
 			Return(nil)
 
 		input := &models.ValidatorRequestInput{
@@ -44,9 +64,9 @@ func TestCreateValidatorRequest(t *testing.T) {
 		assert.NotEmpty(t, response.RequestID)
 		assert.Equal(t, "Validator creation in progress", response.Message)
 
-		mockRepo.AssertCalled(t, "CreateRequest", mock.AnythingOfType("*models.ValidatorRequest"))
-
-		time.Sleep(10 * time.Millisecond)
+		mockRepo.AssertNumberOfCalls(t, "CreateRequest", 1)
+		// Keys are generated by the job workers, not by the request.
+		mockRepo.AssertNotCalled(t, "SaveValidatorKey", mock.Anything)
 	})
 
 	t.Run("validation error - negative validators", func(t *testing.T) {
@@ -64,6 +84,22 @@ func TestCreateValidatorRequest(t *testing.T) {
 		assert.Contains(t, err.Error(), "number of validators must be positive")
 	})
 
+	t.Run("validation error - too many validators", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		input := &models.ValidatorRequestInput{
+			NumValidators: maxValidatorsPerRequest + 1,
+			FeeRecipient:  "0x1234567890abcdef1234567890abcdef12345678",
+		}
+
+		response, err := service.CreateValidatorRequest(input)
+
+		assert.ErrorIs(t, err, models.ErrInvalidInput)
+		assert.Nil(t, response)
+		assert.Contains(t, err.Error(), "number of validators must be at most 100")
+		mockRepo.AssertNotCalled(t, "CreateRequest", mock.Anything)
+	})
+
 	t.Run("validation error - invalid ethereum address", func(t *testing.T) {
 		_, service := setupValidatorServiceTest(t)
 
@@ -82,6 +118,8 @@ func TestCreateValidatorRequest(t *testing.T) {
 	t.Run("repository error", func(t *testing.T) {
 		mockRepo, service := setupValidatorServiceTest(t)
 
+		mockRepo.On("CountJobs", models.JobStageKeys, models.JobStatusPending).Return(0, nil)
+		mockRepo.On("NextKeyIndex").Return(0, nil)
 		mockRepo.On("CreateRequest", mock.AnythingOfType("*models.ValidatorRequest")).
 			Return(errors.New("database error"))
 
@@ -96,6 +134,141 @@ func TestCreateValidatorRequest(t *testing.T) {
 		assert.Nil(t, response)
 		assert.Contains(t, err.Error(), "database error")
 	})
+
+	t.Run("validation error - invalid withdrawal credentials", func(t *testing.T) {
+		_, service := setupValidatorServiceTest(t)
+
+		input := &models.ValidatorRequestInput{
+			NumValidators:         1,
+			FeeRecipient:          "0x1234567890abcdef1234567890abcdef12345678",
+			WithdrawalCredentials: "0x0500000000000000000000001234567890abcdef1234567890abcdef12345678",
+		}
+
+		response, err := service.CreateValidatorRequest(input)
+
+		assert.Error(t, err)
+		assert.Nil(t, response)
+		assert.Contains(t, err.Error(), "unsupported withdrawal credentials prefix")
+	})
+
+	t.Run("validation error - deposit amount too high", func(t *testing.T) {
+		_, service := setupValidatorServiceTest(t)
+
+		input := &models.ValidatorRequestInput{
+			NumValidators: 1,
+			FeeRecipient:  "0x1234567890abcdef1234567890abcdef12345678",
+			Amount:        33 * eth2.GweiPerEth,
+		}
+
+		response, err := service.CreateValidatorRequest(input)
+
+		assert.Error(t, err)
+		assert.Nil(t, response)
+		assert.Contains(t, err.Error(), "deposit amount must be between")
+	})
+
+	t.Run("queue full", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		mockRepo.On("CountJobs", models.JobStageKeys, models.JobStatusPending).Return(DefaultJobLimits.QueueSize, nil)
+
+		input := &models.ValidatorRequestInput{
+			NumValidators: 3,
+			FeeRecipient:  "0x1234567890abcdef1234567890abcdef12345678",
+		}
+
+		response, err := service.CreateValidatorRequest(input)
+
+		assert.ErrorIs(t, err, models.ErrQueueFull)
+		assert.Nil(t, response)
+		mockRepo.AssertNotCalled(t, "CreateRequest", mock.Anything)
+	})
+
+	t.Run("key index reservation error", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		mockRepo.On("CountJobs", models.JobStageKeys, models.JobStatusPending).Return(0, nil)
+		mockRepo.On("NextKeyIndex").Return(0, errors.New("database error"))
+
+		input := &models.ValidatorRequestInput{
+			NumValidators: 3,
+			FeeRecipient:  "0x1234567890abcdef1234567890abcdef12345678",
+		}
+
+		response, err := service.CreateValidatorRequest(input)
+
+		assert.Error(t, err)
+		assert.Nil(t, response)
+		mockRepo.AssertNotCalled(t, "CreateRequest", mock.Anything)
+	})
+}
+
+func TestCreateValidatorRequestWithdrawalCredentials(t *testing.T) {
+	address := "0xE8011087b85953f2b0816a236876a9779462f10b"
+
+	tests := []struct {
+		name        string
+		input       models.ValidatorRequestInput
+		credentials string
+		amount      uint64
+	}{
+		{"default BLS", models.ValidatorRequestInput{}, "", eth2.MaxEffectiveBalance},
+		{"explicit BLS", models.ValidatorRequestInput{WithdrawalType: "0x00"}, "", eth2.MaxEffectiveBalance},
+		{"execution address", models.ValidatorRequestInput{WithdrawalAddress: address},
+			"0x010000000000000000000000e8011087b85953f2b0816a236876a9779462f10b", eth2.MaxEffectiveBalance},
+		{"compounding with custom balance", models.ValidatorRequestInput{WithdrawalType: "0x02", WithdrawalAddress: address, Amount: 1000 * eth2.GweiPerEth},
+			"0x020000000000000000000000e8011087b85953f2b0816a236876a9779462f10b", 1000 * eth2.GweiPerEth},
+		{"raw compounding credentials", models.ValidatorRequestInput{WithdrawalCredentials: "0x020000000000000000000000e8011087b85953f2b0816a236876a9779462f10b", Amount: eth2.MaxEffectiveBalanceElectra},
+			"0x020000000000000000000000e8011087b85953f2b0816a236876a9779462f10b", eth2.MaxEffectiveBalanceElectra},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo, service := setupValidatorServiceTest(t)
+
+			var created *models.ValidatorRequest
+			mockRepo.On("CountJobs", models.JobStageKeys, models.JobStatusPending).Return(0, nil)
+			mockRepo.On("NextKeyIndex").Return(0, nil)
+			mockRepo.On("CreateRequest", mock.AnythingOfType("*models.ValidatorRequest")).
+				Run(func(args mock.Arguments) { created = args.Get(0).(*models.ValidatorRequest) }).
+				Return(nil)
+
+			input := tt.input
+			input.NumValidators = 1
+			input.FeeRecipient = "0x1234567890abcdef1234567890abcdef12345678"
+
+			_, err := service.CreateValidatorRequest(&input)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.credentials, created.WithdrawalCredentials)
+			assert.Equal(t, tt.amount, created.Amount)
+		})
+	}
+
+	invalid := map[string]struct {
+		input models.ValidatorRequestInput
+		err   string
+	}{
+		"unknown type":               {models.ValidatorRequestInput{WithdrawalType: "0x03", WithdrawalAddress: address}, "unsupported withdrawal type"},
+		"execution without address":  {models.ValidatorRequestInput{WithdrawalType: "0x01"}, "invalid withdrawal address format"},
+		"BLS with address":           {models.ValidatorRequestInput{WithdrawalType: "0x00", WithdrawalAddress: address}, "take no withdrawal address"},
+		"raw and typed":              {models.ValidatorRequestInput{WithdrawalCredentials: "0x010000000000000000000000e8011087b85953f2b0816a236876a9779462f10b", WithdrawalAddress: address}, "cannot be combined"},
+		"execution above 32 ETH":     {models.ValidatorRequestInput{WithdrawalAddress: address, Amount: 64 * eth2.GweiPerEth}, "deposit amount must be between"},
+		"compounding above 2048 ETH": {models.ValidatorRequestInput{WithdrawalType: "0x02", WithdrawalAddress: address, Amount: eth2.MaxEffectiveBalanceElectra + 1}, "deposit amount must be between"},
+	}
+	for name, tt := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, service := setupValidatorServiceTest(t)
+
+			input := tt.input
+			input.NumValidators = 1
+			input.FeeRecipient = "0x1234567890abcdef1234567890abcdef12345678"
+
+			response, err := service.CreateValidatorRequest(&input)
+
+			assert.ErrorContains(t, err, tt.err)
+			assert.Nil(t, response)
+		})
+	}
 }
 
 func TestGetRequestStatus(t *testing.T) {
@@ -114,8 +287,8 @@ func TestGetRequestStatus(t *testing.T) {
 				UpdatedAt:     time.Now(),
 			}, nil)
 
-		mockRepo.On("GetKeysByRequestID", requestID).
-			Return([]string{"key1", "key2"}, nil)
+		mockRepo.On("GetValidatorKeysByRequestID", requestID).
+			Return([]*models.ValidatorKey{{Key: "key1"}, {Key: "key2"}}, nil)
 
 		response, err := service.GetRequestStatus(requestID)
 
@@ -180,7 +353,7 @@ func TestGetRequestStatus(t *testing.T) {
 				UpdatedAt:     time.Now(),
 			}, nil)
 
-		mockRepo.On("GetKeysByRequestID", requestID).
+		mockRepo.On("GetValidatorKeysByRequestID", requestID).
 			Return(nil, errors.New("database error"))
 
 		response, err := service.GetRequestStatus(requestID)
@@ -191,42 +364,272 @@ func TestGetRequestStatus(t *testing.T) {
 	})
 }
 
+func TestGetKeystores(t *testing.T) {
+	t.Run("successful export", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		requestID := uuid.New().String()
+		secretKey, err := bls.DeriveKey(testSeed, bls.SigningKeyPath(4))
+		assert.NoError(t, err)
+
+		mockRepo.On("GetRequestByID", requestID).
+			Return(&models.ValidatorRequest{ID: requestID, Status: models.StatusSuccessful}, nil)
+		mockRepo.On("GetValidatorKeysByRequestID", requestID).
+			Return([]*models.ValidatorKey{{
+				RequestID: requestID,
+				Key:       hexutil.Encode(secretKey.PublicKey()),
+				KeyIndex:  4,
+				SecretKey: hexutil.Encode(secretKey.Bytes()),
+			}}, nil)
+
+		keystores, err := service.GetKeystores(requestID, "password", keystore.KDFPBKDF2)
+
+		assert.NoError(t, err)
+		assert.Len(t, keystores, 1)
+		assert.Equal(t, bls.SigningKeyPath(4), keystores[0].Path)
+		assert.Equal(t, hex.EncodeToString(secretKey.PublicKey()), keystores[0].Pubkey)
+
+		decrypted, err := keystore.Decrypt(keystores[0], "password")
+		assert.NoError(t, err)
+		assert.Equal(t, secretKey.Bytes(), decrypted)
+	})
+
+	t.Run("keys not ready", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		requestID := uuid.New().String()
+
+		mockRepo.On("GetRequestByID", requestID).
+			Return(&models.ValidatorRequest{ID: requestID, Status: models.StatusStarted}, nil)
+
+		keystores, err := service.GetKeystores(requestID, "password", keystore.KDFPBKDF2)
+
+		assert.ErrorIs(t, err, models.ErrKeysNotReady)
+		assert.Nil(t, keystores)
+		mockRepo.AssertNotCalled(t, "GetValidatorKeysByRequestID", requestID)
+	})
+
+	t.Run("too many keys for scrypt", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		requestID := uuid.New().String()
+		keys := make([]*models.ValidatorKey, maxScryptKeystores+1)
+		for i := range keys {
+			keys[i] = &models.ValidatorKey{RequestID: requestID, KeyIndex: i}
+		}
+		mockRepo.On("GetRequestByID", requestID).
+			Return(&models.ValidatorRequest{ID: requestID, Status: models.StatusSuccessful}, nil)
+		mockRepo.On("GetValidatorKeysByRequestID", requestID).Return(keys, nil)
+
+		keystores, err := service.GetKeystores(requestID, "password", keystore.KDFScrypt)
+
+		assert.ErrorIs(t, err, models.ErrInvalidInput)
+		assert.ErrorContains(t, err, "use kdf=pbkdf2")
+		assert.Nil(t, keystores)
+	})
+}
+
+func TestGetDepositData(t *testing.T) {
+	secretKey, err := bls.DeriveKey(testSeed, bls.SigningKeyPath(0))
+	assert.NoError(t, err)
+
+	keys := []*models.ValidatorKey{{
+		Key:       hexutil.Encode(secretKey.PublicKey()),
+		KeyIndex:  0,
+		SecretKey: hexutil.Encode(secretKey.Bytes()),
+	}}
+
+	t.Run("execution withdrawal credentials", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		requestID := uuid.New().String()
+		credentials := "0x010000000000000000000000e8011087b85953f2b0816a236876a9779462f10b"
+
+		mockRepo.On("GetRequestByID", requestID).
+			Return(&models.ValidatorRequest{
+				ID:                    requestID,
+				Status:                models.StatusSuccessful,
+				WithdrawalCredentials: credentials,
+				Amount:                eth2.MaxEffectiveBalance,
+			}, nil)
+		mockRepo.On("GetValidatorKeysByRequestID", requestID).Return(keys, nil)
+
+		depositData, err := service.GetDepositData(requestID)
+
+		assert.NoError(t, err)
+		assert.Len(t, depositData, 1)
+		assert.Equal(t, hex.EncodeToString(secretKey.PublicKey()), depositData[0].Pubkey)
+		assert.Equal(t, credentials[2:], depositData[0].WithdrawalCredentials)
+		assert.Equal(t, eth2.MaxEffectiveBalance, depositData[0].Amount)
+		assert.Equal(t, "01017000", depositData[0].ForkVersion)
+		assert.Equal(t, "holesky", depositData[0].NetworkName)
+		assertValidDepositData(t, service.network, depositData[0])
+	})
+
+	t.Run("default BLS withdrawal credentials", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		requestID := uuid.New().String()
+
+		mockRepo.On("GetRequestByID", requestID).
+			Return(&models.ValidatorRequest{ID: requestID, Status: models.StatusSuccessful}, nil)
+		mockRepo.On("GetValidatorKeysByRequestID", requestID).Return(keys, nil)
+
+		depositData, err := service.GetDepositData(requestID)
+
+		assert.NoError(t, err)
+		assert.Len(t, depositData, 1)
+
+		withdrawalKey, err := bls.DeriveKey(testSeed, bls.WithdrawalKeyPath(0))
+		assert.NoError(t, err)
+		credentials := eth2.BLSWithdrawalCredentials(withdrawalKey.PublicKey())
+		assert.Equal(t, hex.EncodeToString(credentials[:]), depositData[0].WithdrawalCredentials)
+		assert.Equal(t, eth2.MaxEffectiveBalance, depositData[0].Amount)
+		assertValidDepositData(t, service.network, depositData[0])
+	})
+
+	t.Run("compounding withdrawal credentials", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		requestID := uuid.New().String()
+		credentials := "0x020000000000000000000000e8011087b85953f2b0816a236876a9779462f10b"
+
+		mockRepo.On("GetRequestByID", requestID).
+			Return(&models.ValidatorRequest{
+				ID:                    requestID,
+				Status:                models.StatusSuccessful,
+				WithdrawalCredentials: credentials,
+				Amount:                256 * eth2.GweiPerEth,
+			}, nil)
+		mockRepo.On("GetValidatorKeysByRequestID", requestID).Return(keys, nil)
+
+		depositData, err := service.GetDepositData(requestID)
+
+		assert.NoError(t, err)
+		assert.Len(t, depositData, 1)
+		assert.Equal(t, credentials[2:], depositData[0].WithdrawalCredentials)
+		assert.Equal(t, uint64(256*eth2.GweiPerEth), depositData[0].Amount)
+		assertValidDepositData(t, service.network, depositData[0])
+	})
+
+	t.Run("deleted keys left out", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		requestID := uuid.New().String()
+		deleted := &models.ValidatorKey{Key: "0x" + repeatHex("aa"), KeyIndex: 1, Deleted: true}
+
+		mockRepo.On("GetRequestByID", requestID).
+			Return(&models.ValidatorRequest{ID: requestID, Status: models.StatusSuccessful}, nil)
+		mockRepo.On("GetValidatorKeysByRequestID", requestID).Return(append([]*models.ValidatorKey{deleted}, keys...), nil)
+
+		depositData, err := service.GetDepositData(requestID)
+
+		assert.NoError(t, err)
+		assert.Len(t, depositData, 1)
+		assert.Equal(t, hex.EncodeToString(secretKey.PublicKey()), depositData[0].Pubkey)
+	})
+}
+
+func assertValidDepositData(t *testing.T, network *eth2.Network, data *models.DepositData) {
+	var deposit eth2.DepositData
+	copy(deposit.Pubkey[:], hexutil.MustDecode("0x"+data.Pubkey))
+	copy(deposit.WithdrawalCredentials[:], hexutil.MustDecode("0x"+data.WithdrawalCredentials))
+	deposit.Amount = data.Amount
+	copy(deposit.Signature[:], hexutil.MustDecode("0x"+data.Signature))
+
+	messageRoot := deposit.Message().HashTreeRoot()
+	dataRoot := deposit.HashTreeRoot()
+
+	assert.True(t, eth2.VerifyDeposit(&deposit, network))
+	assert.Equal(t, hex.EncodeToString(messageRoot[:]), data.DepositMessageRoot)
+	assert.Equal(t, hex.EncodeToString(dataRoot[:]), data.DepositDataRoot)
+}
+
 func TestProcessValidatorCreation(t *testing.T) {
 	t.Run("successful key generation", func(t *testing.T) {
 		mockRepo, service := setupValidatorServiceTest(t)
 
-		requestID := uuid.New().String()
-		numValidators := 2
-		feeRecipient := "0x1234567890abcdef1234567890abcdef12345678"
+		request := &models.ValidatorRequest{
+			ID:            uuid.New().String(),
+			NumValidators: 2,
+			FeeRecipient:  "0x1234567890abcdef1234567890abcdef12345678",
+			StartIndex:    3,
+		}
 
+		var savedKeys []*models.ValidatorKey
+		mockRepo.On("GetValidatorKeysByRequestID", request.ID).Return(nil, nil)
 		mockRepo.On("SaveValidatorKey", mock.AnythingOfType("*models.ValidatorKey")).
+			Run(func(args mock.Arguments) {
+				savedKeys = append(savedKeys, args.Get(0).(*models.ValidatorKey))
+			}).
 			Return(nil)
 
-		mockRepo.On("UpdateRequestStatus", requestID, models.StatusSuccessful, "").
+		mockRepo.On("UpdateRequestStatus", request.ID, models.StatusSuccessful, "").
 			Return(nil)
 
-		service.processValidatorCreation(requestID, numValidators, feeRecipient)
+		assert.True(t, service.processValidatorCreation(request))
+
+		mockRepo.AssertNumberOfCalls(t, "SaveValidatorKey", request.NumValidators)
+		mockRepo.AssertCalled(t, "UpdateRequestStatus", request.ID, models.StatusSuccessful, "")
+
+		for i, key := range savedKeys {
+			expected, err := bls.DeriveKey(testSeed, bls.SigningKeyPath(request.StartIndex+i))
+			assert.NoError(t, err)
+
+			assert.Equal(t, request.StartIndex+i, key.KeyIndex)
+			assert.Equal(t, hexutil.Encode(expected.PublicKey()), key.Key)
+			assert.Equal(t, hexutil.Encode(expected.Bytes()), key.SecretKey)
+			assert.Len(t, hexutil.MustDecode(key.Key), bls.PublicKeyLength)
 
-		mockRepo.AssertNumberOfCalls(t, "SaveValidatorKey", numValidators)
-		mockRepo.AssertCalled(t, "UpdateRequestStatus", requestID, models.StatusSuccessful, "")
+			withdrawalKey, err := bls.DeriveKey(testSeed, bls.WithdrawalKeyPath(request.StartIndex+i))
+			assert.NoError(t, err)
+			assert.Equal(t, hexutil.Encode(withdrawalKey.Bytes()), key.WithdrawalKey)
+		}
 	})
 
 	t.Run("error saving validator key", func(t *testing.T) {
 		mockRepo, service := setupValidatorServiceTest(t)
 
-		requestID := uuid.New().String()
-		numValidators := 2
-		feeRecipient := "0x1234567890abcdef1234567890abcdef12345678"
+		request := &models.ValidatorRequest{
+			ID:            uuid.New().String(),
+			NumValidators: 2,
+			FeeRecipient:  "0x1234567890abcdef1234567890abcdef12345678",
+		}
 
+		mockRepo.On("GetValidatorKeysByRequestID", request.ID).Return(nil, nil)
 		mockRepo.On("SaveValidatorKey", mock.AnythingOfType("*models.ValidatorKey")).
 			Return(errors.New("database error"))
 
-		mockRepo.On("UpdateRequestStatus", requestID, models.StatusFailed, mock.Anything).
+		mockRepo.On("UpdateRequestStatus", request.ID, models.StatusFailed, mock.Anything).
 			Return(nil)
 
-		service.processValidatorCreation(requestID, numValidators, feeRecipient)
+		assert.False(t, service.processValidatorCreation(request))
 
 		mockRepo.AssertNumberOfCalls(t, "SaveValidatorKey", 1) // только первая попытка
-		mockRepo.AssertCalled(t, "UpdateRequestStatus", requestID, models.StatusFailed, mock.Anything)
+		mockRepo.AssertCalled(t, "UpdateRequestStatus", request.ID, models.StatusFailed, mock.Anything)
+	})
+
+	t.Run("resume after saved keys", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		request := &models.ValidatorRequest{
+			ID:            uuid.New().String(),
+			NumValidators: 3,
+			FeeRecipient:  "0x1234567890abcdef1234567890abcdef12345678",
+			StartIndex:    10,
+		}
+
+		mockRepo.On("GetValidatorKeysByRequestID", request.ID).
+			Return([]*models.ValidatorKey{{KeyIndex: 10}, {KeyIndex: 11}}, nil)
+		mockRepo.On("SaveValidatorKey", mock.MatchedBy(func(key *models.ValidatorKey) bool {
+			return key.KeyIndex == 12
+		})).
+			Return(nil)
+		mockRepo.On("UpdateRequestStatus", request.ID, models.StatusSuccessful, "").
+			Return(nil)
+
+		assert.True(t, service.processValidatorCreation(request))
+
+		mockRepo.AssertNumberOfCalls(t, "SaveValidatorKey", 1)
 	})
 }
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"stakeway_test_task/internal/eth2"
+	"stakeway_test_task/internal/models"
+)
+
+// SignBLSToExecutionChanges signs a change of every key of a request from its 0x00 withdrawal credentials
+// to execution credentials of the given address. Only requests with the default credentials, computed from
+// the service's withdrawal keys, can be changed.
+func (s *ValidatorService) SignBLSToExecutionChanges(ctx context.Context, requestID string, input *models.BLSToExecutionChangeInput) ([]*models.SignedBLSToExecutionChange, error) {
+	if !isValidEthereumAddress(input.ToExecutionAddress) {
+		return nil, fmt.Errorf("%w: invalid execution address %q", models.ErrInvalidInput, input.ToExecutionAddress)
+	}
+	address := common.HexToAddress(input.ToExecutionAddress)
+
+	request, keys, err := s.getReadyKeys(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request.WithdrawalCredentials != "" {
+		return nil, fmt.Errorf("%w: request %s was not created with the service's BLS withdrawal credentials", models.ErrInvalidInput, requestID)
+	}
+
+	changes := make([]*models.SignedBLSToExecutionChange, 0, len(keys))
+	for _, key := range keys {
+		withdrawalKey, err := s.withdrawalKey(key)
+		if err != nil {
+			return nil, err
+		}
+		validatorIndex, err := s.validatorIndex(ctx, key, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		change := &eth2.BLSToExecutionChange{ValidatorIndex: validatorIndex, ToExecutionAddress: address}
+		copy(change.FromBLSPubkey[:], withdrawalKey.PublicKey())
+		signature := eth2.SignBLSToExecutionChange(withdrawalKey, change, s.network)
+
+		changes = append(changes, &models.SignedBLSToExecutionChange{
+			Message: models.BLSToExecutionChange{
+				ValidatorIndex:     change.ValidatorIndex,
+				FromBLSPubkey:      hexutil.Encode(change.FromBLSPubkey[:]),
+				ToExecutionAddress: hexutil.Encode(change.ToExecutionAddress[:]),
+			},
+			Signature: hexutil.Encode(signature[:]),
+		})
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"stakeway_test_task/internal/bls"
+	"stakeway_test_task/internal/eth2"
+	"stakeway_test_task/internal/models"
+	"testing"
+)
+
+func TestSignBLSToExecutionChanges(t *testing.T) {
+	address := "0x1234567890ABCDEF1234567890abcdef12345678"
+
+	newKey := func(t *testing.T, index int) *models.ValidatorKey {
+		secretKey, err := bls.DeriveKey(testSeed, bls.SigningKeyPath(index))
+		require.NoError(t, err)
+		return &models.ValidatorKey{
+			Key:       hexutil.Encode(secretKey.PublicKey()),
+			KeyIndex:  index,
+			SecretKey: hexutil.Encode(secretKey.Bytes()),
+			Beacon:    &models.BeaconState{Index: uint64(100 + index)},
+		}
+	}
+
+	t.Run("stored and derived withdrawal keys", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		withdrawalKeys := make([]*bls.SecretKey, 2)
+		for i := range withdrawalKeys {
+			var err error
+			withdrawalKeys[i], err = bls.DeriveKey(testSeed, bls.WithdrawalKeyPath(i))
+			require.NoError(t, err)
+		}
+		stored := newKey(t, 0)
+		stored.WithdrawalKey = hexutil.Encode(withdrawalKeys[0].Bytes())
+		keys := []*models.ValidatorKey{stored, newKey(t, 1)}
+
+		requestID := uuid.New().String()
+		mockRepo.On("GetRequestByID", requestID).Return(&models.ValidatorRequest{ID: requestID, Status: models.StatusDeposited}, nil)
+		mockRepo.On("GetValidatorKeysByRequestID", requestID).Return(keys, nil)
+
+		changes, err := service.SignBLSToExecutionChanges(context.Background(), requestID, &models.BLSToExecutionChangeInput{ToExecutionAddress: address})
+
+		require.NoError(t, err)
+		require.Len(t, changes, 2)
+		for i, change := range changes {
+			assert.Equal(t, uint64(100+i), change.Message.ValidatorIndex)
+			assert.Equal(t, hexutil.Encode(withdrawalKeys[i].PublicKey()), change.Message.FromBLSPubkey)
+			assert.Equal(t, "0x1234567890abcdef1234567890abcdef12345678", change.Message.ToExecutionAddress)
+
+			message := &eth2.BLSToExecutionChange{
+				ValidatorIndex:     change.Message.ValidatorIndex,
+				FromBLSPubkey:      [48]byte(hexutil.MustDecode(change.Message.FromBLSPubkey)),
+				ToExecutionAddress: [20]byte(hexutil.MustDecode(change.Message.ToExecutionAddress)),
+			}
+			assert.True(t, eth2.VerifyBLSToExecutionChange(message, [96]byte(hexutil.MustDecode(change.Signature)), service.network))
+		}
+	})
+
+	t.Run("custom withdrawal credentials", func(t *testing.T) {
+		mockRepo, service := setupValidatorServiceTest(t)
+
+		requestID := uuid.New().String()
+		mockRepo.On("GetRequestByID", requestID).Return(&models.ValidatorRequest{
+			ID:                    requestID,
+			Status:                models.StatusSuccessful,
+			WithdrawalCredentials: "0x010000000000000000000000e8011087b85953f2b0816a236876a9779462f10b",
+		}, nil)
+		mockRepo.On("GetValidatorKeysByRequestID", requestID).Return([]*models.ValidatorKey{newKey(t, 0)}, nil)
+
+		_, err := service.SignBLSToExecutionChanges(context.Background(), requestID, &models.BLSToExecutionChangeInput{ToExecutionAddress: address})
+
+		assert.ErrorIs(t, err, models.ErrInvalidInput)
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		_, service := setupValidatorServiceTest(t)
+
+		_, err := service.SignBLSToExecutionChanges(context.Background(), uuid.New().String(), &models.BLSToExecutionChangeInput{ToExecutionAddress: "0x1234"})
+
+		assert.ErrorIs(t, err, models.ErrInvalidInput)
+	})
+}
@@ -38,4 +38,25 @@ var (
 			Buckets: prometheus.DefBuckets,
 		},
 	)
+
+	JobQueueDepth = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "validator_api_job_queue_depth",
+			Help: "The number of validator requests waiting for a key generation worker",
+		},
+	)
+
+	JobWorkers = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "validator_api_job_workers",
+			Help: "The number of key generation workers",
+		},
+	)
+
+	JobWorkersBusy = promauto.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "validator_api_job_workers_busy",
+			Help: "The number of key generation workers processing a request",
+		},
+	)
 )